@@ -781,6 +781,9 @@ func (a *Agent) runOutputs(
 	}
 
 	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
+	for _, output := range unit.outputs {
+		output.PushDownsampled(time.Time{})
+	}
 	cancel()
 	wg.Wait()
 
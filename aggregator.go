@@ -16,3 +16,19 @@ type Aggregator interface {
 	// Reset resets the aggregators caches and aggregates.
 	Reset()
 }
+
+// MergingAggregator is an Aggregator whose aggregates can be split into
+// partial aggregates and merged again. This allows to compute sliding windows
+// from the partial aggregates of the sub-windows.
+type MergingAggregator interface {
+	Aggregator
+
+	// Extract returns the current aggregates as partial aggregate and clears
+	// them, independent of the behavior of Reset.
+	Extract() interface{}
+
+	// Merge adds the given partial aggregate, previously returned by Extract,
+	// to the current aggregates. Partials are merged in chronological order
+	// and must not be modified as they are merged multiple times.
+	Merge(partial interface{})
+}
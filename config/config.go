@@ -33,7 +33,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/parsers/temporary/json_v2"
 	"github.com/influxdata/telegraf/plugins/parsers/temporary/xpath"
 	"github.com/influxdata/telegraf/plugins/processors"
-	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
 	"github.com/influxdata/toml"
 	"github.com/influxdata/toml/ast"
 )
@@ -785,14 +785,26 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 	}
 	output := creator()
 
+	// For outputs with serializers we need to compute the set of
+	// options that is not covered by both, the serializer and the output.
+	// See addInput for details.
+	missThreshold := 0
+	missCount := make(map[string]int)
+	c.setLocalMissingTomlFieldTracker(missCount)
+	defer c.resetMissingTomlFieldTracker()
+
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
-	if t, ok := output.(serializers.SerializerOutput); ok {
-		serializer, err := c.buildSerializer(table)
+	if t, ok := output.(registry.SerializerOutput); ok {
+		missThreshold = 1
+		serializer, err := c.addSerializer(name, table)
 		if err != nil {
 			return err
 		}
 		t.SetSerializer(serializer)
+
+		// The serializer subtable must not be unmarshalled into the output
+		delete(table.Fields, "serializer")
 	}
 
 	outputConfig, err := c.buildOutput(name, table)
@@ -816,6 +828,17 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 
 	ro := models.NewRunningOutput(output, outputConfig, c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
 	c.Outputs = append(c.Outputs, ro)
+
+	// Check the number of misses against the threshold
+	for key, count := range missCount {
+		if count <= missThreshold {
+			continue
+		}
+		if err := c.missingTomlField(nil, key); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -847,6 +870,12 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	}
 	input := creator()
 
+	// Check the common parser settings upfront as parsers might be created
+	// lazily or fall back to the old way of instantiation
+	if _, err := c.buildParser(name, table); err != nil {
+		return err
+	}
+
 	// If the input has a SetParser or SetParserFunc function, it can accept
 	// arbitrary data-formats, so build the requested parser and set it.
 	if t, ok := input.(telegraf.ParserInput); ok {
@@ -988,6 +1017,9 @@ func (c *Config) buildAggregator(name string, tbl *ast.Table) (*models.Aggregato
 	c.getFieldDuration(tbl, "period", &conf.Period)
 	c.getFieldDuration(tbl, "delay", &conf.Delay)
 	c.getFieldDuration(tbl, "grace", &conf.Grace)
+	c.getFieldDuration(tbl, "window", &conf.Window)
+	var hop time.Duration
+	c.getFieldDuration(tbl, "hop", &hop)
 	c.getFieldBool(tbl, "drop_original", &conf.DropOriginal)
 	c.getFieldString(tbl, "name_prefix", &conf.MeasurementPrefix)
 	c.getFieldString(tbl, "name_suffix", &conf.MeasurementSuffix)
@@ -1007,6 +1039,14 @@ func (c *Config) buildAggregator(name string, tbl *ast.Table) (*models.Aggregato
 		return nil, c.firstErr()
 	}
 
+	// The hop is an alias of the period for sliding windows
+	if _, ok := tbl.Fields["hop"]; ok {
+		if _, ok := tbl.Fields["period"]; ok {
+			return nil, fmt.Errorf("only one of period and hop may be set for aggregator %s", name)
+		}
+		conf.Period = hop
+	}
+
 	var err error
 	conf.Filter, err = c.buildFilter(tbl)
 	if err != nil {
@@ -1026,6 +1066,16 @@ func (c *Config) buildParser(name string, tbl *ast.Table) (*models.ParserConfig,
 		Parent:     name,
 		DataFormat: dataformat,
 	}
+	c.getFieldString(tbl, "on_error", &conf.OnError)
+	c.getFieldString(tbl, "dead_letter_file", &conf.DeadLetterFile)
+
+	switch conf.OnError {
+	case "":
+		conf.OnError = "fail"
+	case "fail", "skip", "metric":
+	default:
+		return nil, fmt.Errorf("invalid on_error policy %q", conf.OnError)
+	}
 
 	return conf, nil
 }
@@ -1324,55 +1374,50 @@ func getTagSubtable(c *Config, metricConfig *ast.Table) []json_v2.DataSet {
 	return tags
 }
 
-// buildSerializer grabs the necessary entries from the ast.Table for creating
-// a serializers.Serializer object, and creates it, which can then be added onto
-// an Output object.
-func (c *Config) buildSerializer(tbl *ast.Table) (serializers.Serializer, error) {
-	sc := &serializers.Config{TimestampUnits: 1 * time.Second}
-
-	c.getFieldString(tbl, "data_format", &sc.DataFormat)
+func (c *Config) addSerializer(parentname string, table *ast.Table) (registry.Serializer, error) {
+	// Options given in the serializer subtable take precedence over the ones
+	// in the output section.
+	subtable, hasSubtable := table.Fields["serializer"].(*ast.Table)
 
-	if sc.DataFormat == "" {
-		sc.DataFormat = "influx"
+	var dataformat string
+	c.getFieldString(table, "data_format", &dataformat)
+	if hasSubtable {
+		c.getFieldString(subtable, "data_format", &dataformat)
+	}
+	if dataformat == "" {
+		dataformat = "influx"
 	}
 
-	c.getFieldString(tbl, "prefix", &sc.Prefix)
-	c.getFieldString(tbl, "template", &sc.Template)
-	c.getFieldStringSlice(tbl, "templates", &sc.Templates)
-	c.getFieldString(tbl, "carbon2_format", &sc.Carbon2Format)
-	c.getFieldString(tbl, "carbon2_sanitize_replace_char", &sc.Carbon2SanitizeReplaceChar)
-	c.getFieldBool(tbl, "csv_column_prefix", &sc.CSVPrefix)
-	c.getFieldBool(tbl, "csv_header", &sc.CSVHeader)
-	c.getFieldString(tbl, "csv_separator", &sc.CSVSeparator)
-	c.getFieldString(tbl, "csv_timestamp_format", &sc.TimestampFormat)
-	c.getFieldInt(tbl, "influx_max_line_bytes", &sc.InfluxMaxLineBytes)
-	c.getFieldBool(tbl, "influx_sort_fields", &sc.InfluxSortFields)
-	c.getFieldBool(tbl, "influx_uint_support", &sc.InfluxUintSupport)
-	c.getFieldBool(tbl, "graphite_tag_support", &sc.GraphiteTagSupport)
-	c.getFieldString(tbl, "graphite_tag_sanitize_mode", &sc.GraphiteTagSanitizeMode)
-
-	c.getFieldString(tbl, "graphite_separator", &sc.GraphiteSeparator)
-
-	c.getFieldDuration(tbl, "json_timestamp_units", &sc.TimestampUnits)
-	c.getFieldString(tbl, "json_timestamp_format", &sc.TimestampFormat)
-	c.getFieldString(tbl, "json_transformation", &sc.Transformation)
-
-	c.getFieldBool(tbl, "splunkmetric_hec_routing", &sc.HecRouting)
-	c.getFieldBool(tbl, "splunkmetric_multimetric", &sc.SplunkmetricMultiMetric)
+	creator, ok := registry.Serializers[dataformat]
+	if !ok {
+		return nil, fmt.Errorf("undefined but requested serializer: %s", dataformat)
+	}
+	serializer := creator()
 
-	c.getFieldStringSlice(tbl, "wavefront_source_override", &sc.WavefrontSourceOverride)
-	c.getFieldBool(tbl, "wavefront_use_strict", &sc.WavefrontUseStrict)
-	c.getFieldBool(tbl, "wavefront_disable_prefix_conversion", &sc.WavefrontDisablePrefixConversion)
+	if err := c.toml.UnmarshalTable(table, serializer); err != nil {
+		return nil, err
+	}
 
-	c.getFieldBool(tbl, "prometheus_export_timestamp", &sc.PrometheusExportTimestamp)
-	c.getFieldBool(tbl, "prometheus_sort_metrics", &sc.PrometheusSortMetrics)
-	c.getFieldBool(tbl, "prometheus_string_as_label", &sc.PrometheusStringAsLabel)
+	if hasSubtable {
+		// All options of the subtable belong to the serializer so report
+		// any unknown option directly.
+		missingField := c.toml.MissingField
+		c.toml.MissingField = c.missingTomlField
+		err := c.toml.UnmarshalTable(subtable, serializer)
+		c.toml.MissingField = missingField
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	if c.hasErrs() {
-		return nil, c.firstErr()
+	models.SetLoggerOnPlugin(serializer, models.NewLogger("serializers", dataformat, parentname))
+	if s, ok := serializer.(telegraf.Initializer); ok {
+		if err := s.Init(); err != nil {
+			return nil, fmt.Errorf("initializing %s serializer failed: %w", dataformat, err)
+		}
 	}
 
-	return serializers.NewSerializer(sc)
+	return serializer, nil
 }
 
 // buildOutput parses output specific items from the ast.Table,
@@ -1401,6 +1446,25 @@ func (c *Config) buildOutput(name string, tbl *ast.Table) (*models.OutputConfig,
 	c.getFieldString(tbl, "name_suffix", &oc.NameSuffix)
 	c.getFieldString(tbl, "name_prefix", &oc.NamePrefix)
 
+	if node, ok := tbl.Fields["downsample"]; ok {
+		subtbl, ok := node.(*ast.Table)
+		if !ok {
+			return nil, fmt.Errorf("invalid downsample configuration for output %s", name)
+		}
+		oc.Downsample = &models.DownsampleConfig{}
+		c.getFieldInt(subtbl, "every", &oc.Downsample.Every)
+		c.getFieldDuration(subtbl, "period", &oc.Downsample.Period)
+		c.getFieldString(subtbl, "method", &oc.Downsample.Method)
+		c.getFieldStringMap(subtbl, "fields", &oc.Downsample.Fields)
+		for key := range subtbl.Fields {
+			switch key {
+			case "every", "period", "method", "fields":
+			default:
+				return nil, fmt.Errorf("unknown downsample option %q for output %s", key, name)
+			}
+		}
+	}
+
 	if c.hasErrs() {
 		return nil, c.firstErr()
 	}
@@ -1413,19 +1477,21 @@ func (c *Config) missingTomlField(_ reflect.Type, key string) error {
 	// General options to ignore
 	case "alias",
 		"collection_jitter", "collection_offset",
-		"data_format", "delay", "drop", "drop_original",
+		"data_format", "delay", "downsample", "drop", "drop_original",
 		"fielddrop", "fieldpass", "flush_interval", "flush_jitter",
 		"grace",
+		"hop",
 		"interval",
 		"lvm", // What is this used for?
 		"metric_batch_size", "metric_buffer_limit",
 		"name_override", "name_prefix", "name_suffix", "namedrop", "namepass",
 		"order",
 		"pass", "period", "precision",
-		"tagdrop", "tagexclude", "taginclude", "tagpass", "tags":
+		"tagdrop", "tagexclude", "taginclude", "tagpass", "tags",
+		"window":
 
 	// Parser options to ignore
-	case "data_type", "separator", "tag_keys",
+	case "data_type", "dead_letter_file", "on_error", "separator", "tag_keys",
 		// "templates", // shared with serializers
 		"grok_custom_pattern_files", "grok_custom_patterns", "grok_named_patterns", "grok_patterns",
 		"grok_timezone", "grok_unique_timestamp",
@@ -1433,15 +1499,7 @@ func (c *Config) missingTomlField(_ reflect.Type, key string) error {
 		"value_field_name":
 
 	// Serializer options to ignore
-	case "prefix", "template", "templates",
-		"carbon2_format", "carbon2_sanitize_replace_char",
-		"csv_column_prefix", "csv_header", "csv_separator", "csv_timestamp_format",
-		"graphite_tag_sanitize_mode", "graphite_tag_support", "graphite_separator",
-		"influx_max_line_bytes", "influx_sort_fields", "influx_uint_support",
-		"json_timestamp_format", "json_timestamp_units",
-		"prometheus_export_timestamp", "prometheus_sort_metrics", "prometheus_string_as_label",
-		"splunkmetric_hec_routing", "splunkmetric_multimetric",
-		"wavefront_disable_prefix_conversion", "wavefront_source_override", "wavefront_use_strict":
+	case "prefix", "template", "templates":
 	default:
 		c.unusedFieldsMutex.Lock()
 		c.UnusedFields[key] = true
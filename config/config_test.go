@@ -14,6 +14,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/influxdata/toml"
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
@@ -23,6 +24,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	_ "github.com/influxdata/telegraf/plugins/parsers/all" // Blank import to have all parsers for testing
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
 )
 
 func TestConfig_LoadSingleInputWithEnvVars(t *testing.T) {
@@ -647,6 +649,175 @@ func TestConfig_ParserInterfaceOldFormat(t *testing.T) {
 	}
 }
 
+func TestConfig_ParserErrorHandling(t *testing.T) {
+	conf := `
+[[inputs.parser_test_new]]
+  data_format = "influx"
+  on_error = "metric"
+  dead_letter_file = "/var/lib/telegraf/dead_letter.jsonl"
+`
+	c := NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(conf)))
+	require.Len(t, c.Inputs, 1)
+
+	input, ok := c.Inputs[0].Input.(*MockupInputPluginParserNew)
+	require.True(t, ok)
+	parser, ok := input.Parser.(*models.RunningParser)
+	require.True(t, ok)
+	require.Equal(t, "metric", parser.Config.OnError)
+	require.Equal(t, "/var/lib/telegraf/dead_letter.jsonl", parser.Config.DeadLetterFile)
+
+	conf = `
+[[inputs.parser_test_new]]
+  data_format = "influx"
+  on_error = "ignore"
+`
+	c = NewConfig()
+	require.ErrorContains(t, c.LoadConfigData([]byte(conf)), `invalid on_error policy "ignore"`)
+}
+
+func TestConfig_SerializerInterface(t *testing.T) {
+	conf := `
+[[outputs.serializer_test]]
+  url = "http://localhost"
+  data_format = "mockup"
+  mockup_prefix = "flat"
+  mockup_separator = ","
+
+  [outputs.serializer_test.serializer]
+    mockup_prefix = "sub"
+`
+	c := NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(conf)))
+	require.Len(t, c.Outputs, 1)
+
+	output, ok := c.Outputs[0].Output.(*MockupOutputPluginSerializer)
+	require.True(t, ok)
+	require.Equal(t, "http://localhost", output.URL)
+
+	serializer, ok := output.Serializer.(*MockupSerializer)
+	require.True(t, ok)
+	require.Equal(t, "sub", serializer.Prefix)
+	require.Equal(t, ",", serializer.Separator)
+	require.True(t, serializer.initialized)
+	require.NotNil(t, serializer.Log)
+}
+
+func TestConfig_SerializerInterfaceErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     string
+		expected string
+	}{
+		{
+			name: "unknown format",
+			conf: `
+[[outputs.serializer_test]]
+  data_format = "foo"
+`,
+			expected: "undefined but requested serializer: foo",
+		},
+		{
+			name: "unknown option",
+			conf: `
+[[outputs.serializer_test]]
+  data_format = "mockup"
+  mockup_foo = "bar"
+`,
+			expected: `configuration specified the fields ["mockup_foo"], but they weren't used`,
+		},
+		{
+			name: "option of other serializer",
+			conf: `
+[[outputs.serializer_test]]
+  data_format = "mockup"
+  csv_header = true
+`,
+			expected: `configuration specified the fields ["csv_header"], but they weren't used`,
+		},
+		{
+			name: "unknown subtable option",
+			conf: `
+[[outputs.serializer_test]]
+  [outputs.serializer_test.serializer]
+    data_format = "mockup"
+    url = "http://localhost"
+`,
+			expected: `configuration specified the fields ["url"], but they weren't used`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig()
+			require.ErrorContains(t, c.LoadConfigData([]byte(tt.conf)), tt.expected)
+		})
+	}
+}
+
+func TestConfig_OutputDownsample(t *testing.T) {
+	conf := `
+[[outputs.serializer_test]]
+  url = "http://localhost"
+  data_format = "mockup"
+
+  [outputs.serializer_test.downsample]
+    period = "1m"
+    method = "mean"
+
+    [outputs.serializer_test.downsample.fields]
+      usage_max = "max"
+`
+	c := NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(conf)))
+	require.Len(t, c.Outputs, 1)
+
+	expected := &models.DownsampleConfig{
+		Period: time.Minute,
+		Method: "mean",
+		Fields: map[string]string{"usage_max": "max"},
+	}
+	require.Equal(t, expected, c.Outputs[0].Config.Downsample)
+	require.NoError(t, c.Outputs[0].Init())
+
+	output, ok := c.Outputs[0].Output.(*MockupOutputPluginSerializer)
+	require.True(t, ok)
+	require.Equal(t, "http://localhost", output.URL)
+
+	conf = `
+[[outputs.serializer_test]]
+  data_format = "mockup"
+
+  [outputs.serializer_test.downsample]
+    window = "1m"
+`
+	c = NewConfig()
+	require.ErrorContains(t, c.LoadConfigData([]byte(conf)), `unknown downsample option "window"`)
+}
+
+func TestConfig_AggregatorHop(t *testing.T) {
+	tbl, err := toml.Parse([]byte(`
+window = "1m"
+hop = "10s"
+`))
+	require.NoError(t, err)
+	c := NewConfig()
+	conf, err := c.buildAggregator("basicstats", tbl)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, conf.Window)
+	require.Equal(t, 10*time.Second, conf.Period)
+
+	tbl, err = toml.Parse([]byte(`
+window = "1m"
+period = "30s"
+hop = "10s"
+`))
+	require.NoError(t, err)
+	c = NewConfig()
+	_, err = c.buildAggregator("basicstats", tbl)
+	require.EqualError(t, err, "only one of period and hop may be set for aggregator basicstats")
+}
+
 /*** Mockup INPUT plugin for (old) parser testing to avoid cyclic dependencies ***/
 type MockupInputPluginParserOld struct {
 	Parser     parsers.Parser
@@ -706,6 +877,36 @@ func (m *MockupOuputPlugin) Close() error                          { return nil
 func (m *MockupOuputPlugin) SampleConfig() string                  { return "Mockup test output plugin" }
 func (m *MockupOuputPlugin) Write(metrics []telegraf.Metric) error { return nil }
 
+/*** Mockup OUTPUT plugin for serializer testing to avoid cyclic dependencies ***/
+type MockupOutputPluginSerializer struct {
+	URL        string `toml:"url"`
+	Serializer registry.Serializer
+}
+
+func (m *MockupOutputPluginSerializer) Connect() error                        { return nil }
+func (m *MockupOutputPluginSerializer) Close() error                          { return nil }
+func (m *MockupOutputPluginSerializer) SampleConfig() string                  { return "Mockup serializer test plugin" }
+func (m *MockupOutputPluginSerializer) Write(metrics []telegraf.Metric) error { return nil }
+func (m *MockupOutputPluginSerializer) SetSerializer(s registry.Serializer) {
+	m.Serializer = s
+}
+
+/*** Mockup SERIALIZER for testing to avoid cyclic dependencies ***/
+type MockupSerializer struct {
+	Prefix    string          `toml:"mockup_prefix"`
+	Separator string          `toml:"mockup_separator"`
+	Log       telegraf.Logger `toml:"-"`
+
+	initialized bool
+}
+
+func (m *MockupSerializer) Init() error {
+	m.initialized = true
+	return nil
+}
+func (m *MockupSerializer) Serialize(metric telegraf.Metric) ([]byte, error)         { return nil, nil }
+func (m *MockupSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) { return nil, nil }
+
 // Register the mockup plugin on loading
 func init() {
 	// Register the mockup input plugin for the required names
@@ -719,4 +920,8 @@ func init() {
 	// Register the mockup output plugin for the required names
 	outputs.Add("azure_monitor", func() telegraf.Output { return &MockupOuputPlugin{NamespacePrefix: "Telegraf/"} })
 	outputs.Add("http", func() telegraf.Output { return &MockupOuputPlugin{} })
+	outputs.Add("serializer_test", func() telegraf.Output { return &MockupOutputPluginSerializer{} })
+
+	// Register the mockup serializer
+	registry.Add("mockup", func() registry.Serializer { return &MockupSerializer{} })
 }
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Serializes writes of all parsers as the same file might be shared
+var deadLetterMutex sync.Mutex
+
+// deadLetter is an entry of the dead-letter file describing a payload that
+// could not be parsed
+type deadLetter struct {
+	Time          string `json:"time"`
+	Input         string `json:"input"`
+	Alias         string `json:"alias,omitempty"`
+	DataFormat    string `json:"data_format"`
+	Error         string `json:"error"`
+	Payload       string `json:"payload,omitempty"`
+	PayloadBase64 string `json:"payload_base64,omitempty"`
+}
+
+// writeDeadLetter appends the payload and error as JSON line to the file.
+// Payloads that are not valid UTF-8 are stored base64 encoded.
+func writeDeadLetter(filename string, config *ParserConfig, payload []byte, parseErr error) error {
+	entry := deadLetter{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		Input:      config.Parent,
+		Alias:      config.Alias,
+		DataFormat: config.DataFormat,
+		Error:      parseErr.Error(),
+	}
+	if utf8.Valid(payload) {
+		entry.Payload = string(payload)
+	} else {
+		entry.PayloadBase64 = base64.StdEncoding.EncodeToString(payload)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	deadLetterMutex.Lock()
+	defer deadLetterMutex.Unlock()
+
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,285 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// downsampleSeriesExpiry is the time after which the state of a series
+// without metrics is removed, in addition to the period of the windows.
+const downsampleSeriesExpiry = time.Hour
+
+// DownsampleConfig is the downsampling policy of an output.
+type DownsampleConfig struct {
+	// Every keeps only every Nth metric of each series
+	Every int
+	// Period aggregates the metrics of each series over windows of the period
+	Period time.Duration
+	// Method is the aggregation method of fields without an explicit method
+	Method string
+	// Fields are the aggregation methods by field name
+	Fields map[string]string
+}
+
+// downsampler reduces the resolution of the metrics of an output either by
+// sampling every Nth metric or by aggregating each series over time windows.
+type downsampler struct {
+	every   uint64
+	period  time.Duration
+	method  string
+	methods map[string]string
+
+	sync.Mutex
+	series  map[uint64]*seriesState
+	windows map[windowKey]*window
+	late    int
+}
+
+// seriesState is the state of a series kept across windows.
+type seriesState struct {
+	// count is the number of metrics seen in sampling mode
+	count uint64
+	// open is the number of windows not pushed yet
+	open int
+	// pushed is the start of the latest pushed window
+	pushed time.Time
+	// seen is the time the last metric was added
+	seen time.Time
+}
+
+type windowKey struct {
+	series uint64
+	start  int64
+}
+
+// window is the aggregate of a series within one period.
+type window struct {
+	name   string
+	tags   map[string]string
+	tp     telegraf.ValueType
+	start  time.Time
+	fields map[string]*fieldAggregate
+}
+
+type fieldAggregate struct {
+	method string
+	value  interface{}
+	sum    float64
+	count  int64
+}
+
+func newDownsampler(cfg *DownsampleConfig) (*downsampler, error) {
+	if cfg.Every < 0 {
+		return nil, errors.New("downsample every must not be negative")
+	}
+	if cfg.Period < 0 {
+		return nil, errors.New("downsample period must not be negative")
+	}
+	if cfg.Every > 0 && cfg.Period > 0 {
+		return nil, errors.New("downsample every and period are mutually exclusive")
+	}
+	if cfg.Every == 0 && cfg.Period == 0 {
+		return nil, errors.New("downsample requires either every or period")
+	}
+
+	method := cfg.Method
+	if method == "" {
+		method = "last"
+	}
+	if err := checkDownsampleMethod(method); err != nil {
+		return nil, err
+	}
+	for field, m := range cfg.Fields {
+		if err := checkDownsampleMethod(m); err != nil {
+			return nil, fmt.Errorf("field %q: %w", field, err)
+		}
+	}
+
+	return &downsampler{
+		every:   uint64(cfg.Every),
+		period:  cfg.Period,
+		method:  method,
+		methods: cfg.Fields,
+		series:  make(map[uint64]*seriesState),
+		windows: make(map[windowKey]*window),
+	}, nil
+}
+
+func checkDownsampleMethod(method string) error {
+	switch method {
+	case "last", "mean", "min", "max":
+		return nil
+	}
+	return fmt.Errorf("invalid downsample method %q", method)
+}
+
+// Add passes the metric to the downsampler. In sampling mode the metric is
+// returned if it should be kept, in aggregation mode the metric is consumed
+// and nil is returned. Metrics not returned are dropped.
+func (d *downsampler) Add(m telegraf.Metric) telegraf.Metric {
+	d.Lock()
+	defer d.Unlock()
+
+	id := m.HashID()
+	s, ok := d.series[id]
+	if !ok {
+		s = &seriesState{}
+		d.series[id] = s
+	}
+	s.seen = time.Now()
+
+	if d.every > 0 {
+		count := s.count
+		s.count++
+		if count%d.every == 0 {
+			return m
+		}
+		m.Drop()
+		return nil
+	}
+
+	// Drop metrics of windows already pushed, as writing the window again
+	// would result in a second aggregate for the same time
+	start := m.Time().Truncate(d.period)
+	if !s.pushed.IsZero() && !start.After(s.pushed) {
+		d.late++
+		m.Drop()
+		return nil
+	}
+
+	key := windowKey{series: id, start: start.UnixNano()}
+	w, ok := d.windows[key]
+	if !ok {
+		w = &window{
+			name:   m.Name(),
+			tags:   m.Tags(),
+			tp:     m.Type(),
+			start:  start,
+			fields: make(map[string]*fieldAggregate, len(m.FieldList())),
+		}
+		d.windows[key] = w
+		s.open++
+	}
+	for _, field := range m.FieldList() {
+		agg, ok := w.fields[field.Key]
+		if !ok {
+			method, ok := d.methods[field.Key]
+			if !ok {
+				method = d.method
+			}
+			agg = &fieldAggregate{method: method}
+			w.fields[field.Key] = agg
+		}
+		agg.add(field.Value)
+	}
+	m.Drop()
+	return nil
+}
+
+// Push returns the aggregates of all windows ended before the given time and
+// removes the windows. The zero time pushes all windows. Additionally the
+// number of late metrics dropped since the last push is returned.
+func (d *downsampler) Push(until time.Time) ([]telegraf.Metric, int) {
+	d.Lock()
+	defer d.Unlock()
+
+	var windows []*window
+	for key, w := range d.windows {
+		if !until.IsZero() && w.start.Add(d.period).After(until) {
+			continue
+		}
+		windows = append(windows, w)
+		delete(d.windows, key)
+
+		s := d.series[key.series]
+		s.open--
+		if w.start.After(s.pushed) {
+			s.pushed = w.start
+		}
+	}
+
+	// Remove the state of series without metrics and open windows for a while
+	if !until.IsZero() {
+		expiry := until.Add(-downsampleSeriesExpiry - d.period)
+		for id, s := range d.series {
+			if s.open == 0 && s.seen.Before(expiry) {
+				delete(d.series, id)
+			}
+		}
+	}
+	late := d.late
+	d.late = 0
+
+	// Push the windows in time order to keep the order of the metrics
+	sort.SliceStable(windows, func(i, j int) bool {
+		return windows[i].start.Before(windows[j].start)
+	})
+
+	metrics := make([]telegraf.Metric, 0, len(windows))
+	for _, w := range windows {
+		fields := make(map[string]interface{}, len(w.fields))
+		for key, agg := range w.fields {
+			fields[key] = agg.result()
+		}
+		metrics = append(metrics, metric.New(w.name, w.tags, fields, w.start, w.tp))
+	}
+	return metrics, late
+}
+
+// add adds a value to the aggregate. Values not convertible to float, such
+// as strings, are always aggregated using the last value.
+func (a *fieldAggregate) add(value interface{}) {
+	v, ok := convertFloat(value)
+	if !ok {
+		a.value = value
+		a.sum = 0
+		a.count = 0
+		return
+	}
+	if a.count == 0 {
+		a.value = value
+		a.sum = v
+		a.count = 1
+		return
+	}
+
+	switch a.method {
+	case "last":
+		a.value = value
+	case "min":
+		if current, _ := convertFloat(a.value); v < current {
+			a.value = value
+		}
+	case "max":
+		if current, _ := convertFloat(a.value); v > current {
+			a.value = value
+		}
+	}
+	a.sum += v
+	a.count++
+}
+
+func (a *fieldAggregate) result() interface{} {
+	if a.method != "mean" || a.count == 0 {
+		return a.value
+	}
+	return a.sum / float64(a.count)
+}
+
+func convertFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
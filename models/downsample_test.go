@@ -0,0 +1,220 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestDownsampleEvery(t *testing.T) {
+	conf := &OutputConfig{
+		Downsample: &DownsampleConfig{Every: 3},
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, ro.Init())
+
+	now := time.Unix(0, 0)
+	for i := 0; i < 7; i++ {
+		ro.AddMetric(metric.New("cpu", map[string]string{"cpu": "cpu0"}, map[string]interface{}{"value": i}, now))
+		ro.AddMetric(metric.New("cpu", map[string]string{"cpu": "cpu1"}, map[string]interface{}{"value": i}, now))
+	}
+	require.NoError(t, ro.Write())
+
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"cpu": "cpu0"}, map[string]interface{}{"value": 0}, now),
+		metric.New("cpu", map[string]string{"cpu": "cpu1"}, map[string]interface{}{"value": 0}, now),
+		metric.New("cpu", map[string]string{"cpu": "cpu0"}, map[string]interface{}{"value": 3}, now),
+		metric.New("cpu", map[string]string{"cpu": "cpu1"}, map[string]interface{}{"value": 3}, now),
+		metric.New("cpu", map[string]string{"cpu": "cpu0"}, map[string]interface{}{"value": 6}, now),
+		metric.New("cpu", map[string]string{"cpu": "cpu1"}, map[string]interface{}{"value": 6}, now),
+	}
+	testutil.RequireMetricsEqual(t, expected, m.Metrics())
+}
+
+func TestDownsamplePeriod(t *testing.T) {
+	conf := &OutputConfig{
+		Downsample: &DownsampleConfig{
+			Period: time.Minute,
+			Fields: map[string]string{
+				"mean": "mean",
+				"min":  "min",
+				"max":  "max",
+			},
+		},
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, ro.Init())
+
+	start := time.Now().Truncate(time.Minute).Add(-2 * time.Minute)
+	values := []int64{3, 1, 5, 4}
+	for i, v := range values {
+		ro.AddMetric(metric.New(
+			"cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"last": v, "mean": v, "min": v, "max": v, "status": "ok"},
+			start.Add(time.Duration(i)*10*time.Second),
+		))
+	}
+	// The metric of the current window is not written before the window ended
+	ro.AddMetric(metric.New(
+		"cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"last": 42, "mean": 42, "min": 42, "max": 42},
+		start.Add(2*time.Minute),
+	))
+	require.NoError(t, ro.Write())
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"last": 4, "mean": 3.25, "min": 1, "max": 5, "status": "ok"},
+			start,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, m.Metrics())
+
+	// Incomplete windows are written when pushing all windows
+	ro.PushDownsampled(time.Time{})
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 2)
+	require.Equal(t, start.Add(2*time.Minute), m.Metrics()[1].Time())
+	require.Equal(t, 42.0, m.Metrics()[1].Fields()["mean"])
+}
+
+func TestDownsampleLate(t *testing.T) {
+	conf := &OutputConfig{
+		Downsample: &DownsampleConfig{Period: time.Minute, Method: "max"},
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, ro.Init())
+
+	start := time.Now().Truncate(time.Minute).Add(-3 * time.Minute)
+	ro.AddMetric(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, start.Add(time.Minute)))
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 1)
+
+	// Metrics of the written window or earlier windows are dropped, while
+	// other series and later windows are not affected
+	ro.AddMetric(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, start.Add(time.Minute)))
+	ro.AddMetric(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 3.0}, start))
+	ro.AddMetric(metric.New("cpu", map[string]string{"cpu": "cpu0"}, map[string]interface{}{"value": 4.0}, start))
+	ro.AddMetric(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 5.0}, start.Add(2*time.Minute)))
+	require.NoError(t, ro.Write())
+	ro.PushDownsampled(time.Time{})
+	require.NoError(t, ro.Write())
+
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, start.Add(time.Minute)),
+		metric.New("cpu", map[string]string{"cpu": "cpu0"}, map[string]interface{}{"value": 4.0}, start),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 5.0}, start.Add(2*time.Minute)),
+	}
+	testutil.RequireMetricsEqual(t, expected, m.Metrics(), testutil.SortMetrics())
+}
+
+func TestDownsampleSeriesExpiry(t *testing.T) {
+	tests := []struct {
+		name string
+		conf *DownsampleConfig
+	}{
+		{
+			name: "every",
+			conf: &DownsampleConfig{Every: 2},
+		},
+		{
+			name: "period",
+			conf: &DownsampleConfig{Period: time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := newDownsampler(tt.conf)
+			require.NoError(t, err)
+
+			now := time.Now()
+			d.Add(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, now.Add(-2*time.Minute)))
+			d.Add(metric.New("mem", map[string]string{}, map[string]interface{}{"value": 1.0}, now.Add(-2*time.Minute)))
+			_, _ = d.Push(now)
+			require.Len(t, d.series, 2)
+
+			// Only series without metrics for longer than the expiry are removed
+			for _, s := range d.series {
+				s.seen = now.Add(-downsampleSeriesExpiry - 2*time.Minute)
+				break
+			}
+			_, _ = d.Push(now)
+			require.Len(t, d.series, 1)
+		})
+	}
+}
+
+func TestDownsampleFiltered(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{
+			NamePass: []string{"cpu"},
+		},
+		Downsample: &DownsampleConfig{Period: time.Minute, Method: "max"},
+	}
+	require.NoError(t, conf.Filter.Compile())
+
+	m := &mockOutput{}
+	ro := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, ro.Init())
+
+	start := time.Now().Truncate(time.Minute).Add(-time.Minute)
+	ro.AddMetric(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, start))
+	ro.AddMetric(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, start))
+	ro.AddMetric(metric.New("mem", map[string]string{}, map[string]interface{}{"value": 3.0}, start))
+	require.NoError(t, ro.Write())
+
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, start),
+	}
+	testutil.RequireMetricsEqual(t, expected, m.Metrics())
+}
+
+func TestDownsampleInitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     *DownsampleConfig
+		expected string
+	}{
+		{
+			name:     "no policy",
+			conf:     &DownsampleConfig{},
+			expected: "downsample requires either every or period",
+		},
+		{
+			name:     "both policies",
+			conf:     &DownsampleConfig{Every: 2, Period: time.Minute},
+			expected: "downsample every and period are mutually exclusive",
+		},
+		{
+			name:     "invalid method",
+			conf:     &DownsampleConfig{Period: time.Minute, Method: "median"},
+			expected: `invalid downsample method "median"`,
+		},
+		{
+			name:     "invalid field method",
+			conf:     &DownsampleConfig{Period: time.Minute, Fields: map[string]string{"value": "sum"}},
+			expected: `field "value": invalid downsample method "sum"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ro := NewRunningOutput(&mockOutput{}, &OutputConfig{Downsample: tt.conf}, 1000, 10000)
+			require.EqualError(t, ro.Init(), tt.expected)
+		})
+	}
+}
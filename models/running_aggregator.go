@@ -1,6 +1,8 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -15,6 +17,7 @@ type RunningAggregator struct {
 	Config      *AggregatorConfig
 	periodStart time.Time
 	periodEnd   time.Time
+	partials    []interface{}
 	log         telegraf.Logger
 
 	MetricsPushed   selfstat.Stat
@@ -72,6 +75,7 @@ type AggregatorConfig struct {
 	Period       time.Duration
 	Delay        time.Duration
 	Grace        time.Duration
+	Window       time.Duration
 
 	NameOverride      string
 	MeasurementPrefix string
@@ -85,6 +89,18 @@ func (r *RunningAggregator) LogName() string {
 }
 
 func (r *RunningAggregator) Init() error {
+	if r.Config.Window > 0 {
+		if _, ok := r.Aggregator.(telegraf.MergingAggregator); !ok {
+			return errors.New("aggregator does not support sliding windows")
+		}
+		if r.Config.Period <= 0 {
+			return errors.New("period must be positive for sliding windows")
+		}
+		if r.Config.Window < r.Config.Period || r.Config.Window%r.Config.Period != 0 {
+			return fmt.Errorf("window %s must be a multiple of the period %s", r.Config.Window, r.Config.Period)
+		}
+	}
+
 	if p, ok := r.Aggregator.(telegraf.Initializer); ok {
 		err := p.Init()
 		if err != nil {
@@ -163,10 +179,36 @@ func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
 	until := r.periodEnd.Add(r.Config.Period)
 	r.UpdateWindow(since, until)
 
+	if r.Config.Window > 0 {
+		r.pushWindow(acc)
+		return
+	}
+
 	r.push(acc)
 	r.Aggregator.Reset()
 }
 
+// pushWindow pushes the aggregates of the sliding window ending with the
+// current period. The aggregates of the last period are kept as partial
+// aggregate and the window is computed by merging the partials of all
+// periods covered by the window.
+func (r *RunningAggregator) pushWindow(acc telegraf.Accumulator) {
+	agg := r.Aggregator.(telegraf.MergingAggregator)
+
+	r.partials = append(r.partials, agg.Extract())
+	if n := int(r.Config.Window / r.Config.Period); len(r.partials) > n {
+		r.partials = r.partials[len(r.partials)-n:]
+	}
+
+	for _, partial := range r.partials {
+		agg.Merge(partial)
+	}
+	r.push(acc)
+
+	// Discard the merged aggregates to start the next period from scratch
+	agg.Extract()
+}
+
 func (r *RunningAggregator) push(acc telegraf.Accumulator) {
 	start := time.Now()
 	r.Aggregator.Push(acc)
@@ -262,3 +262,71 @@ func (t *TestAggregator) Add(in telegraf.Metric) {
 		}
 	}
 }
+
+func TestSlidingWindow(t *testing.T) {
+	a := &MergingTestAggregator{}
+	ra := NewRunningAggregator(a, &AggregatorConfig{
+		Name: "TestRunningAggregator",
+		Filter: Filter{
+			NamePass: []string{"*"},
+		},
+		Period: time.Minute,
+		Window: 3 * time.Minute,
+	})
+	require.NoError(t, ra.Config.Filter.Compile())
+	require.NoError(t, ra.Init())
+
+	now := time.Now()
+	ra.UpdateWindow(now, now.Add(ra.Config.Period))
+
+	var acc testutil.Accumulator
+	var sums []int64
+	for i := int64(1); i <= 5; i++ {
+		m := testutil.MustMetric("RITest",
+			map[string]string{},
+			map[string]interface{}{
+				"value": i,
+			},
+			ra.EndPeriod().Add(-time.Second),
+			telegraf.Untyped)
+		require.False(t, ra.Add(m))
+		ra.Push(&acc)
+
+		require.Len(t, acc.Metrics, int(i))
+		sums = append(sums, acc.Metrics[i-1].Fields["sum"].(int64))
+	}
+
+	// The sums of the last three periods are pushed every period
+	require.Equal(t, []int64{1, 3, 6, 9, 12}, sums)
+}
+
+func TestSlidingWindowInitError(t *testing.T) {
+	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+		Name:   "TestRunningAggregator",
+		Period: time.Minute,
+		Window: 3 * time.Minute,
+	})
+	require.ErrorContains(t, ra.Init(), "aggregator does not support sliding windows")
+
+	ra = NewRunningAggregator(&MergingTestAggregator{}, &AggregatorConfig{
+		Name:   "TestRunningAggregator",
+		Period: time.Minute,
+		Window: 90 * time.Second,
+	})
+	require.ErrorContains(t, ra.Init(), "window 1m30s must be a multiple of the period 1m0s")
+}
+
+// MergingTestAggregator sums up values and supports merging partial sums
+type MergingTestAggregator struct {
+	TestAggregator
+}
+
+func (t *MergingTestAggregator) Extract() interface{} {
+	partial := t.sum
+	t.sum = 0
+	return partial
+}
+
+func (t *MergingTestAggregator) Merge(partial interface{}) {
+	t.sum += partial.(int64)
+}
@@ -31,6 +31,8 @@ type OutputConfig struct {
 	NameOverride string
 	NamePrefix   string
 	NameSuffix   string
+
+	Downsample *DownsampleConfig
 }
 
 // RunningOutput contains the output configuration
@@ -49,8 +51,9 @@ type RunningOutput struct {
 
 	BatchReady chan time.Time
 
-	buffer *Buffer
-	log    telegraf.Logger
+	buffer      *Buffer
+	downsampler *downsampler
+	log         telegraf.Logger
 
 	aggMutex sync.Mutex
 }
@@ -119,6 +122,14 @@ func (r *RunningOutput) metricFiltered(metric telegraf.Metric) {
 }
 
 func (r *RunningOutput) Init() error {
+	if r.Config.Downsample != nil {
+		d, err := newDownsampler(r.Config.Downsample)
+		if err != nil {
+			return err
+		}
+		r.downsampler = d
+	}
+
 	if p, ok := r.Output.(telegraf.Initializer); ok {
 		err := p.Init()
 		if err != nil {
@@ -143,6 +154,16 @@ func (r *RunningOutput) AddMetric(metric telegraf.Metric) {
 		return
 	}
 
+	if r.downsampler != nil {
+		if metric = r.downsampler.Add(metric); metric == nil {
+			return
+		}
+	}
+
+	r.add(metric)
+}
+
+func (r *RunningOutput) add(metric telegraf.Metric) {
 	if output, ok := r.Output.(telegraf.AggregatingOutput); ok {
 		r.aggMutex.Lock()
 		output.Add(metric)
@@ -175,9 +196,27 @@ func (r *RunningOutput) AddMetric(metric telegraf.Metric) {
 	}
 }
 
+// PushDownsampled adds the downsampled metrics of all windows ended before
+// the given time to the output. The zero time adds all windows including the
+// incomplete ones, e.g. on shutdown.
+func (r *RunningOutput) PushDownsampled(until time.Time) {
+	if r.downsampler == nil {
+		return
+	}
+	metrics, late := r.downsampler.Push(until)
+	if late > 0 {
+		r.log.Debugf("Dropped %d late metrics of already written downsample windows", late)
+	}
+	for _, metric := range metrics {
+		r.add(metric)
+	}
+}
+
 // Write writes all metrics to the output, stopping when all have been sent on
 // or error.
 func (r *RunningOutput) Write() error {
+	r.PushDownsampled(time.Now())
+
 	if output, ok := r.Output.(telegraf.AggregatingOutput); ok {
 		r.aggMutex.Lock()
 		metrics := output.Push()
@@ -1,12 +1,20 @@
 package models
 
 import (
+	"context"
+	"io"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
+// Inputs already warned about not streaming due to error handling. Inputs
+// create a new parser for every payload, so warn only once per input.
+var streamingDisabledWarned sync.Map
+
 type RunningParser struct {
 	Parser telegraf.Parser
 	Config *ParserConfig
@@ -52,6 +60,14 @@ type ParserConfig struct {
 	Alias       string
 	DataFormat  string
 	DefaultTags map[string]string
+
+	// OnError is the policy for payloads failing to parse, either "fail" to
+	// return the error, "skip" to drop the invalid records or "metric" to
+	// replace the invalid records by an error metric.
+	OnError string
+
+	// DeadLetterFile receives payloads failing to parse, if set
+	DeadLetterFile string
 }
 
 func (r *RunningParser) LogName() string {
@@ -71,6 +87,9 @@ func (r *RunningParser) Init() error {
 func (r *RunningParser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	start := time.Now()
 	m, err := r.Parser.Parse(buf)
+	if err != nil {
+		m, err = r.handleError(buf, m, err)
+	}
 	elapsed := time.Since(start)
 	r.ParseTime.Incr(elapsed.Nanoseconds())
 	r.MetricsParsed.Incr(int64(len(m)))
@@ -81,13 +100,151 @@ func (r *RunningParser) Parse(buf []byte) ([]telegraf.Metric, error) {
 func (r *RunningParser) ParseLine(line string) (telegraf.Metric, error) {
 	start := time.Now()
 	m, err := r.Parser.ParseLine(line)
+	if err != nil {
+		var metrics []telegraf.Metric
+		if m != nil {
+			metrics = []telegraf.Metric{m}
+		}
+		m = nil
+		if metrics, err = r.handleError([]byte(line), metrics, err); len(metrics) > 0 {
+			m = metrics[0]
+		}
+	}
 	elapsed := time.Since(start)
 	r.ParseTime.Incr(elapsed.Nanoseconds())
-	r.MetricsParsed.Incr(1)
+	if m != nil {
+		r.MetricsParsed.Incr(1)
+	}
 
 	return m, err
 }
 
+// handleError applies the error policy to the payload failing to parse. For
+// parsers of record-based formats, the valid records are kept when skipping
+// the invalid ones.
+func (r *RunningParser) handleError(buf []byte, metrics []telegraf.Metric, parseErr error) ([]telegraf.Metric, error) {
+	switch r.Config.OnError {
+	case "skip", "metric":
+	default:
+		r.writeDeadLetter(buf, parseErr)
+		return metrics, parseErr
+	}
+
+	p, ok := r.Parser.(telegraf.RecordParser)
+	if !ok {
+		return r.rejectRecord(buf, parseErr), nil
+	}
+
+	metrics = nil
+	for _, record := range p.SplitRecords(buf) {
+		m, err := r.Parser.Parse(record)
+		if err != nil {
+			m = r.rejectRecord(record, err)
+		}
+		metrics = append(metrics, m...)
+	}
+	return metrics, nil
+}
+
+// rejectRecord drops the invalid record and returns an error metric if
+// requested by the policy
+func (r *RunningParser) rejectRecord(record []byte, parseErr error) []telegraf.Metric {
+	r.writeDeadLetter(record, parseErr)
+
+	if r.Config.OnError == "metric" {
+		r.log.Errorf("Replacing record failing to parse by error metric: %v", parseErr)
+		return []telegraf.Metric{r.errorMetric(record, parseErr)}
+	}
+	r.log.Errorf("Skipping record failing to parse: %v", parseErr)
+	return nil
+}
+
+func (r *RunningParser) writeDeadLetter(record []byte, parseErr error) {
+	if r.Config.DeadLetterFile == "" {
+		return
+	}
+	if err := writeDeadLetter(r.Config.DeadLetterFile, r.Config, record, parseErr); err != nil {
+		r.log.Errorf("Writing to dead-letter file failed: %v", err)
+	}
+}
+
+func (r *RunningParser) errorMetric(record []byte, parseErr error) telegraf.Metric {
+	tags := map[string]string{
+		"input":       r.Config.Parent,
+		"data_format": r.Config.DataFormat,
+	}
+	if r.Config.Alias != "" {
+		tags["alias"] = r.Config.Alias
+	}
+	fields := map[string]interface{}{
+		"error": parseErr.Error(),
+		"size":  int64(len(record)),
+	}
+	return metric.New("parser_error", tags, fields, time.Now())
+}
+
+// ParseStream parses the data of the given reader using the streaming
+// interface of the parser if available. Otherwise, the data is read completely
+// and handed to the parser at once.
+func (r *RunningParser) ParseStream(ctx context.Context, reader io.Reader, fn func(telegraf.Metric) error) error {
+	start := time.Now()
+
+	// Exclude the time spent in the callback from the parse time
+	var callback time.Duration
+	emit := func(m telegraf.Metric) error {
+		r.MetricsParsed.Incr(1)
+		t := time.Now()
+		err := fn(m)
+		callback += time.Since(t)
+		return err
+	}
+
+	// Handling errors requires the complete payload, so data is only streamed
+	// if errors are passed to the caller unmodified
+	var err error
+	p, streaming := r.Parser.(telegraf.StreamingParser)
+	switch {
+	case streaming && !r.handlesErrors():
+		err = p.ParseStream(ctx, reader, emit)
+	case streaming:
+		key := r.Config.Parent + "::" + r.Config.Alias
+		if _, warned := streamingDisabledWarned.LoadOrStore(key, true); !warned {
+			r.log.Warn("Parsing data incrementally is disabled by the error handling, reading complete payloads into memory")
+		}
+		err = r.parseAll(ctx, reader, emit)
+	default:
+		err = r.parseAll(ctx, reader, emit)
+	}
+	r.ParseTime.Incr((time.Since(start) - callback).Nanoseconds())
+
+	return err
+}
+
+func (r *RunningParser) parseAll(ctx context.Context, reader io.Reader, fn func(telegraf.Metric) error) error {
+	buf, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	metrics, perr := r.Parser.Parse(buf)
+	if perr != nil {
+		metrics, perr = r.handleError(buf, metrics, perr)
+	}
+	for _, m := range metrics {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return perr
+}
+
+func (r *RunningParser) handlesErrors() bool {
+	return (r.Config.OnError != "" && r.Config.OnError != "fail") || r.Config.DeadLetterFile != ""
+}
+
 func (r *RunningParser) SetDefaultTags(tags map[string]string) {
 	r.Parser.SetDefaultTags(tags)
 }
@@ -0,0 +1,242 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// MockParser creates one metric per line named like the line
+type MockParser struct{}
+
+func (p *MockParser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	var metrics []telegraf.Metric
+	for _, line := range strings.Split(strings.TrimSpace(string(buf)), "\n") {
+		if line == "invalid" {
+			return metrics, errors.New("invalid line")
+		}
+		m, err := p.ParseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func (p *MockParser) ParseLine(line string) (telegraf.Metric, error) {
+	return metric.New(line, map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0)), nil
+}
+
+func (p *MockParser) SetDefaultTags(map[string]string) {}
+
+// MockStreamingParser additionally records if the streaming interface was used
+type MockStreamingParser struct {
+	MockParser
+	streamed bool
+}
+
+func (p *MockStreamingParser) ParseStream(_ context.Context, r io.Reader, fn func(telegraf.Metric) error) error {
+	p.streamed = true
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	metrics, err := p.Parse(buf)
+	for _, m := range metrics {
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// MockRecordParser additionally allows to parse each line separately
+type MockRecordParser struct {
+	MockParser
+}
+
+func (p *MockRecordParser) SplitRecords(buf []byte) [][]byte {
+	return bytes.Split(buf, []byte("\n"))
+}
+
+func TestRunningParserOnError(t *testing.T) {
+	valid := []telegraf.Metric{
+		metric.New("a", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("b", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+	}
+	errorMetric := metric.New(
+		"parser_error",
+		map[string]string{"input": "file", "data_format": "mock"},
+		map[string]interface{}{"error": "invalid line", "size": int64(7)},
+		time.Unix(0, 0),
+	)
+
+	tests := []struct {
+		name     string
+		parser   telegraf.Parser
+		policy   string
+		expected []telegraf.Metric
+		err      string
+	}{
+		{
+			name:     "fail",
+			parser:   &MockRecordParser{},
+			policy:   "fail",
+			expected: valid[:1],
+			err:      "invalid line",
+		},
+		{
+			name:     "skip records",
+			parser:   &MockRecordParser{},
+			policy:   "skip",
+			expected: valid,
+		},
+		{
+			name:     "skip payload",
+			parser:   &MockParser{},
+			policy:   "skip",
+			expected: nil,
+		},
+		{
+			name:     "error metric for record",
+			parser:   &MockRecordParser{},
+			policy:   "metric",
+			expected: []telegraf.Metric{valid[0], errorMetric, valid[1]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			running := NewRunningParser(tt.parser, &ParserConfig{
+				Parent:     "file",
+				DataFormat: "mock",
+				OnError:    tt.policy,
+			})
+
+			actual, err := running.Parse([]byte("a\ninvalid\nb"))
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestRunningParserDeadLetter(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dead_letter.jsonl")
+	running := NewRunningParser(&MockRecordParser{}, &ParserConfig{
+		Parent:         "file",
+		Alias:          "local",
+		DataFormat:     "mock",
+		OnError:        "skip",
+		DeadLetterFile: filename,
+	})
+
+	_, err := running.Parse([]byte("a\ninvalid\nb"))
+	require.NoError(t, err)
+	_, err = running.Parse([]byte("invalid"))
+	require.NoError(t, err)
+
+	buf, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	require.Len(t, lines, 2)
+
+	for _, line := range lines {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		require.NotEmpty(t, entry["time"])
+		delete(entry, "time")
+		require.Equal(t, map[string]interface{}{
+			"input":       "file",
+			"alias":       "local",
+			"data_format": "mock",
+			"error":       "invalid line",
+			"payload":     "invalid",
+		}, entry)
+	}
+}
+
+func TestRunningParserParseStream(t *testing.T) {
+	expected := []telegraf.Metric{
+		metric.New("a", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("b", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+	}
+
+	streaming := &MockStreamingParser{}
+	parsers := map[string]telegraf.Parser{
+		"fallback":  &MockParser{},
+		"streaming": streaming,
+	}
+
+	for name, parser := range parsers {
+		t.Run(name, func(t *testing.T) {
+			running := NewRunningParser(parser, &ParserConfig{DataFormat: name})
+
+			var actual []telegraf.Metric
+			err := running.ParseStream(context.Background(), strings.NewReader("a\nb\ninvalid\nc"), func(m telegraf.Metric) error {
+				actual = append(actual, m)
+				return nil
+			})
+			require.ErrorContains(t, err, "invalid line")
+			testutil.RequireMetricsEqual(t, expected, actual)
+			require.EqualValues(t, 2, running.MetricsParsed.Get())
+		})
+	}
+	require.True(t, streaming.streamed)
+}
+
+func TestRunningParserParseStreamErrorHandling(t *testing.T) {
+	streaming := &MockStreamingParser{}
+	running := NewRunningParser(streaming, &ParserConfig{Parent: "stream_skip", DataFormat: "streaming", OnError: "skip"})
+
+	// Skipping invalid data requires the complete payload, which is skipped
+	// as a whole as the parser cannot split it into records
+	var actual []telegraf.Metric
+	err := running.ParseStream(context.Background(), strings.NewReader("a\ninvalid\nb"), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Empty(t, actual)
+	require.False(t, streaming.streamed)
+
+	_, warned := streamingDisabledWarned.Load("stream_skip::")
+	require.True(t, warned)
+}
+
+func TestRunningParserParseStreamStop(t *testing.T) {
+	running := NewRunningParser(&MockParser{}, &ParserConfig{DataFormat: "stop"})
+
+	stop := errors.New("stop")
+	var count int
+	err := running.ParseStream(context.Background(), strings.NewReader("a\nb\nc"), func(telegraf.Metric) error {
+		count++
+		return stop
+	})
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, 1, count)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = running.ParseStream(ctx, strings.NewReader("a\nb\nc"), func(telegraf.Metric) error {
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
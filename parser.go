@@ -1,5 +1,10 @@
 package telegraf
 
+import (
+	"context"
+	"io"
+)
+
 // Parser is an interface defining functions that a parser plugin must satisfy.
 type Parser interface {
 	// Parse takes a byte buffer separated by newlines
@@ -22,6 +27,32 @@ type Parser interface {
 	SetDefaultTags(tags map[string]string)
 }
 
+// StreamingParser is an optional interface for parsers able to process data
+// incrementally from a reader instead of requiring the complete payload in
+// memory.
+type StreamingParser interface {
+	Parser
+
+	// ParseStream reads data from the given reader and calls fn for each
+	// parsed metric. Parsing stops at the end of the data, if the context is
+	// cancelled or if fn returns an error. The error is returned unmodified
+	// in the latter case.
+	//
+	// Must not be called concurrently on the same parser.
+	ParseStream(ctx context.Context, r io.Reader, fn func(Metric) error) error
+}
+
+// RecordParser is an optional interface for parsers of formats consisting of
+// independent records, e.g. lines, allowing to skip invalid records instead
+// of dropping the whole payload.
+type RecordParser interface {
+	Parser
+
+	// SplitRecords splits the data into records that can each be passed to
+	// Parse on their own.
+	SplitRecords(buf []byte) [][]byte
+}
+
 type ParserFunc func() (Parser, error)
 
 // ParserInput is an interface for input plugins that are able to parse
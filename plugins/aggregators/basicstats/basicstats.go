@@ -269,6 +269,72 @@ func (b *BasicStats) Reset() {
 	b.cache = make(map[uint64]aggregate)
 }
 
+// Extract returns the current aggregates and clears them
+func (b *BasicStats) Extract() interface{} {
+	partial := b.cache
+	b.Reset()
+	return partial
+}
+
+// Merge adds the partial aggregates returned by Extract
+func (b *BasicStats) Merge(partial interface{}) {
+	for id, other := range partial.(map[uint64]aggregate) {
+		a, ok := b.cache[id]
+		if !ok {
+			a = aggregate{
+				name:   other.name,
+				tags:   other.tags,
+				fields: make(map[string]basicstats, len(other.fields)),
+			}
+			b.cache[id] = a
+		}
+		for k, v := range other.fields {
+			if current, ok := a.fields[k]; ok {
+				a.fields[k] = mergeStats(current, v)
+			} else {
+				a.fields[k] = v
+			}
+		}
+	}
+}
+
+// mergeStats combines the statistics of two disjoint sets of values
+func mergeStats(x, y basicstats) basicstats {
+	// Order the sets in time, diff, rate and interval are computed from the
+	// first value of the earlier and the last value of the later set.
+	if y.TIME.Before(x.TIME) {
+		x, y = y, x
+	}
+
+	//https://en.wikipedia.org/wiki/Algorithms_for_calculating_variance#Parallel_algorithm
+	n := x.count + y.count
+	delta := y.mean - x.mean
+	merged := basicstats{
+		count: n,
+		min:   math.Min(x.min, y.min),
+		max:   math.Max(x.max, y.max),
+		sum:   x.sum + y.sum,
+		mean:  x.mean + delta*y.count/n,
+		M2:    x.M2 + y.M2 + delta*delta*x.count*y.count/n,
+		LAST:  x.LAST,
+		TIME:  x.TIME,
+	}
+
+	// The last value of a set is its first value plus the difference
+	merged.diff = y.LAST + y.diff - x.LAST
+	merged.interval = y.TIME.Add(y.interval).Sub(x.TIME)
+	if x.interval > merged.interval {
+		// The earlier set also contains the last value
+		merged.diff = x.diff
+		merged.interval = x.interval
+	}
+	if merged.interval != 0 {
+		merged.rate = merged.diff / merged.interval.Seconds()
+	}
+
+	return merged
+}
+
 func convert(in interface{}) (float64, bool) {
 	switch v := in.(type) {
 	case float64:
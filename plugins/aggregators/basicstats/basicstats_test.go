@@ -5,8 +5,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/require"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -706,3 +708,50 @@ func TestBasicStatsWithDefaultStats(t *testing.T) {
 	require.True(t, acc.HasField("m1", "a_s2"))
 	require.False(t, acc.HasField("m1", "a_sum"))
 }
+
+// Test that merging partial aggregates yields the same result as adding all
+// metrics to a single aggregate
+func TestBasicStatsMerge(t *testing.T) {
+	stats := []string{"count", "min", "max", "mean", "s2", "stdev", "sum", "diff", "rate", "interval"}
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	var metrics []telegraf.Metric
+	for i, v := range []float64{3, 1, 4, 1, 5, 9, 2, 6, 5, 3} {
+		metrics = append(metrics, metric.New("m1",
+			map[string]string{"foo": "bar"},
+			map[string]interface{}{"a": v},
+			start.Add(time.Duration(i)*time.Second),
+		))
+	}
+
+	expected := NewBasicStats()
+	expected.Stats = stats
+	expected.Log = testutil.Logger{}
+	require.NoError(t, expected.Init())
+	for _, m := range metrics {
+		expected.Add(m)
+	}
+	var expectedAcc testutil.Accumulator
+	expected.Push(&expectedAcc)
+
+	aggregator := NewBasicStats()
+	aggregator.Stats = stats
+	aggregator.Log = testutil.Logger{}
+	require.NoError(t, aggregator.Init())
+	var partials []interface{}
+	for _, part := range [][]telegraf.Metric{metrics[:4], metrics[4:5], metrics[5:]} {
+		for _, m := range part {
+			aggregator.Add(m)
+		}
+		partials = append(partials, aggregator.Extract())
+	}
+	// Merging must not modify the partials so merge them twice
+	for i := 0; i < 2; i++ {
+		for _, partial := range partials {
+			aggregator.Merge(partial)
+		}
+		var acc testutil.Accumulator
+		aggregator.Push(&acc)
+		testutil.RequireMetricsEqual(t, expectedAcc.GetTelegrafMetrics(), acc.GetTelegrafMetrics(), cmpopts.EquateApprox(0, 1e-9), testutil.IgnoreTime())
+		aggregator.Reset()
+	}
+}
@@ -0,0 +1,144 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package cardinality
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+// DO NOT REMOVE THE NEXT TWO LINES! This is required to embed the sampleConfig data.
+//go:embed sample.conf
+var sampleConfig string
+
+type Cardinality struct {
+	Fields     []string        `toml:"fields"`
+	Tags       []string        `toml:"tags"`
+	Precision  int             `toml:"precision"`
+	EmitSketch bool            `toml:"emit_sketch"`
+	Log        telegraf.Logger `toml:"-"`
+
+	cache map[uint64]aggregate
+}
+
+type aggregate struct {
+	name     string
+	tags     map[string]string
+	sketches map[string]*sketch
+}
+
+func (*Cardinality) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *Cardinality) Init() error {
+	if len(c.Fields) == 0 && len(c.Tags) == 0 {
+		return fmt.Errorf("no fields or tags configured")
+	}
+
+	// Test the precision upfront to avoid failing on every metric
+	if _, err := newSketch(c.Precision); err != nil {
+		return err
+	}
+
+	c.Reset()
+
+	return nil
+}
+
+func (c *Cardinality) Add(in telegraf.Metric) {
+	// The tags we count the values for cannot be part of the series,
+	// otherwise every value would end up in a series of its own.
+	h := fnv.New64a()
+	h.Write([]byte(in.Name()))
+	h.Write([]byte("\n"))
+	tags := make(map[string]string, len(in.TagList()))
+	for _, tag := range in.TagList() {
+		if c.isCountedTag(tag.Key) {
+			continue
+		}
+		h.Write([]byte(tag.Key))
+		h.Write([]byte("\n"))
+		h.Write([]byte(tag.Value))
+		h.Write([]byte("\n"))
+		tags[tag.Key] = tag.Value
+	}
+	id := h.Sum64()
+
+	a, found := c.cache[id]
+	if !found {
+		a = aggregate{
+			name:     in.Name(),
+			tags:     tags,
+			sketches: make(map[string]*sketch),
+		}
+		c.cache[id] = a
+	}
+
+	for _, key := range c.Fields {
+		if v, ok := in.GetField(key); ok {
+			c.insert(a, key, fmt.Sprintf("%v", v))
+		}
+	}
+	for _, key := range c.Tags {
+		if v, ok := in.GetTag(key); ok {
+			c.insert(a, key, v)
+		}
+	}
+}
+
+func (c *Cardinality) Push(acc telegraf.Accumulator) {
+	for _, a := range c.cache {
+		if len(a.sketches) == 0 {
+			continue
+		}
+
+		fields := make(map[string]interface{}, len(a.sketches))
+		for key, s := range a.sketches {
+			fields[key+"_distinct"] = s.Estimate()
+			if !c.EmitSketch {
+				continue
+			}
+			buf, err := s.MarshalBinary()
+			if err != nil {
+				c.Log.Errorf("Serializing sketch of %q failed: %v", key, err)
+				continue
+			}
+			fields[key+"_sketch"] = base64.StdEncoding.EncodeToString(buf)
+		}
+		acc.AddFields(a.name, fields, a.tags)
+	}
+}
+
+func (c *Cardinality) Reset() {
+	c.cache = make(map[uint64]aggregate)
+}
+
+func (c *Cardinality) insert(a aggregate, key, value string) {
+	s, found := a.sketches[key]
+	if !found {
+		// This should never error out as we tested it in Init()
+		s, _ = newSketch(c.Precision)
+		a.sketches[key] = s
+	}
+	s.Insert([]byte(value))
+}
+
+func (c *Cardinality) isCountedTag(key string) bool {
+	for _, t := range c.Tags {
+		if t == key {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	aggregators.Add("cardinality", func() telegraf.Aggregator {
+		return &Cardinality{Precision: 14}
+	})
+}
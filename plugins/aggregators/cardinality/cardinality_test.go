@@ -0,0 +1,193 @@
+package cardinality
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigInvalid(t *testing.T) {
+	c := Cardinality{Precision: 14}
+	err := c.Init()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no fields or tags configured")
+
+	c = Cardinality{Fields: []string{"value"}, Precision: 3}
+	err = c.Init()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "precision 3 out of range")
+
+	c = Cardinality{Fields: []string{"value"}, Precision: 19}
+	err = c.Init()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "precision 19 out of range")
+}
+
+func TestDistinctFields(t *testing.T) {
+	c := Cardinality{Fields: []string{"client", "status"}, Precision: 14}
+	require.NoError(t, c.Init())
+
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		c.Add(testutil.MustMetric(
+			"access",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"client": fmt.Sprintf("10.0.0.%d", i%50),
+				"status": int64(200 + i%3),
+				"bytes":  int64(i),
+			},
+			now,
+		))
+	}
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"access",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"client_distinct": uint64(50),
+				"status_distinct": uint64(3),
+			},
+			now,
+		),
+	}
+
+	var acc testutil.Accumulator
+	c.Push(&acc)
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestDistinctTags(t *testing.T) {
+	c := Cardinality{Tags: []string{"user"}, Precision: 14}
+	require.NoError(t, c.Init())
+
+	now := time.Now()
+	for i := 0; i < 30; i++ {
+		c.Add(testutil.MustMetric(
+			"login",
+			map[string]string{"host": "a", "user": "user" + strconv.Itoa(i%10)},
+			map[string]interface{}{"value": 1},
+			now,
+		))
+		c.Add(testutil.MustMetric(
+			"login",
+			map[string]string{"host": "b", "user": "user" + strconv.Itoa(i%20)},
+			map[string]interface{}{"value": 1},
+			now,
+		))
+	}
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"login",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"user_distinct": uint64(10)},
+			now,
+		),
+		testutil.MustMetric(
+			"login",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"user_distinct": uint64(20)},
+			now,
+		),
+	}
+
+	var acc testutil.Accumulator
+	c.Push(&acc)
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestReset(t *testing.T) {
+	c := Cardinality{Fields: []string{"value"}, Precision: 14}
+	require.NoError(t, c.Init())
+
+	c.Add(testutil.MustMetric("test", map[string]string{}, map[string]interface{}{"value": 1}, time.Now()))
+	c.Reset()
+
+	var acc testutil.Accumulator
+	c.Push(&acc)
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestEmitSketch(t *testing.T) {
+	c := Cardinality{Fields: []string{"value"}, Precision: 10, EmitSketch: true}
+	require.NoError(t, c.Init())
+
+	for i := 0; i < 1000; i++ {
+		c.Add(testutil.MustMetric("test", map[string]string{}, map[string]interface{}{"value": i}, time.Now()))
+	}
+
+	var acc testutil.Accumulator
+	c.Push(&acc)
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 1)
+
+	field, found := metrics[0].GetField("value_sketch")
+	require.True(t, found)
+	encoded, ok := field.(string)
+	require.True(t, ok)
+	buf, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+
+	var s sketch
+	require.NoError(t, s.UnmarshalBinary(buf))
+	require.Equal(t, uint8(10), s.precision)
+
+	estimate, found := metrics[0].GetField("value_distinct")
+	require.True(t, found)
+	require.Equal(t, estimate, s.Estimate())
+}
+
+func TestSketchAccuracy(t *testing.T) {
+	for _, precision := range []int{10, 14, 18} {
+		// Allow for four times the standard error
+		tolerance := 4 * 1.04 / math.Sqrt(float64(uint64(1)<<precision))
+		for _, n := range []int{100, 10000, 500000} {
+			t.Run(fmt.Sprintf("p%d_n%d", precision, n), func(t *testing.T) {
+				s, err := newSketch(precision)
+				require.NoError(t, err)
+				for i := 0; i < n; i++ {
+					s.Insert([]byte(strconv.Itoa(i)))
+				}
+				require.InEpsilon(t, float64(n), float64(s.Estimate()), tolerance)
+			})
+		}
+	}
+}
+
+func TestSketchMerge(t *testing.T) {
+	a, err := newSketch(14)
+	require.NoError(t, err)
+	b, err := newSketch(14)
+	require.NoError(t, err)
+	for i := 0; i < 20000; i++ {
+		if i < 15000 {
+			a.Insert([]byte(strconv.Itoa(i)))
+		}
+		if i >= 5000 {
+			b.Insert([]byte(strconv.Itoa(i)))
+		}
+	}
+	require.NoError(t, a.Merge(b))
+	require.InEpsilon(t, 20000, float64(a.Estimate()), 0.03)
+
+	c, err := newSketch(12)
+	require.NoError(t, err)
+	require.Error(t, a.Merge(c))
+}
+
+func TestSketchUnmarshalInvalid(t *testing.T) {
+	var s sketch
+	require.ErrorContains(t, s.UnmarshalBinary([]byte{}), "too short")
+	require.ErrorContains(t, s.UnmarshalBinary([]byte{2, 14}), "unsupported sketch version")
+	require.ErrorContains(t, s.UnmarshalBinary([]byte{1, 20}), "out of range")
+	require.ErrorContains(t, s.UnmarshalBinary([]byte{1, 4, 0, 0}), "invalid number of registers")
+}
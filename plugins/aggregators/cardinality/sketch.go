@@ -0,0 +1,136 @@
+package cardinality
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+const (
+	minPrecision = 4
+	maxPrecision = 18
+
+	sketchVersion = 1
+)
+
+// sketch is a dense HyperLogLog sketch using 64-bit hashes as proposed for
+// HyperLogLog++. Using the full 64-bit hash removes the need for the large
+// range correction, small cardinalities are estimated using linear counting.
+type sketch struct {
+	precision uint8
+	registers []uint8
+}
+
+func newSketch(precision int) (*sketch, error) {
+	if precision < minPrecision || precision > maxPrecision {
+		return nil, fmt.Errorf("precision %d out of range [%d,%d]", precision, minPrecision, maxPrecision)
+	}
+	return &sketch{
+		precision: uint8(precision),
+		registers: make([]uint8, 1<<precision),
+	}, nil
+}
+
+// Insert adds the given value to the sketch
+func (s *sketch) Insert(value []byte) {
+	h := fnv.New64a()
+	h.Write(value)
+	x := mix(h.Sum64())
+
+	// The first bits of the hash select the register, the number of leading
+	// zeros in the remaining bits determine the rank of the value.
+	idx := x >> (64 - s.precision)
+	w := x<<s.precision | 1<<(s.precision-1)
+	rank := uint8(bits.LeadingZeros64(w)) + 1
+	if rank > s.registers[idx] {
+		s.registers[idx] = rank
+	}
+}
+
+// Merge combines the other sketch into this one
+func (s *sketch) Merge(other *sketch) error {
+	if s.precision != other.precision {
+		return fmt.Errorf("cannot merge sketches of different precision %d and %d", s.precision, other.precision)
+	}
+	for i, r := range other.registers {
+		if r > s.registers[i] {
+			s.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// Estimate returns the estimated number of distinct values added
+func (s *sketch) Estimate() uint64 {
+	m := float64(len(s.registers))
+
+	var sum float64
+	var zeros int
+	for _, r := range s.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := alpha(len(s.registers)) * m * m / sum
+
+	// Use linear counting for small cardinalities where the raw estimate is
+	// strongly biased.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(estimate))
+}
+
+// MarshalBinary serializes the sketch as version, precision and registers
+func (s *sketch) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 2+len(s.registers))
+	buf = append(buf, sketchVersion, s.precision)
+	return append(buf, s.registers...), nil
+}
+
+// UnmarshalBinary restores a sketch serialized with MarshalBinary
+func (s *sketch) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errors.New("sketch data too short")
+	}
+	if data[0] != sketchVersion {
+		return fmt.Errorf("unsupported sketch version %d", data[0])
+	}
+	precision := data[1]
+	if precision < minPrecision || precision > maxPrecision {
+		return fmt.Errorf("precision %d out of range [%d,%d]", precision, minPrecision, maxPrecision)
+	}
+	if len(data)-2 != 1<<precision {
+		return fmt.Errorf("invalid number of registers %d for precision %d", len(data)-2, precision)
+	}
+	s.precision = precision
+	s.registers = make([]uint8, 1<<precision)
+	copy(s.registers, data[2:])
+	return nil
+}
+
+func alpha(m int) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/float64(m))
+}
+
+// mix is the finalizer of MurmurHash3 improving the avalanche behavior of
+// FNV for short inputs
+func mix(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -229,6 +229,41 @@ func (h *HistogramAggregator) Reset() {
 	}
 }
 
+// Extract returns the current counts and clears them independent of the
+// reset setting
+func (h *HistogramAggregator) Extract() interface{} {
+	partial := h.cache
+	h.resetCache()
+	return partial
+}
+
+// Merge adds the partial counts returned by Extract
+func (h *HistogramAggregator) Merge(partial interface{}) {
+	for id, other := range partial.(map[uint64]metricHistogramCollection) {
+		agr, ok := h.cache[id]
+		if !ok {
+			agr = metricHistogramCollection{
+				name:                other.name,
+				tags:                other.tags,
+				histogramCollection: make(map[string]counts, len(other.histogramCollection)),
+			}
+		}
+		for field, c := range other.histogramCollection {
+			if agr.histogramCollection[field] == nil {
+				agr.histogramCollection[field] = make(counts, len(c))
+			}
+			for i, count := range c {
+				agr.histogramCollection[field][i] += count
+			}
+		}
+		if other.expireTime.After(agr.expireTime) {
+			agr.expireTime = other.expireTime
+		}
+		agr.updated = agr.updated || other.updated
+		h.cache[id] = agr
+	}
+}
+
 // resetCache resets cached counts(hits) in the buckets
 func (h *HistogramAggregator) resetCache() {
 	h.cache = make(map[uint64]metricHistogramCollection)
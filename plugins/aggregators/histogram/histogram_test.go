@@ -360,3 +360,32 @@ func assertContainsTaggedField(t *testing.T, acc *testutil.Accumulator, metricNa
 
 	require.Fail(t, fmt.Sprintf("unknown measurement '%s' with tags: %v, fields: %v", metricName, tags, fields))
 }
+
+// TestHistogramMerge tests merging partial counts ignoring the reset setting
+func TestHistogramMerge(t *testing.T) {
+	var cfg []config
+	cfg = append(cfg, config{Metric: "first_metric_name", Fields: []string{"a"}, Buckets: []float64{0.0, 10.0, 20.0, 30.0, 40.0}})
+	histogram := NewTestHistogram(cfg, false, true, false).(*HistogramAggregator)
+
+	histogram.Add(firstMetric1)
+	p1 := histogram.Extract()
+	histogram.Add(firstMetric2)
+	p2 := histogram.Extract()
+
+	// Merging must not modify the partials so merge them twice
+	for i := 0; i < 2; i++ {
+		acc := &testutil.Accumulator{}
+		histogram.Merge(p1)
+		histogram.Merge(p2)
+		histogram.Push(acc)
+		histogram.Extract()
+
+		require.Len(t, acc.Metrics, 6, "Incorrect number of metrics")
+		assertContainsTaggedField(t, acc, "first_metric_name", fields{"a_bucket": int64(0)}, tags{bucketRightTag: "0"})
+		assertContainsTaggedField(t, acc, "first_metric_name", fields{"a_bucket": int64(0)}, tags{bucketRightTag: "10"})
+		assertContainsTaggedField(t, acc, "first_metric_name", fields{"a_bucket": int64(2)}, tags{bucketRightTag: "20"})
+		assertContainsTaggedField(t, acc, "first_metric_name", fields{"a_bucket": int64(2)}, tags{bucketRightTag: "30"})
+		assertContainsTaggedField(t, acc, "first_metric_name", fields{"a_bucket": int64(2)}, tags{bucketRightTag: "40"})
+		assertContainsTaggedField(t, acc, "first_metric_name", fields{"a_bucket": int64(2)}, tags{bucketRightTag: bucketPosInf})
+	}
+}
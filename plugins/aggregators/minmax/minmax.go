@@ -95,6 +95,42 @@ func (m *MinMax) Reset() {
 	m.cache = make(map[uint64]aggregate)
 }
 
+// Extract returns the current aggregates and clears them
+func (m *MinMax) Extract() interface{} {
+	partial := m.cache
+	m.Reset()
+	return partial
+}
+
+// Merge adds the partial aggregates returned by Extract
+func (m *MinMax) Merge(partial interface{}) {
+	for id, other := range partial.(map[uint64]aggregate) {
+		a, ok := m.cache[id]
+		if !ok {
+			a = aggregate{
+				name:   other.name,
+				tags:   other.tags,
+				fields: make(map[string]minmax, len(other.fields)),
+			}
+			m.cache[id] = a
+		}
+		for k, v := range other.fields {
+			current, ok := a.fields[k]
+			if !ok {
+				a.fields[k] = v
+				continue
+			}
+			if v.min < current.min {
+				current.min = v.min
+			}
+			if v.max > current.max {
+				current.max = v.max
+			}
+			a.fields[k] = current
+		}
+	}
+}
+
 func convert(in interface{}) (float64, bool) {
 	switch v := in.(type) {
 	case float64:
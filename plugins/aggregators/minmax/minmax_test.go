@@ -165,3 +165,29 @@ func TestMinMaxDifferentPeriods(t *testing.T) {
 	}
 	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
 }
+
+// Test that merging partial aggregates yields the same result as adding all
+// metrics to a single aggregate
+func TestMinMaxMerge(t *testing.T) {
+	expected := NewMinMax()
+	expected.Add(m1)
+	expected.Add(m2)
+	var expectedAcc testutil.Accumulator
+	expected.Push(&expectedAcc)
+
+	minmax := NewMinMax().(*MinMax)
+	minmax.Add(m1)
+	p1 := minmax.Extract()
+	minmax.Add(m2)
+	p2 := minmax.Extract()
+
+	// Merging must not modify the partials so merge them twice
+	for i := 0; i < 2; i++ {
+		minmax.Merge(p1)
+		minmax.Merge(p2)
+		var acc testutil.Accumulator
+		minmax.Push(&acc)
+		testutil.RequireMetricsEqual(t, expectedAcc.GetTelegrafMetrics(), acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+		minmax.Reset()
+	}
+}
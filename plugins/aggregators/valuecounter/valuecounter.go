@@ -81,6 +81,31 @@ func (vc *ValueCounter) Reset() {
 	vc.cache = make(map[uint64]aggregate)
 }
 
+// Extract returns the current counters and clears them
+func (vc *ValueCounter) Extract() interface{} {
+	partial := vc.cache
+	vc.Reset()
+	return partial
+}
+
+// Merge adds the partial counters returned by Extract
+func (vc *ValueCounter) Merge(partial interface{}) {
+	for id, other := range partial.(map[uint64]aggregate) {
+		a, ok := vc.cache[id]
+		if !ok {
+			a = aggregate{
+				name:       other.name,
+				tags:       other.tags,
+				fieldCount: make(map[string]int, len(other.fieldCount)),
+			}
+			vc.cache[id] = a
+		}
+		for field, count := range other.fieldCount {
+			a.fieldCount[field] += count
+		}
+	}
+}
+
 func init() {
 	aggregators.Add("valuecounter", func() telegraf.Aggregator {
 		return NewValueCounter()
@@ -123,3 +123,32 @@ func TestWithReset(t *testing.T) {
 	}
 	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
 }
+
+// Test merging partial counters
+func TestMerge(t *testing.T) {
+	vc := NewTestValueCounter([]string{"status"}).(*ValueCounter)
+
+	vc.Add(m1)
+	vc.Add(m2)
+	p1 := vc.Extract()
+	vc.Add(m1)
+	p2 := vc.Extract()
+
+	// Merging must not modify the partials so merge them twice
+	for i := 0; i < 2; i++ {
+		acc := testutil.Accumulator{}
+		vc.Merge(p1)
+		vc.Merge(p2)
+		vc.Push(&acc)
+		vc.Reset()
+
+		expectedFields := map[string]interface{}{
+			"status_200": 2,
+			"status_OK":  1,
+		}
+		expectedTags := map[string]string{
+			"foo": "bar",
+		}
+		acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+	}
+}
@@ -0,0 +1,104 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SchemaRegistry fetches schemas from a Confluent compatible schema registry
+// and caches them by ID. Credentials can be passed as part of the URL.
+type SchemaRegistry struct {
+	url    string
+	client *http.Client
+
+	cache map[int]*Schema
+	sync.Mutex
+}
+
+const requestTimeout = 10 * time.Second
+
+type schemaResponse struct {
+	ID         int    `json:"id"`
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType"`
+}
+
+// NewSchemaRegistry creates a client for the schema registry at the given URL
+func NewSchemaRegistry(address string) *SchemaRegistry {
+	return &SchemaRegistry{
+		url:    strings.TrimSuffix(address, "/"),
+		client: &http.Client{Timeout: requestTimeout},
+		cache:  make(map[int]*Schema),
+	}
+}
+
+// SchemaByID returns the schema with the given ID
+func (r *SchemaRegistry) SchemaByID(id int) (*Schema, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if schema, found := r.cache[id]; found {
+		return schema, nil
+	}
+
+	var response schemaResponse
+	if err := r.get(fmt.Sprintf("/schemas/ids/%d", id), &response); err != nil {
+		return nil, fmt.Errorf("fetching schema %d failed: %w", id, err)
+	}
+	schema, err := r.compile(id, &response)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+	r.cache[id] = schema
+
+	return schema, nil
+}
+
+// LatestSchema returns the latest version of the schema registered for the
+// given subject
+func (r *SchemaRegistry) LatestSchema(subject string) (*Schema, error) {
+	var response schemaResponse
+	if err := r.get("/subjects/"+url.PathEscape(subject)+"/versions/latest", &response); err != nil {
+		return nil, fmt.Errorf("fetching latest schema of subject %q failed: %w", subject, err)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if schema, found := r.cache[response.ID]; found {
+		return schema, nil
+	}
+	schema, err := r.compile(response.ID, &response)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", response.ID, err)
+	}
+	r.cache[response.ID] = schema
+
+	return schema, nil
+}
+
+func (r *SchemaRegistry) compile(id int, response *schemaResponse) (*Schema, error) {
+	if response.SchemaType != "" && response.SchemaType != "AVRO" {
+		return nil, fmt.Errorf("unsupported schema type %q", response.SchemaType)
+	}
+	return NewSchema(id, response.Schema)
+}
+
+func (r *SchemaRegistry) get(path string, v interface{}) error {
+	resp, err := r.client.Get(r.url + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received status code %d (%s)", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
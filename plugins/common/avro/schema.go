@@ -0,0 +1,198 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// MagicByte is the first byte of messages in the Confluent wire format
+const MagicByte byte = 0x00
+
+// HeaderSize is the size of the Confluent wire format header consisting of
+// the magic byte and the schema ID
+const HeaderSize = 5
+
+// Field describes a top-level field of a record schema
+type Field struct {
+	Name string
+	// Types contains the type names of the field, multiple ones for unions
+	Types      []string
+	HasDefault bool
+}
+
+// IsUnion returns true if the field is of a union type
+func (f *Field) IsUnion() bool {
+	return len(f.Types) > 1
+}
+
+// Schema is a compiled Avro schema
+type Schema struct {
+	ID     int
+	Codec  *goavro.Codec
+	Fields []Field
+
+	// names of all types used as union members
+	unionTypes map[string]bool
+}
+
+// NewSchema compiles the given schema specification. The top-level type of
+// the schema must be a record.
+func NewSchema(id int, spec string) (*Schema, error) {
+	codec, err := goavro.NewCodec(spec)
+	if err != nil {
+		return nil, fmt.Errorf("compiling schema failed: %w", err)
+	}
+
+	var definition map[string]interface{}
+	if err := json.Unmarshal([]byte(spec), &definition); err != nil {
+		return nil, errors.New("schema must be a record")
+	}
+	if t, _ := definition["type"].(string); t != "record" {
+		return nil, errors.New("schema must be a record")
+	}
+
+	s := &Schema{
+		ID:         id,
+		Codec:      codec,
+		unionTypes: make(map[string]bool),
+	}
+	namespace := namespaceOf(definition, "")
+	fields, _ := definition["fields"].([]interface{})
+	for _, raw := range fields {
+		f, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := f["name"].(string)
+		_, hasDefault := f["default"]
+		field := Field{Name: name, HasDefault: hasDefault}
+		if members, ok := f["type"].([]interface{}); ok {
+			for _, member := range members {
+				field.Types = append(field.Types, typeName(member, namespace))
+			}
+		} else {
+			field.Types = []string{typeName(f["type"], namespace)}
+		}
+		s.Fields = append(s.Fields, field)
+	}
+	s.collectUnionTypes(definition, "")
+
+	return s, nil
+}
+
+// NewSchemaFromFile reads and compiles the schema in the given file
+func NewSchemaFromFile(id int, filename string) (*Schema, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema file failed: %w", err)
+	}
+	return NewSchema(id, string(buf))
+}
+
+// IsUnionType returns true if the given type name is used as member of a union
+// anywhere in the schema. The native representation of those union values is
+// a map with the type name as only key.
+func (s *Schema) IsUnionType(name string) bool {
+	return s.unionTypes[name]
+}
+
+func (s *Schema) collectUnionTypes(definition interface{}, namespace string) {
+	switch d := definition.(type) {
+	case []interface{}:
+		for _, member := range d {
+			s.unionTypes[typeName(member, namespace)] = true
+			s.collectUnionTypes(member, namespace)
+		}
+	case map[string]interface{}:
+		namespace = namespaceOf(d, namespace)
+		switch d["type"] {
+		case "record", "error":
+			fields, _ := d["fields"].([]interface{})
+			for _, f := range fields {
+				if field, ok := f.(map[string]interface{}); ok {
+					s.collectUnionTypes(field["type"], namespace)
+				}
+			}
+		case "array":
+			s.collectUnionTypes(d["items"], namespace)
+		case "map":
+			s.collectUnionTypes(d["values"], namespace)
+		default:
+			// Nested type definitions, e.g. {"type": {"type": "array", ...}}
+			if _, ok := d["type"].(string); !ok {
+				s.collectUnionTypes(d["type"], namespace)
+			}
+		}
+	}
+}
+
+// typeName returns the name used by the codec for the given type definition
+func typeName(definition interface{}, namespace string) string {
+	switch d := definition.(type) {
+	case string:
+		return fullName(d, namespace)
+	case map[string]interface{}:
+		t, ok := d["type"].(string)
+		if !ok {
+			return typeName(d["type"], namespace)
+		}
+		switch t {
+		case "record", "error", "enum", "fixed":
+			name, _ := d["name"].(string)
+			return fullName(name, namespaceOf(d, namespace))
+		}
+		if logical, ok := d["logicalType"].(string); ok {
+			return t + "." + logical
+		}
+		return t
+	}
+	return ""
+}
+
+func namespaceOf(definition map[string]interface{}, enclosing string) string {
+	if name, _ := definition["name"].(string); strings.Contains(name, ".") {
+		return name[:strings.LastIndex(name, ".")]
+	}
+	if namespace, ok := definition["namespace"].(string); ok {
+		return namespace
+	}
+	return enclosing
+}
+
+func fullName(name, namespace string) string {
+	switch name {
+	case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+		return name
+	}
+	if namespace == "" || strings.Contains(name, ".") {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// SplitMessage splits a message in Confluent wire format into the schema ID and
+// the Avro encoded payload
+func SplitMessage(buf []byte) (int, []byte, error) {
+	if len(buf) < HeaderSize {
+		return 0, nil, fmt.Errorf("message too short (%d bytes)", len(buf))
+	}
+	if buf[0] != MagicByte {
+		return 0, nil, fmt.Errorf("invalid magic byte 0x%02x", buf[0])
+	}
+	return int(binary.BigEndian.Uint32(buf[1:HeaderSize])), buf[HeaderSize:], nil
+}
+
+// AppendHeader appends the Confluent wire format header for the given schema
+// ID to the buffer
+func AppendHeader(buf []byte, id int) []byte {
+	var header [HeaderSize]byte
+	header[0] = MagicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(id))
+	return append(buf, header[:]...)
+}
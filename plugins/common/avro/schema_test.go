@@ -0,0 +1,63 @@
+package avro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	spec := `{
+		"type": "record",
+		"name": "Event",
+		"namespace": "com.example",
+		"fields": [
+			{"name": "id", "type": "long"},
+			{"name": "time", "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}]},
+			{"name": "origin", "type": ["null", {
+				"type": "record",
+				"name": "Origin",
+				"fields": [{"name": "kind", "type": ["string", {"type": "enum", "name": "org.other.Kind", "symbols": ["A"]}]}]
+			}], "default": null},
+			{"name": "values", "type": {"type": "array", "items": ["int", "double"]}}
+		]
+	}`
+
+	schema, err := NewSchema(1, spec)
+	require.NoError(t, err)
+	require.Equal(t, 1, schema.ID)
+
+	expected := []Field{
+		{Name: "id", Types: []string{"long"}},
+		{Name: "time", Types: []string{"null", "long.timestamp-millis"}},
+		{Name: "origin", Types: []string{"null", "com.example.Origin"}, HasDefault: true},
+		{Name: "values", Types: []string{"array"}},
+	}
+	require.Equal(t, expected, schema.Fields)
+
+	for _, name := range []string{"null", "long.timestamp-millis", "com.example.Origin", "string", "org.other.Kind", "int", "double"} {
+		require.True(t, schema.IsUnionType(name), name)
+	}
+	for _, name := range []string{"long", "array", "Origin"} {
+		require.False(t, schema.IsUnionType(name), name)
+	}
+
+	_, err = NewSchema(0, `{"type": "array", "items": "int"}`)
+	require.ErrorContains(t, err, "schema must be a record")
+}
+
+func TestMessageHeader(t *testing.T) {
+	buf := AppendHeader(nil, 258)
+	buf = append(buf, 0x42)
+	require.Equal(t, []byte{0x00, 0x00, 0x00, 0x01, 0x02, 0x42}, buf)
+
+	id, payload, err := SplitMessage(buf)
+	require.NoError(t, err)
+	require.Equal(t, 258, id)
+	require.Equal(t, []byte{0x42}, payload)
+
+	_, _, err = SplitMessage([]byte{0x00, 0x01})
+	require.ErrorContains(t, err, "message too short")
+	_, _, err = SplitMessage([]byte{0x01, 0x00, 0x00, 0x00, 0x01})
+	require.ErrorContains(t, err, "invalid magic byte")
+}
@@ -0,0 +1,205 @@
+package columnar
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Type of the values stored in a column
+type Type int
+
+const (
+	Bool Type = iota + 1
+	Int
+	Uint
+	Float
+	String
+)
+
+// Names of the columns holding the measurement name and the timestamp of the
+// metrics. Tags or fields of the same name are suffixed with "_tag" or
+// "_field" respectively.
+const (
+	MeasurementColumn = "measurement"
+	TimeColumn        = "time"
+)
+
+// Column of a table derived from a tag or field of the metrics
+type Column struct {
+	Name string
+	Key  string
+	Type Type
+	Tag  bool
+}
+
+// Group contains all metrics of a measurement
+type Group struct {
+	Name    string
+	Metrics []telegraf.Metric
+}
+
+// Table is the columnar representation of a batch of metrics. The columns
+// are the union of all tags and fields in the batch with the tag columns
+// preceding the field columns, both sorted by name.
+type Table struct {
+	Columns []Column
+	Groups  []Group
+}
+
+// NewTable groups the metrics by measurement and derives the columns from
+// the tags and fields. In case a field has different types across the
+// metrics, the column uses a type able to represent all values.
+func NewTable(metrics []telegraf.Metric) *Table {
+	groups := make(map[string][]telegraf.Metric)
+	tags := make(map[string]bool)
+	fields := make(map[string]Type)
+	for _, m := range metrics {
+		groups[m.Name()] = append(groups[m.Name()], m)
+		for _, tag := range m.TagList() {
+			tags[tag.Key] = true
+		}
+		for _, field := range m.FieldList() {
+			t := typeOf(field.Value)
+			if t == 0 {
+				continue
+			}
+			if current, found := fields[field.Key]; found {
+				t = promote(current, t)
+			}
+			fields[field.Key] = t
+		}
+	}
+
+	t := &Table{
+		Columns: make([]Column, 0, len(tags)+len(fields)),
+		Groups:  make([]Group, 0, len(groups)),
+	}
+
+	tagKeys := make([]string, 0, len(tags))
+	for key := range tags {
+		tagKeys = append(tagKeys, key)
+	}
+	sort.Strings(tagKeys)
+
+	fieldKeys := make([]string, 0, len(fields))
+	for key := range fields {
+		fieldKeys = append(fieldKeys, key)
+	}
+	sort.Strings(fieldKeys)
+
+	names := make(map[string]bool, len(tags))
+	for _, key := range tagKeys {
+		name := key
+		if name == MeasurementColumn || name == TimeColumn {
+			name += "_tag"
+		}
+		names[name] = true
+		t.Columns = append(t.Columns, Column{Name: name, Key: key, Type: String, Tag: true})
+	}
+	for _, key := range fieldKeys {
+		name := key
+		if name == MeasurementColumn || name == TimeColumn || names[name] {
+			name += "_field"
+		}
+		t.Columns = append(t.Columns, Column{Name: name, Key: key, Type: fields[key]})
+	}
+
+	for name, metrics := range groups {
+		t.Groups = append(t.Groups, Group{Name: name, Metrics: metrics})
+	}
+	sort.Slice(t.Groups, func(i, j int) bool { return t.Groups[i].Name < t.Groups[j].Name })
+
+	return t
+}
+
+// Value returns the value of the column for the given metric converted to
+// the column type. Nil is returned if the metric has no value for the column.
+func (c *Column) Value(m telegraf.Metric) interface{} {
+	if c.Tag {
+		if v, found := m.GetTag(c.Key); found {
+			return v
+		}
+		return nil
+	}
+
+	v, found := m.GetField(c.Key)
+	if !found {
+		return nil
+	}
+	return convert(v, c.Type)
+}
+
+func typeOf(v interface{}) Type {
+	switch v.(type) {
+	case bool:
+		return Bool
+	case int64:
+		return Int
+	case uint64:
+		return Uint
+	case float64:
+		return Float
+	case string:
+		return String
+	}
+	return 0
+}
+
+// promote returns a type able to represent values of both types
+func promote(a, b Type) Type {
+	switch {
+	case a == b:
+		return a
+	case a == String || b == String || a == Bool || b == Bool:
+		return String
+	case a == Float || b == Float:
+		return Float
+	}
+	// Mixed signed and unsigned integers
+	return Int
+}
+
+func convert(v interface{}, t Type) interface{} {
+	switch t {
+	case Int:
+		switch v := v.(type) {
+		case int64:
+			return v
+		case uint64:
+			return int64(v)
+		}
+	case Uint:
+		if v, ok := v.(uint64); ok {
+			return v
+		}
+	case Float:
+		switch v := v.(type) {
+		case int64:
+			return float64(v)
+		case uint64:
+			return float64(v)
+		case float64:
+			return v
+		}
+	case Bool:
+		if v, ok := v.(bool); ok {
+			return v
+		}
+	case String:
+		switch v := v.(type) {
+		case string:
+			return v
+		case bool:
+			return strconv.FormatBool(v)
+		case int64:
+			return strconv.FormatInt(v, 10)
+		case uint64:
+			return strconv.FormatUint(v, 10)
+		case float64:
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	}
+	return nil
+}
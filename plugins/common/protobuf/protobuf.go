@@ -0,0 +1,77 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// TimestampMessage is the full name of the well-known timestamp type
+const TimestampMessage = "google.protobuf.Timestamp"
+
+// LoadMessageDescriptor parses the given protocol-buffer definition file and
+// returns the descriptor of the message type with the given full name
+func LoadMessageDescriptor(filename string, importPaths []string, messageType string) (protoreflect.MessageDescriptor, error) {
+	if filename == "" {
+		return nil, errors.New("no message definition file specified")
+	}
+	if messageType == "" {
+		return nil, errors.New("no message type specified")
+	}
+
+	parser := protoparse.Parser{
+		ImportPaths:      importPaths,
+		InferImportPaths: true,
+	}
+	fds, err := parser.ParseFiles(filename)
+	if err != nil {
+		return nil, fmt.Errorf("parsing message definition %q failed: %w", filename, err)
+	}
+
+	registry, err := protodesc.NewFiles(desc.ToFileDescriptorSet(fds...))
+	if err != nil {
+		return nil, fmt.Errorf("constructing registry failed: %w", err)
+	}
+
+	descriptor, err := registry.FindDescriptorByName(protoreflect.FullName(messageType))
+	if err != nil {
+		return nil, fmt.Errorf("looking up message type %q failed: %w", messageType, err)
+	}
+	msgDesc, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a message type", messageType)
+	}
+
+	return msgDesc, nil
+}
+
+// SplitLengthDelimited splits the buffer into messages each prefixed by their
+// length encoded as varint
+func SplitLengthDelimited(buf []byte) ([][]byte, error) {
+	var messages [][]byte
+	for len(buf) > 0 {
+		size, n := protowire.ConsumeVarint(buf)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid message length: %w", protowire.ParseError(n))
+		}
+		buf = buf[n:]
+		if uint64(len(buf)) < size {
+			return nil, fmt.Errorf("message of %d bytes exceeds the remaining %d bytes", size, len(buf))
+		}
+		messages = append(messages, buf[:size])
+		buf = buf[size:]
+	}
+	return messages, nil
+}
+
+// AppendLengthDelimited appends the message to the buffer prefixed by its
+// length encoded as varint
+func AppendLengthDelimited(buf, message []byte) []byte {
+	buf = protowire.AppendVarint(buf, uint64(len(message)))
+	return append(buf, message...)
+}
@@ -0,0 +1,55 @@
+package templating
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Metric is the view of a metric available in templates
+type Metric struct {
+	metric telegraf.Metric
+}
+
+func NewMetric(m telegraf.Metric) *Metric {
+	return &Metric{metric: m}
+}
+
+func (m *Metric) Name() string {
+	return m.metric.Name()
+}
+
+func (m *Metric) Tag(key string) string {
+	tagString, _ := m.metric.GetTag(key)
+	return tagString
+}
+
+func (m *Metric) HasTag(key string) bool {
+	return m.metric.HasTag(key)
+}
+
+func (m *Metric) Field(key string) interface{} {
+	field, _ := m.metric.GetField(key)
+	return field
+}
+
+func (m *Metric) HasField(key string) bool {
+	return m.metric.HasField(key)
+}
+
+func (m *Metric) Time() time.Time {
+	return m.metric.Time()
+}
+
+func (m *Metric) String() string {
+	return fmt.Sprint(m.metric)
+}
+
+func (m *Metric) TagList() map[string]string {
+	return m.metric.Tags()
+}
+
+func (m *Metric) FieldList() map[string]interface{} {
+	return m.metric.Fields()
+}
@@ -0,0 +1,66 @@
+package templating
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// FuncMap contains the helper functions available in all templates
+var FuncMap = template.FuncMap{
+	"formatTime": formatTime,
+	"json":       toJSON,
+	"jsonEscape": jsonEscape,
+	"join":       strings.Join,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+}
+
+// New parses the given text as a template with the helper functions
+func New(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(FuncMap).Parse(text)
+}
+
+// formatTime formats the time using one of "unix", "unix_ms", "unix_us",
+// "unix_ns" or a Go reference time layout
+func formatTime(t time.Time, format string) string {
+	switch format {
+	case "unix":
+		return fmt.Sprint(t.Unix())
+	case "unix_ms":
+		return fmt.Sprint(t.UnixNano() / int64(time.Millisecond))
+	case "unix_us":
+		return fmt.Sprint(t.UnixNano() / int64(time.Microsecond))
+	case "unix_ns":
+		return fmt.Sprint(t.UnixNano())
+	}
+	return t.Format(format)
+}
+
+// toJSON encodes the value as JSON
+func toJSON(v interface{}) (string, error) {
+	if m, ok := v.(*Metric); ok {
+		v = map[string]interface{}{
+			"name":      m.Name(),
+			"tags":      m.TagList(),
+			"fields":    m.FieldList(),
+			"timestamp": m.Time().Unix(),
+		}
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// jsonEscape escapes the string for use inside of a quoted JSON string
+func jsonEscape(s string) (string, error) {
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	return string(buf[1 : len(buf)-1]), nil
+}
@@ -251,17 +251,17 @@ func (monitor *DirectoryMonitor) parseFile(parser parsers.Parser, reader io.Read
 }
 
 func (monitor *DirectoryMonitor) parseAtOnce(parser parsers.Parser, reader io.Reader, fileName string) error {
-	bytes, err := io.ReadAll(reader)
-	if err != nil {
-		return err
-	}
-
-	metrics, err := monitor.parseMetrics(parser, bytes, fileName)
-	if err != nil {
-		return err
+	// Parse the file incrementally
+	sp, ok := parser.(telegraf.StreamingParser)
+	if !ok {
+		return fmt.Errorf("parser %T does not support streaming", parser)
 	}
-
-	return monitor.sendMetrics(metrics)
+	return sp.ParseStream(monitor.context, reader, func(m telegraf.Metric) error {
+		if monitor.FileTag != "" {
+			m.AddTag(monitor.FileTag, filepath.Base(fileName))
+		}
+		return monitor.sendMetrics([]telegraf.Metric{m})
+	})
 }
 
 func (monitor *DirectoryMonitor) parseMetrics(parser parsers.Parser, line []byte, fileName string) (metrics []telegraf.Metric, err error) {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/influxdata/telegraf/models"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/plugins/parsers/csv"
@@ -120,7 +121,7 @@ func TestMultipleJSONFileImports(t *testing.T) {
 	r.SetParserFunc(func() (parsers.Parser, error) {
 		p := &json.Parser{NameKey: "Name"}
 		err := p.Init()
-		return p, err
+		return models.NewRunningParser(p, &models.ParserConfig{}), err
 	})
 
 	// Let's drop a 5-line LINE-DELIMITED json.
@@ -167,7 +168,7 @@ func TestFileTag(t *testing.T) {
 	r.SetParserFunc(func() (parsers.Parser, error) {
 		p := &json.Parser{NameKey: "Name"}
 		err := p.Init()
-		return p, err
+		return models.NewRunningParser(p, &models.ParserConfig{}), err
 	})
 
 	// Let's drop a 1-line LINE-DELIMITED json.
@@ -432,7 +433,11 @@ func TestParseCompleteFile(t *testing.T) {
 	}
 
 	r.SetParserFunc(func() (parsers.Parser, error) {
-		return parsers.NewParser(&parserConfig)
+		parser, err := parsers.NewParser(&parserConfig)
+		if err != nil {
+			return nil, err
+		}
+		return models.NewRunningParser(parser, &models.ParserConfig{}), nil
 	})
 
 	testJSON := `{
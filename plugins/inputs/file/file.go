@@ -2,9 +2,9 @@
 package file
 
 import (
+	"context"
 	_ "embed"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 
@@ -46,16 +46,16 @@ func (f *File) Gather(acc telegraf.Accumulator) error {
 		return err
 	}
 	for _, k := range f.filenames {
-		metrics, err := f.readMetric(k)
-		if err != nil {
-			return err
-		}
-
-		for _, m := range metrics {
+		filename := k
+		err := f.readMetric(filename, func(m telegraf.Metric) error {
 			if f.FileTag != "" {
-				m.AddTag(f.FileTag, filepath.Base(k))
+				m.AddTag(f.FileTag, filepath.Base(filename))
 			}
 			acc.AddMetric(m)
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -83,27 +83,28 @@ func (f *File) refreshFilePaths() error {
 	return nil
 }
 
-func (f *File) readMetric(filename string) ([]telegraf.Metric, error) {
+func (f *File) readMetric(filename string, fn func(telegraf.Metric) error) error {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer file.Close()
 
 	r, _ := utfbom.Skip(f.decoder.Reader(file))
-	fileContents, err := io.ReadAll(r)
-	if err != nil {
-		return nil, fmt.Errorf("could not read %q: %w", filename, err)
-	}
 	parser, err := f.parserFunc()
 	if err != nil {
-		return nil, fmt.Errorf("could not instantiate parser: %w", err)
+		return fmt.Errorf("could not instantiate parser: %w", err)
 	}
-	metrics, err := parser.Parse(fileContents)
-	if err != nil {
-		return metrics, fmt.Errorf("could not parse %q: %w", filename, err)
+
+	// Parse the file incrementally
+	sp, ok := parser.(telegraf.StreamingParser)
+	if !ok {
+		return fmt.Errorf("parser %T does not support streaming", parser)
+	}
+	if err := sp.ParseStream(context.Background(), r, fn); err != nil {
+		return fmt.Errorf("could not parse %q: %w", filename, err)
 	}
-	return metrics, err
+	return nil
 }
 
 func init() {
@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
 	"github.com/influxdata/telegraf/plugins/parsers/csv"
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
@@ -49,7 +50,7 @@ func TestFileTag(t *testing.T) {
 	r.SetParserFunc(func() (telegraf.Parser, error) {
 		p := &json.Parser{}
 		err := p.Init()
-		return p, err
+		return models.NewRunningParser(p, &models.ParserConfig{}), err
 	})
 
 	require.NoError(t, r.Gather(&acc))
@@ -73,7 +74,7 @@ func TestJSONParserCompile(t *testing.T) {
 	r.SetParserFunc(func() (telegraf.Parser, error) {
 		p := &json.Parser{TagKeys: []string{"parent_ignored_child"}}
 		err := p.Init()
-		return p, err
+		return models.NewRunningParser(p, &models.ParserConfig{}), err
 	})
 
 	require.NoError(t, r.Gather(&acc))
@@ -97,7 +98,7 @@ func TestGrokParser(t *testing.T) {
 		}
 		err := parser.Init()
 
-		return parser, err
+		return models.NewRunningParser(parser, &models.ParserConfig{}), err
 	})
 
 	err = r.Gather(&acc)
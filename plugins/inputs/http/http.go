@@ -159,28 +159,28 @@ func (h *HTTP) gatherURL(
 			h.SuccessStatusCodes)
 	}
 
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("reading body failed: %v", err)
-	}
-
 	// Instantiate a new parser for the new data to avoid trouble with stateful parsers
 	parser, err := h.parserFunc()
 	if err != nil {
 		return fmt.Errorf("instantiating parser failed: %v", err)
 	}
-	metrics, err := parser.Parse(b)
-	if err != nil {
-		return fmt.Errorf("parsing metrics failed: %v", err)
-	}
 
-	for _, metric := range metrics {
+	addMetric := func(metric telegraf.Metric) error {
 		if !metric.HasTag("url") {
 			metric.AddTag("url", url)
 		}
 		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+		return nil
 	}
 
+	// Parse the body incrementally
+	sp, ok := parser.(telegraf.StreamingParser)
+	if !ok {
+		return fmt.Errorf("parser %T does not support streaming", parser)
+	}
+	if err := sp.ParseStream(request.Context(), resp.Body, addMetric); err != nil {
+		return fmt.Errorf("parsing metrics failed: %v", err)
+	}
 	return nil
 }
 
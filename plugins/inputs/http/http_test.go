@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
 	httpconfig "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/common/oauth"
 	httpplugin "github.com/influxdata/telegraf/plugins/inputs/http"
@@ -43,7 +44,7 @@ func TestHTTPWithJSONFormat(t *testing.T) {
 	plugin.SetParserFunc(func() (telegraf.Parser, error) {
 		p := &json.Parser{MetricName: "metricName"}
 		err := p.Init()
-		return p, err
+		return models.NewRunningParser(p, &models.ParserConfig{}), err
 	})
 
 	var acc testutil.Accumulator
@@ -86,7 +87,7 @@ func TestHTTPHeaders(t *testing.T) {
 	plugin.SetParserFunc(func() (telegraf.Parser, error) {
 		p := &json.Parser{MetricName: "metricName"}
 		err := p.Init()
-		return p, err
+		return models.NewRunningParser(p, &models.ParserConfig{}), err
 	})
 
 	var acc testutil.Accumulator
@@ -119,7 +120,7 @@ func TestHTTPContentLengthHeader(t *testing.T) {
 	plugin.SetParserFunc(func() (telegraf.Parser, error) {
 		p := &json.Parser{MetricName: "metricName"}
 		err := p.Init()
-		return p, err
+		return models.NewRunningParser(p, &models.ParserConfig{}), err
 	})
 
 	var acc testutil.Accumulator
@@ -142,7 +143,7 @@ func TestInvalidStatusCode(t *testing.T) {
 	plugin.SetParserFunc(func() (telegraf.Parser, error) {
 		p := &json.Parser{MetricName: "metricName"}
 		err := p.Init()
-		return p, err
+		return models.NewRunningParser(p, &models.ParserConfig{}), err
 	})
 
 	var acc testutil.Accumulator
@@ -166,7 +167,7 @@ func TestSuccessStatusCodes(t *testing.T) {
 	plugin.SetParserFunc(func() (telegraf.Parser, error) {
 		p := &json.Parser{MetricName: "metricName"}
 		err := p.Init()
-		return p, err
+		return models.NewRunningParser(p, &models.ParserConfig{}), err
 	})
 
 	var acc testutil.Accumulator
@@ -193,7 +194,7 @@ func TestMethod(t *testing.T) {
 	plugin.SetParserFunc(func() (telegraf.Parser, error) {
 		p := &json.Parser{MetricName: "metricName"}
 		err := p.Init()
-		return p, err
+		return models.NewRunningParser(p, &models.ParserConfig{}), err
 	})
 
 	var acc testutil.Accumulator
@@ -380,7 +381,7 @@ func TestOAuthClientCredentialsGrant(t *testing.T) {
 					DataType:   "string",
 				}
 				err := p.Init()
-				return p, err
+				return models.NewRunningParser(p, &models.ParserConfig{}), err
 			})
 
 			err = tt.plugin.Init()
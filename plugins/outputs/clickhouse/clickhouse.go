@@ -0,0 +1,584 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package clickhouse
+
+import (
+	"database/sql/driver"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+// DO NOT REMOVE THE NEXT TWO LINES! This is required to embed the sampleConfig data.
+//go:embed sample.conf
+var sampleConfig string
+
+// Suffixes of the ephemeral columns filling the tags column in single table
+// mode, the client library cannot write Map columns directly
+const (
+	tagKeysSuffix   = "_keys"
+	tagValuesSuffix = "_values"
+)
+
+type ClickHouse struct {
+	DSN                string          `toml:"dsn"`
+	TableMode          string          `toml:"table_mode"`
+	Table              string          `toml:"table"`
+	TimestampColumn    string          `toml:"timestamp_column"`
+	MeasurementColumn  string          `toml:"measurement_column"`
+	TagsColumn         string          `toml:"tags_column"`
+	CreateTemplate     string          `toml:"create_template"`
+	AddColumnTemplate  string          `toml:"add_column_template"`
+	AsyncInsert        bool            `toml:"async_insert"`
+	WaitForAsyncInsert bool            `toml:"wait_for_async_insert"`
+	Log                telegraf.Logger `toml:"-"`
+
+	conn   clickhouse.Clickhouse
+	tables map[string]map[string]string
+}
+
+// column of the insert statement with the type used when creating the column
+type column struct {
+	name      string
+	datatype  string
+	ephemeral bool
+}
+
+// batch of rows inserted into a table, the values of a row are indexed by
+// column name
+type batch struct {
+	table   string
+	columns []column
+	index   map[string]bool
+	rows    []map[string]interface{}
+}
+
+func (b *batch) addColumn(c column) {
+	if b.index[c.name] {
+		return
+	}
+	b.index[c.name] = true
+	b.columns = append(b.columns, c)
+}
+
+func (*ClickHouse) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *ClickHouse) Init() error {
+	if c.DSN == "" {
+		return errors.New("dsn must not be empty")
+	}
+	if c.TimestampColumn == "" {
+		return errors.New("timestamp column must not be empty")
+	}
+
+	switch c.TableMode {
+	case "measurement":
+	case "single":
+		if c.Table == "" {
+			return errors.New("table must not be empty in single table mode")
+		}
+		if c.MeasurementColumn == "" || c.TagsColumn == "" {
+			return errors.New("measurement and tags column must not be empty in single table mode")
+		}
+	default:
+		return fmt.Errorf("invalid table mode %q", c.TableMode)
+	}
+
+	return nil
+}
+
+func (c *ClickHouse) Connect() error {
+	conn, err := clickhouse.OpenDirect(c.DSN)
+	if err != nil {
+		return fmt.Errorf("connecting failed: %w", err)
+	}
+	c.conn = conn
+	c.tables = make(map[string]map[string]string)
+
+	return nil
+}
+
+func (c *ClickHouse) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// Quote an identifier (table or column name)
+func quoteIdent(name string) string {
+	return "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(name) + "`"
+}
+
+// Quote a string literal
+func quoteStr(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// fieldType returns the column type for the field value, fields are nullable
+// as metrics written to the same table might have different fields
+func fieldType(value interface{}) string {
+	switch value.(type) {
+	case float64:
+		return "Nullable(Float64)"
+	case int64:
+		return "Nullable(Int64)"
+	case uint64:
+		return "Nullable(UInt64)"
+	case bool:
+		return "Nullable(UInt8)"
+	default:
+		return "Nullable(String)"
+	}
+}
+
+// batches groups the metrics into the rows of the tables according to the
+// table mode
+func (c *ClickHouse) batches(metrics []telegraf.Metric) []*batch {
+	var batches []*batch
+	index := make(map[string]*batch)
+	for _, m := range metrics {
+		table := m.Name()
+		if c.TableMode == "single" {
+			table = c.Table
+		}
+
+		b, found := index[table]
+		if !found {
+			b = &batch{table: table, index: make(map[string]bool)}
+			b.addColumn(column{name: c.TimestampColumn, datatype: "DateTime64(9)"})
+			if c.TableMode == "single" {
+				b.addColumn(column{name: c.MeasurementColumn, datatype: "String"})
+				b.addColumn(column{name: c.TagsColumn + tagKeysSuffix, datatype: "Array(String)", ephemeral: true})
+				b.addColumn(column{name: c.TagsColumn + tagValuesSuffix, datatype: "Array(String)", ephemeral: true})
+			}
+			index[table] = b
+			batches = append(batches, b)
+		}
+
+		row := map[string]interface{}{c.TimestampColumn: m.Time()}
+		if c.TableMode == "single" {
+			keys := make([]string, 0, len(m.TagList()))
+			values := make([]string, 0, len(m.TagList()))
+			for _, tag := range m.TagList() {
+				keys = append(keys, tag.Key)
+				values = append(values, tag.Value)
+			}
+			row[c.MeasurementColumn] = m.Name()
+			row[c.TagsColumn+tagKeysSuffix] = keys
+			row[c.TagsColumn+tagValuesSuffix] = values
+		} else {
+			for _, tag := range m.TagList() {
+				if tag.Key == c.TimestampColumn {
+					c.Log.Debugf("Skipping tag %q of %q conflicting with the timestamp column", tag.Key, m.Name())
+					continue
+				}
+				b.addColumn(column{name: tag.Key, datatype: "String"})
+				row[tag.Key] = tag.Value
+			}
+		}
+
+		// Sort the fields like the tags to get a stable column order
+		fields := append([]*telegraf.Field(nil), m.FieldList()...)
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+		for _, field := range fields {
+			if _, found := row[field.Key]; found || c.reserved(field.Key) {
+				c.Log.Debugf("Skipping field %q of %q conflicting with another column", field.Key, m.Name())
+				continue
+			}
+			b.addColumn(column{name: field.Key, datatype: fieldType(field.Value)})
+			row[field.Key] = field.Value
+		}
+		b.rows = append(b.rows, row)
+	}
+	return batches
+}
+
+// reserved checks if the name is used by one of the fixed columns
+func (c *ClickHouse) reserved(name string) bool {
+	if name == c.TimestampColumn {
+		return true
+	}
+	if c.TableMode != "single" {
+		return false
+	}
+	switch name {
+	case c.MeasurementColumn, c.TagsColumn, c.TagsColumn + tagKeysSuffix, c.TagsColumn + tagValuesSuffix:
+		return true
+	}
+	return false
+}
+
+// orderBy returns the default sorting key of a new table
+func (c *ClickHouse) orderBy(columns []column) string {
+	var keys []string
+	if c.TableMode == "single" {
+		keys = append(keys, quoteIdent(c.MeasurementColumn))
+	} else {
+		for _, col := range columns {
+			if col.datatype == "String" {
+				keys = append(keys, quoteIdent(col.name))
+			}
+		}
+	}
+	keys = append(keys, quoteIdent(c.TimestampColumn))
+	return "(" + strings.Join(keys, ", ") + ")"
+}
+
+func (c *ClickHouse) generateCreateTable(table string, columns []column) string {
+	definitions := make([]string, 0, len(columns)+1)
+	for _, col := range columns {
+		definition := quoteIdent(col.name) + " " + col.datatype
+		if col.ephemeral {
+			definition += " EPHEMERAL"
+		}
+		definitions = append(definitions, definition)
+
+		// The map of tags is filled from the ephemeral key and value columns
+		if col.ephemeral && col.name == c.TagsColumn+tagValuesSuffix {
+			definitions = append(definitions, fmt.Sprintf("%s Map(String, String) DEFAULT CAST((%s, %s), 'Map(String, String)')",
+				quoteIdent(c.TagsColumn),
+				quoteIdent(c.TagsColumn+tagKeysSuffix),
+				quoteIdent(c.TagsColumn+tagValuesSuffix),
+			))
+		}
+	}
+
+	query := c.CreateTemplate
+	query = strings.ReplaceAll(query, "{TABLE}", quoteIdent(table))
+	query = strings.ReplaceAll(query, "{TABLELITERAL}", quoteStr(table))
+	query = strings.ReplaceAll(query, "{COLUMNS}", strings.Join(definitions, ", "))
+	query = strings.ReplaceAll(query, "{ORDER_BY}", c.orderBy(columns))
+	return query
+}
+
+func (c *ClickHouse) generateAddColumn(table string, col column) string {
+	query := c.AddColumnTemplate
+	query = strings.ReplaceAll(query, "{TABLE}", quoteIdent(table))
+	query = strings.ReplaceAll(query, "{TABLELITERAL}", quoteStr(table))
+	query = strings.ReplaceAll(query, "{COLUMN}", quoteIdent(col.name)+" "+col.datatype)
+	return query
+}
+
+func (c *ClickHouse) generateInsert(table string, columns []column) string {
+	names := make([]string, 0, len(columns))
+	placeholders := make([]string, 0, len(columns))
+	for _, col := range columns {
+		names = append(names, quoteIdent(col.name))
+		placeholders = append(placeholders, "?")
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s)", quoteIdent(table), strings.Join(names, ", "))
+	if c.AsyncInsert {
+		wait := 0
+		if c.WaitForAsyncInsert {
+			wait = 1
+		}
+		query += fmt.Sprintf(" SETTINGS async_insert=1, wait_for_async_insert=%d", wait)
+	}
+	return query + " VALUES (" + strings.Join(placeholders, ", ") + ")"
+}
+
+// exec runs a statement not returning any rows
+func (c *ClickHouse) exec(query string) error {
+	if _, err := c.conn.Begin(); err != nil {
+		return err
+	}
+	stmt, err := c.conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec([]driver.Value{}); err != nil {
+		return err
+	}
+	return c.conn.Commit()
+}
+
+// tableColumns queries the names and types of the existing columns of the
+// table, the result is empty if the table does not exist
+func (c *ClickHouse) tableColumns(table string) (map[string]string, error) {
+	if _, err := c.conn.Begin(); err != nil {
+		return nil, err
+	}
+	stmt, err := c.conn.Prepare("SELECT name, type FROM system.columns WHERE database = currentDatabase() AND table = " + quoteStr(table))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query([]driver.Value{})
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]string)
+	row := make([]driver.Value, 2)
+	for {
+		err := rows.Next(row)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		name, _ := row[0].(string)
+		datatype, _ := row[1].(string)
+		columns[name] = datatype
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	return columns, c.conn.Commit()
+}
+
+// updateSchema creates the table or adds columns missing in the table
+func (c *ClickHouse) updateSchema(table string, columns []column) error {
+	existing, found := c.tables[table]
+	if !found {
+		var err error
+		if existing, err = c.tableColumns(table); err != nil {
+			return fmt.Errorf("querying columns of table %q failed: %w", table, err)
+		}
+
+		if len(existing) == 0 {
+			if c.CreateTemplate == "" {
+				return fmt.Errorf("table %q does not exist", table)
+			}
+			if err := c.exec(c.generateCreateTable(table, columns)); err != nil {
+				return fmt.Errorf("creating table %q failed: %w", table, err)
+			}
+			for _, col := range columns {
+				existing[col.name] = col.datatype
+			}
+		}
+		c.tables[table] = existing
+	}
+
+	for _, col := range columns {
+		// Ephemeral columns are not listed by all server versions
+		if _, found := existing[col.name]; found || col.ephemeral {
+			continue
+		}
+		if c.AddColumnTemplate == "" {
+			return fmt.Errorf("column %q missing in table %q", col.name, table)
+		}
+		if err := c.exec(c.generateAddColumn(table, col)); err != nil {
+			return fmt.Errorf("adding column %q to table %q failed: %w", col.name, table, err)
+		}
+		existing[col.name] = col.datatype
+	}
+
+	return nil
+}
+
+// insert writes all rows of the batch as a single block, the values are
+// converted to the column types reported by the server
+func (c *ClickHouse) insert(b *batch) error {
+	if _, err := c.conn.Begin(); err != nil {
+		return err
+	}
+	stmt, err := c.conn.Prepare(c.generateInsert(b.table, b.columns))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	block, err := c.conn.Block()
+	if err != nil {
+		return err
+	}
+
+	types := make([]string, 0, len(block.Columns))
+	names := make([]string, 0, len(block.Columns))
+	for _, col := range block.Columns {
+		types = append(types, col.CHType())
+		names = append(names, col.Name())
+	}
+
+	invalid := make(map[string]bool)
+	for _, row := range b.rows {
+		values := make([]driver.Value, 0, len(names))
+		for i, name := range names {
+			v, err := convert(row[name], types[i])
+			if err != nil && !invalid[name] {
+				c.Log.Warnf("Writing default value to column %q of table %q: %v", name, b.table, err)
+				invalid[name] = true
+			}
+			values = append(values, v)
+		}
+		if err := block.AppendRow(values); err != nil {
+			return err
+		}
+	}
+
+	return c.conn.Commit()
+}
+
+func (c *ClickHouse) Write(metrics []telegraf.Metric) error {
+	if c.conn == nil {
+		if err := c.Connect(); err != nil {
+			return err
+		}
+	}
+
+	for _, b := range c.batches(metrics) {
+		err := c.updateSchema(b.table, b.columns)
+		if err == nil {
+			err = c.insert(b)
+		}
+		if err != nil {
+			// The connection is in an undefined state after a failure and
+			// the cached columns might be outdated, so start from scratch
+			// with the next write
+			if cerr := c.Close(); cerr != nil {
+				c.Log.Debugf("Closing connection failed: %v", cerr)
+			}
+			return fmt.Errorf("writing table %q failed: %w", b.table, err)
+		}
+	}
+	return nil
+}
+
+// convert returns the value with the Go type expected by the client library
+// for the column type. Missing or invalid values are written as NULL for
+// nullable columns and as default value otherwise.
+func convert(value interface{}, datatype string) (interface{}, error) {
+	base := datatype
+	nullable := strings.HasPrefix(datatype, "Nullable(")
+	if nullable {
+		base = strings.TrimSuffix(strings.TrimPrefix(datatype, "Nullable("), ")")
+	}
+
+	if value == nil {
+		if nullable {
+			return nil, nil
+		}
+		return defaultValue(base), nil
+	}
+
+	v, err := convertBase(value, base)
+	if err != nil {
+		if nullable {
+			return nil, err
+		}
+		return defaultValue(base), err
+	}
+	return v, nil
+}
+
+func convertBase(value interface{}, base string) (interface{}, error) {
+	if b, ok := value.(bool); ok && base != "String" {
+		value = uint64(0)
+		if b {
+			value = uint64(1)
+		}
+	}
+
+	switch base {
+	case "String":
+		return internal.ToString(value)
+	case "Float64":
+		return internal.ToFloat64(value)
+	case "Float32":
+		v, err := internal.ToFloat64(value)
+		return float32(v), err
+	case "Int64", "Int32", "Int16", "Int8":
+		v, err := internal.ToInt64(value)
+		switch base {
+		case "Int32":
+			return int32(v), err
+		case "Int16":
+			return int16(v), err
+		case "Int8":
+			return int8(v), err
+		}
+		return v, err
+	case "UInt64", "UInt32", "UInt16", "UInt8":
+		v, err := internal.ToUint64(value)
+		switch base {
+		case "UInt32":
+			return uint32(v), err
+		case "UInt16":
+			return uint16(v), err
+		case "UInt8":
+			return uint8(v), err
+		}
+		return v, err
+	case "Array(String)":
+		if v, ok := value.([]string); ok {
+			return v, nil
+		}
+	default:
+		if strings.HasPrefix(base, "DateTime") {
+			if v, ok := value.(time.Time); ok {
+				return v, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %T to %s", value, base)
+}
+
+func defaultValue(base string) interface{} {
+	switch base {
+	case "Float64":
+		return float64(0)
+	case "Float32":
+		return float32(0)
+	case "Int64":
+		return int64(0)
+	case "Int32":
+		return int32(0)
+	case "Int16":
+		return int16(0)
+	case "Int8":
+		return int8(0)
+	case "UInt64":
+		return uint64(0)
+	case "UInt32":
+		return uint32(0)
+	case "UInt16":
+		return uint16(0)
+	case "UInt8":
+		return uint8(0)
+	case "Array(String)":
+		return []string{}
+	}
+	if strings.HasPrefix(base, "DateTime") {
+		return time.Time{}
+	}
+	return ""
+}
+
+func init() {
+	outputs.Add("clickhouse", func() telegraf.Output { return newClickHouse() })
+}
+
+func newClickHouse() *ClickHouse {
+	return &ClickHouse{
+		DSN:                "tcp://localhost:9000?database=default",
+		TableMode:          "measurement",
+		Table:              "telegraf",
+		TimestampColumn:    "timestamp",
+		MeasurementColumn:  "measurement",
+		TagsColumn:         "tags",
+		CreateTemplate:     "CREATE TABLE IF NOT EXISTS {TABLE} ({COLUMNS}) ENGINE = MergeTree ORDER BY {ORDER_BY}",
+		AddColumnTemplate:  "ALTER TABLE {TABLE} ADD COLUMN IF NOT EXISTS {COLUMN}",
+		WaitForAsyncInsert: true,
+	}
+}
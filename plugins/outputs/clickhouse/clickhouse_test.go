@@ -0,0 +1,617 @@
+package clickhouse
+
+import (
+	"bufio"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/lib/binary"
+	chcolumn "github.com/ClickHouse/clickhouse-go/lib/column"
+	"github.com/ClickHouse/clickhouse-go/lib/data"
+	"github.com/ClickHouse/clickhouse-go/lib/protocol"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// server is a stand-in for the native protocol interface of ClickHouse,
+// keeping the column definitions of the created tables and the inserted rows
+type server struct {
+	listener net.Listener
+
+	sync.Mutex
+	tables  map[string][][2]string
+	rows    map[string][]map[string]interface{}
+	queries []string
+}
+
+var (
+	createRe    = regexp.MustCompile("^CREATE TABLE IF NOT EXISTS `([^`]+)` \\((.*)\\) ENGINE")
+	addColumnRe = regexp.MustCompile("^ALTER TABLE `([^`]+)` ADD COLUMN IF NOT EXISTS `([^`]+)` (.+)$")
+	columnsRe   = regexp.MustCompile("^SELECT name, type FROM system.columns .* table = '([^']+)'$")
+	insertRe    = regexp.MustCompile("^INSERT INTO `([^`]+)` \\(([^)]*)\\)")
+)
+
+func newServer(t *testing.T) *server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &server{
+		listener: listener,
+		tables:   make(map[string][][2]string),
+		rows:     make(map[string][]map[string]interface{}),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return s
+}
+
+func (s *server) dsn() string {
+	return "tcp://" + s.listener.Addr().String() + "?database=default"
+}
+
+func (s *server) close() {
+	s.listener.Close()
+}
+
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	buffer := bufio.NewWriter(conn)
+	dec := binary.NewDecoder(conn)
+	enc := binary.NewEncoder(buffer)
+	info := &data.ServerInfo{Revision: data.ClickHouseRevision, Timezone: time.UTC}
+
+	// Client hello with name, version, database and credentials
+	if _, err := dec.Uvarint(); err != nil {
+		return
+	}
+	dec.String()  //nolint:errcheck // checked by the next read
+	dec.Uvarint() //nolint:errcheck // checked by the next read
+	dec.Uvarint() //nolint:errcheck // checked by the next read
+	dec.Uvarint() //nolint:errcheck // checked by the next read
+	for i := 0; i < 3; i++ {
+		if _, err := dec.String(); err != nil {
+			return
+		}
+	}
+
+	enc.Uvarint(protocol.ServerHello) //nolint:errcheck // checked by flush
+	enc.String("ClickHouse")          //nolint:errcheck // checked by flush
+	enc.Uvarint(22)                   //nolint:errcheck // checked by flush
+	enc.Uvarint(8)                    //nolint:errcheck // checked by flush
+	enc.Uvarint(info.Revision)        //nolint:errcheck // checked by flush
+	enc.String("UTC")                 //nolint:errcheck // checked by flush
+	if err := buffer.Flush(); err != nil {
+		return
+	}
+
+	for {
+		packet, err := dec.Uvarint()
+		if err != nil {
+			return
+		}
+		switch packet {
+		case protocol.ClientPing:
+			enc.Uvarint(protocol.ServerPong) //nolint:errcheck // checked by flush
+		case protocol.ClientQuery:
+			query, err := readQuery(dec, info)
+			if err != nil {
+				return
+			}
+			if err := s.query(query, dec, enc, info); err != nil {
+				return
+			}
+		default:
+			return
+		}
+		if err := buffer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// readQuery reads the query packet followed by the empty data block ending
+// the external tables
+func readQuery(dec *binary.Decoder, info *data.ServerInfo) (string, error) {
+	// Query ID and client info
+	for i := 0; i < 4; i++ {
+		if i == 1 {
+			if _, err := dec.Uvarint(); err != nil {
+				return "", err
+			}
+		}
+		if _, err := dec.String(); err != nil {
+			return "", err
+		}
+	}
+	if _, err := dec.Uvarint(); err != nil {
+		return "", err
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := dec.String(); err != nil {
+			return "", err
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := dec.Uvarint(); err != nil {
+			return "", err
+		}
+	}
+	// Quota key and settings, no settings are used in the tests
+	for i := 0; i < 2; i++ {
+		if _, err := dec.String(); err != nil {
+			return "", err
+		}
+	}
+	// Stage and compression
+	for i := 0; i < 2; i++ {
+		if _, err := dec.Uvarint(); err != nil {
+			return "", err
+		}
+	}
+	query, err := dec.String()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = readBlock(dec, info)
+	return query, err
+}
+
+func readBlock(dec *binary.Decoder, info *data.ServerInfo) (*data.Block, error) {
+	packet, err := dec.Uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if packet != protocol.ClientData {
+		return nil, fmt.Errorf("unexpected packet %d", packet)
+	}
+	if _, err := dec.String(); err != nil {
+		return nil, err
+	}
+	var block data.Block
+	if err := block.Read(info, dec); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+func writeBlock(enc *binary.Encoder, info *data.ServerInfo, columns [][2]string, rows [][]driver.Value) error {
+	block := &data.Block{NumColumns: uint64(len(columns))}
+	for _, c := range columns {
+		col, err := chcolumn.Factory(c[0], c[1], info.Timezone)
+		if err != nil {
+			return err
+		}
+		block.Columns = append(block.Columns, col)
+	}
+	block.Reserve()
+	for _, row := range rows {
+		if err := block.AppendRow(row); err != nil {
+			return err
+		}
+	}
+
+	enc.Uvarint(protocol.ServerData) //nolint:errcheck // checked by block write
+	enc.String("")                   //nolint:errcheck // checked by block write
+	return block.Write(info, enc)
+}
+
+func writeException(enc *binary.Encoder, message string) {
+	enc.Uvarint(protocol.ServerException) //nolint:errcheck // checked by flush
+	enc.Int32(47)                         //nolint:errcheck // checked by flush
+	enc.String("DB::Exception")           //nolint:errcheck // checked by flush
+	enc.String(message)                   //nolint:errcheck // checked by flush
+	enc.String("")                        //nolint:errcheck // checked by flush
+	enc.Bool(false)                       //nolint:errcheck // checked by flush
+}
+
+func (s *server) query(query string, dec *binary.Decoder, enc *binary.Encoder, info *data.ServerInfo) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.queries = append(s.queries, query)
+	switch {
+	case createRe.MatchString(query):
+		match := createRe.FindStringSubmatch(query)
+		if _, found := s.tables[match[1]]; !found {
+			s.tables[match[1]] = parseDefinitions(match[2])
+		}
+	case addColumnRe.MatchString(query):
+		match := addColumnRe.FindStringSubmatch(query)
+		s.tables[match[1]] = append(s.tables[match[1]], [2]string{match[2], match[3]})
+	case columnsRe.MatchString(query):
+		match := columnsRe.FindStringSubmatch(query)
+		columns := [][2]string{{"name", "String"}, {"type", "String"}}
+		rows := make([][]driver.Value, 0, len(s.tables[match[1]]))
+		for _, c := range s.tables[match[1]] {
+			rows = append(rows, []driver.Value{c[0], c[1]})
+		}
+		if err := writeBlock(enc, info, columns, nil); err != nil {
+			return err
+		}
+		if len(rows) > 0 {
+			if err := writeBlock(enc, info, columns, rows); err != nil {
+				return err
+			}
+		}
+	case insertRe.MatchString(query):
+		match := insertRe.FindStringSubmatch(query)
+		table := match[1]
+		types := make(map[string]string)
+		for _, c := range s.tables[table] {
+			types[c[0]] = c[1]
+		}
+
+		var columns [][2]string
+		for _, name := range strings.Split(match[2], ", ") {
+			name = strings.Trim(name, "`")
+			if _, found := types[name]; !found {
+				writeException(enc, fmt.Sprintf("No such column %s in table %s", name, table))
+				return nil
+			}
+			columns = append(columns, [2]string{name, types[name]})
+		}
+		if err := writeBlock(enc, info, columns, nil); err != nil {
+			return err
+		}
+		if err := enc.Flush(); err != nil {
+			return err
+		}
+
+		for {
+			block, err := readBlock(dec, info)
+			if err != nil {
+				return err
+			}
+			if block.NumColumns == 0 && block.NumRows == 0 {
+				break
+			}
+			for i := 0; i < int(block.NumRows); i++ {
+				row := make(map[string]interface{}, len(block.Columns))
+				for j, c := range block.Columns {
+					row[c.Name()] = block.Values[j][i]
+				}
+				s.rows[table] = append(s.rows[table], row)
+			}
+		}
+	}
+	return enc.Uvarint(protocol.ServerEndOfStream)
+}
+
+// parseDefinitions splits the column definitions of a create statement into
+// names and types
+func parseDefinitions(definitions string) [][2]string {
+	var columns [][2]string
+	var depth int
+	var start int
+	for i := 0; i <= len(definitions); i++ {
+		if i < len(definitions) {
+			switch definitions[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+
+		definition := strings.TrimSpace(definitions[start:i])
+		start = i + 1
+		end := strings.Index(definition[1:], "`") + 1
+		name := definition[1:end]
+		datatype := definition[end+2:]
+		depth := 0
+		for j, r := range datatype {
+			if r == '(' {
+				depth++
+			} else if r == ')' {
+				depth--
+			} else if r == ' ' && depth == 0 {
+				datatype = datatype[:j]
+				break
+			}
+		}
+		columns = append(columns, [2]string{name, datatype})
+	}
+	return columns
+}
+
+func (s *server) getRows(table string) []map[string]interface{} {
+	s.Lock()
+	defer s.Unlock()
+	return s.rows[table]
+}
+
+func (s *server) getQueries() []string {
+	s.Lock()
+	defer s.Unlock()
+	return append([]string{}, s.queries...)
+}
+
+func TestMeasurementTables(t *testing.T) {
+	s := newServer(t)
+	defer s.close()
+
+	plugin := newClickHouse()
+	plugin.DSN = s.dsn()
+	plugin.Log = testutil.Logger{}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	metrics := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 42.0, "count": int64(3)},
+			time.Unix(1, 0),
+		),
+		metric.New(
+			"cpu",
+			map[string]string{"host": "b", "cpu": "cpu0"},
+			map[string]interface{}{"usage": 23.0, "ok": true},
+			time.Unix(2, 0),
+		),
+		metric.New(
+			"mem",
+			map[string]string{},
+			map[string]interface{}{"used": uint64(10)},
+			time.Unix(3, 0),
+		),
+	}
+	require.NoError(t, plugin.Write(metrics))
+
+	queries := s.getQueries()
+	require.Contains(t, queries,
+		"CREATE TABLE IF NOT EXISTS `cpu` (`timestamp` DateTime64(9), `host` String, `count` Nullable(Int64), "+
+			"`usage` Nullable(Float64), `cpu` String, `ok` Nullable(UInt8)) ENGINE = MergeTree ORDER BY (`host`, `cpu`, `timestamp`)")
+	require.Contains(t, queries,
+		"CREATE TABLE IF NOT EXISTS `mem` (`timestamp` DateTime64(9), `used` Nullable(UInt64)) ENGINE = MergeTree ORDER BY (`timestamp`)")
+
+	expected := []map[string]interface{}{
+		{
+			"timestamp": time.Unix(1, 0).UTC(),
+			"host":      "a",
+			"usage":     42.0,
+			"count":     int64(3),
+			"cpu":       "",
+			"ok":        nil,
+		},
+		{
+			"timestamp": time.Unix(2, 0).UTC(),
+			"host":      "b",
+			"usage":     23.0,
+			"count":     nil,
+			"cpu":       "cpu0",
+			"ok":        uint8(1),
+		},
+	}
+	require.Equal(t, expected, s.getRows("cpu"))
+	require.Equal(t, []map[string]interface{}{
+		{"timestamp": time.Unix(3, 0).UTC(), "used": uint64(10)},
+	}, s.getRows("mem"))
+}
+
+func TestAddColumns(t *testing.T) {
+	s := newServer(t)
+	defer s.close()
+
+	// Existing table with a field type differing from the metric
+	s.tables["cpu"] = [][2]string{
+		{"timestamp", "DateTime64(9)"},
+		{"host", "String"},
+		{"usage", "Nullable(Float64)"},
+	}
+
+	plugin := newClickHouse()
+	plugin.DSN = s.dsn()
+	plugin.Log = testutil.Logger{}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	require.NoError(t, plugin.Write([]telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": int64(42)},
+			time.Unix(1, 0),
+		),
+	}))
+	require.NoError(t, plugin.Write([]telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "a", "region": "eu"},
+			map[string]interface{}{"usage": 23.0, "idle": 10.0},
+			time.Unix(2, 0),
+		),
+	}))
+
+	queries := s.getQueries()
+	for _, q := range queries {
+		require.NotContains(t, q, "CREATE TABLE")
+	}
+	require.Contains(t, queries, "ALTER TABLE `cpu` ADD COLUMN IF NOT EXISTS `region` String")
+	require.Contains(t, queries, "ALTER TABLE `cpu` ADD COLUMN IF NOT EXISTS `idle` Nullable(Float64)")
+
+	expected := []map[string]interface{}{
+		{"timestamp": time.Unix(1, 0).UTC(), "host": "a", "usage": 42.0},
+		{"timestamp": time.Unix(2, 0).UTC(), "host": "a", "usage": 23.0, "region": "eu", "idle": 10.0},
+	}
+	require.Equal(t, expected, s.getRows("cpu"))
+}
+
+func TestSingleTable(t *testing.T) {
+	s := newServer(t)
+	defer s.close()
+
+	plugin := newClickHouse()
+	plugin.DSN = s.dsn()
+	plugin.TableMode = "single"
+	plugin.CreateTemplate = "CREATE TABLE IF NOT EXISTS {TABLE} ({COLUMNS}) ENGINE = MergeTree ORDER BY {ORDER_BY} " +
+		"TTL toDateTime(timestamp) + INTERVAL 30 DAY"
+	plugin.Log = testutil.Logger{}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	metrics := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 42.0},
+			time.Unix(1, 0),
+		),
+		metric.New(
+			"mem",
+			map[string]string{"host": "a", "region": "eu"},
+			map[string]interface{}{"used": int64(10), "measurement": "dropped"},
+			time.Unix(2, 0),
+		),
+	}
+	require.NoError(t, plugin.Write(metrics))
+
+	require.Contains(t, s.getQueries(),
+		"CREATE TABLE IF NOT EXISTS `telegraf` (`timestamp` DateTime64(9), `measurement` String, "+
+			"`tags_keys` Array(String) EPHEMERAL, `tags_values` Array(String) EPHEMERAL, "+
+			"`tags` Map(String, String) DEFAULT CAST((`tags_keys`, `tags_values`), 'Map(String, String)'), "+
+			"`usage` Nullable(Float64), `used` Nullable(Int64)) ENGINE = MergeTree ORDER BY (`measurement`, `timestamp`) "+
+			"TTL toDateTime(timestamp) + INTERVAL 30 DAY")
+
+	expected := []map[string]interface{}{
+		{
+			"timestamp":   time.Unix(1, 0).UTC(),
+			"measurement": "cpu",
+			"tags_keys":   []string{"host"},
+			"tags_values": []string{"a"},
+			"usage":       42.0,
+			"used":        nil,
+		},
+		{
+			"timestamp":   time.Unix(2, 0).UTC(),
+			"measurement": "mem",
+			"tags_keys":   []string{"host", "region"},
+			"tags_values": []string{"a", "eu"},
+			"usage":       nil,
+			"used":        int64(10),
+		},
+	}
+	require.Equal(t, expected, s.getRows("telegraf"))
+}
+
+func TestAsyncInsert(t *testing.T) {
+	plugin := newClickHouse()
+	plugin.AsyncInsert = true
+	plugin.WaitForAsyncInsert = false
+
+	columns := []column{{name: "timestamp"}, {name: "value"}}
+	require.Equal(t,
+		"INSERT INTO `cpu` (`timestamp`, `value`) SETTINGS async_insert=1, wait_for_async_insert=0 VALUES (?, ?)",
+		plugin.generateInsert("cpu", columns))
+}
+
+func TestMissingTable(t *testing.T) {
+	s := newServer(t)
+	defer s.close()
+
+	plugin := newClickHouse()
+	plugin.DSN = s.dsn()
+	plugin.CreateTemplate = ""
+	plugin.Log = testutil.Logger{}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"usage": 42.0}, time.Unix(1, 0))
+	require.EqualError(t, plugin.Write([]telegraf.Metric{m}), `writing table "cpu" failed: table "cpu" does not exist`)
+
+	// The connection is reestablished with the next write
+	s.Lock()
+	s.tables["cpu"] = [][2]string{{"timestamp", "DateTime64(9)"}, {"usage", "Nullable(Float64)"}}
+	s.Unlock()
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+	require.Len(t, s.getRows("cpu"), 1)
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   func(*ClickHouse)
+		expected string
+	}{
+		{
+			name:     "invalid table mode",
+			plugin:   func(c *ClickHouse) { c.TableMode = "foo" },
+			expected: `invalid table mode "foo"`,
+		},
+		{
+			name: "missing table",
+			plugin: func(c *ClickHouse) {
+				c.TableMode = "single"
+				c.Table = ""
+			},
+			expected: "table must not be empty in single table mode",
+		},
+		{
+			name:     "missing timestamp column",
+			plugin:   func(c *ClickHouse) { c.TimestampColumn = "" },
+			expected: "timestamp column must not be empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newClickHouse()
+			tt.plugin(plugin)
+			require.EqualError(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		datatype string
+		expected interface{}
+		err      bool
+	}{
+		{value: int64(1), datatype: "Nullable(Float64)", expected: 1.0},
+		{value: true, datatype: "Nullable(UInt8)", expected: uint8(1)},
+		{value: true, datatype: "String", expected: "true"},
+		{value: 1.5, datatype: "Int32", expected: int32(1)},
+		{value: nil, datatype: "Nullable(Int64)", expected: nil},
+		{value: nil, datatype: "UInt16", expected: uint16(0)},
+		{value: "foo", datatype: "Nullable(Float64)", expected: nil, err: true},
+		{value: "foo", datatype: "Float64", expected: 0.0, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v %s", tt.value, tt.datatype), func(t *testing.T) {
+			actual, err := convert(tt.value, tt.datatype)
+			require.Equal(t, tt.expected, actual)
+			require.Equal(t, tt.err, err != nil)
+		})
+	}
+}
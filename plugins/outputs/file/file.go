@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -30,6 +32,9 @@ type File struct {
 	writer     io.Writer
 	closers    []io.Closer
 	serializer serializers.Serializer
+
+	// Files to write whole-file formats to, a new file is created per batch
+	batchFiles []string
 }
 
 func (*File) SampleConfig() string {
@@ -47,9 +52,16 @@ func (f *File) Connect() error {
 		f.Files = []string{"stdout"}
 	}
 
+	var wholeFile bool
+	if s, ok := f.serializer.(serializers.WholeFileSerializer); ok {
+		wholeFile = s.WholeFile()
+	}
+
 	for _, file := range f.Files {
 		if file == "stdout" {
 			writers = append(writers, os.Stdout)
+		} else if wholeFile {
+			f.batchFiles = append(f.batchFiles, file)
 		} else {
 			of, err := rotate.NewFileWriter(
 				file, time.Duration(f.RotationInterval), int64(f.RotationMaxSize), f.RotationMaxArchives)
@@ -77,6 +89,10 @@ func (f *File) Close() error {
 }
 
 func (f *File) Write(metrics []telegraf.Metric) error {
+	if f.batchFiles != nil {
+		return f.writeBatchFiles(metrics)
+	}
+
 	var writeErr error
 
 	if f.UseBatchFormat {
@@ -106,6 +122,32 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 	return writeErr
 }
 
+// writeBatchFiles writes the metrics as one complete file per configured file
+// as the output of whole-file formats cannot be appended to existing files.
+// The files are named after the configured ones with the current time inserted
+// before the extension.
+func (f *File) writeBatchFiles(metrics []telegraf.Metric) error {
+	octets, err := f.serializer.SerializeBatch(metrics)
+	if err != nil {
+		return fmt.Errorf("could not serialize metrics: %w", err)
+	}
+
+	if _, err := f.writer.Write(octets); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	suffix := time.Now().UTC().Format("20060102T150405.000000000Z")
+	for _, file := range f.batchFiles {
+		ext := filepath.Ext(file)
+		filename := strings.TrimSuffix(file, ext) + "." + suffix + ext
+		if err := os.WriteFile(filename, octets, rotate.FilePerm); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func init() {
 	outputs.Add("file", func() telegraf.Output {
 		return &File{}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/parquet"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -172,6 +174,42 @@ func TestFileStdout(t *testing.T) {
 	require.Equal(t, expNewFile, out)
 }
 
+func TestFileWholeFileFormat(t *testing.T) {
+	s := &parquet.Serializer{}
+	require.NoError(t, s.Init())
+
+	dir := t.TempDir()
+	f := File{
+		Files:      []string{filepath.Join(dir, "metrics.parquet")},
+		serializer: s,
+	}
+
+	err := f.Connect()
+	require.NoError(t, err)
+
+	// Every batch must end up in a separate complete file
+	err = f.Write(testutil.MockMetrics())
+	require.NoError(t, err)
+	err = f.Write(testutil.MockMetrics())
+	require.NoError(t, err)
+
+	err = f.Close()
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "metrics.*.parquet"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, fn := range files {
+		buf, err := os.ReadFile(fn)
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(buf, []byte("PAR1")), "missing header in %q", fn)
+		require.True(t, bytes.HasSuffix(buf, []byte("PAR1")), "missing footer in %q", fn)
+	}
+
+	// The configured file itself must not be written
+	require.NoFileExists(t, filepath.Join(dir, "metrics.parquet"))
+}
+
 func createFile(t *testing.T) *os.File {
 	f, err := os.CreateTemp(t.TempDir(), "")
 	require.NoError(t, err)
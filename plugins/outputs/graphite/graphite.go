@@ -15,7 +15,7 @@ import (
 	"github.com/influxdata/telegraf"
 	tlsint "github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
-	"github.com/influxdata/telegraf/plugins/serializers"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/graphite"
 )
 
 // DO NOT REMOVE THE NEXT TWO LINES! This is required to embed the sampleConfig data.
@@ -161,8 +161,15 @@ func (g *Graphite) checkEOF(conn net.Conn) error {
 func (g *Graphite) Write(metrics []telegraf.Metric) error {
 	// Prepare data
 	var batch []byte
-	s, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template, g.GraphiteTagSupport, g.GraphiteTagSanitizeMode, g.GraphiteSeparator, g.Templates)
-	if err != nil {
+	s := &serializer.GraphiteSerializer{
+		Prefix:          g.Prefix,
+		Template:        g.Template,
+		TagSupport:      g.GraphiteTagSupport,
+		TagSanitizeMode: g.GraphiteTagSanitizeMode,
+		Separator:       g.GraphiteSeparator,
+		Templates:       g.Templates,
+	}
+	if err := s.Init(); err != nil {
 		return err
 	}
 
@@ -174,7 +181,7 @@ func (g *Graphite) Write(metrics []telegraf.Metric) error {
 		batch = append(batch, buf...)
 	}
 
-	err = g.send(batch)
+	err := g.send(batch)
 
 	// If a send failed for a server, try to reconnect to that server
 	if len(g.failedServers) > 0 {
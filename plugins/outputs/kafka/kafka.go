@@ -3,8 +3,11 @@ package kafka
 
 import (
 	_ "embed"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,6 +19,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/common/proxy"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 // DO NOT REMOVE THE NEXT TWO LINES! This is required to embed the sampleConfig data.
@@ -28,6 +32,13 @@ var ValidTopicSuffixMethods = []string{
 	"tags",
 }
 
+var ValidPartitioners = []string{
+	"hash",
+	"random",
+	"round_robin",
+	"manual",
+}
+
 var zeroTime = time.Unix(0, 0)
 
 type Kafka struct {
@@ -39,6 +50,12 @@ type Kafka struct {
 	RoutingTag      string      `toml:"routing_tag"`
 	RoutingKey      string      `toml:"routing_key"`
 
+	HeaderTags        []string          `toml:"header_tags"`
+	Partitioner       string            `toml:"partitioner"`
+	TopicPartitioners map[string]string `toml:"topic_partitioners"`
+	PartitionTags     []string          `toml:"partition_tags"`
+	PartitionTag      string            `toml:"partition_tag"`
+
 	proxy.Socks5ProxyConfig
 
 	// Legacy TLS config options
@@ -57,7 +74,14 @@ type Kafka struct {
 	producerFunc func(addrs []string, config *sarama.Config) (sarama.SyncProducer, error)
 	producer     sarama.SyncProducer
 
+	// Client looking up the partitions of the topics for the manual partitioner
+	manual     bool
+	clientFunc func(addrs []string, config *sarama.Config) (sarama.Client, error)
+	client     sarama.Client
+
 	serializer serializers.Serializer
+
+	stats map[string]selfstat.Stat
 }
 
 type TopicSuffix struct {
@@ -95,6 +119,15 @@ func ValidateTopicSuffixMethod(method string) error {
 	return fmt.Errorf("unknown topic suffix method provided: %s", method)
 }
 
+func ValidatePartitioner(partitioner string) error {
+	for _, valid := range ValidPartitioners {
+		if partitioner == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown partitioner provided: %s", partitioner)
+}
+
 func (*Kafka) SampleConfig() string {
 	return sampleConfig
 }
@@ -144,13 +177,33 @@ func (k *Kafka) Init() error {
 	if err != nil {
 		return err
 	}
-	config := sarama.NewConfig()
 
-	if err := k.SetConfig(config); err != nil {
+	if k.Partitioner == "" {
+		k.Partitioner = "hash"
+	}
+	manual := k.Partitioner == "manual"
+	if err := ValidatePartitioner(k.Partitioner); err != nil {
 		return err
 	}
+	for _, partitioner := range k.TopicPartitioners {
+		if err := ValidatePartitioner(partitioner); err != nil {
+			return err
+		}
+		manual = manual || partitioner == "manual"
+	}
+	if manual && k.PartitionTag == "" {
+		return errors.New("partition_tag must be set for the manual partitioner")
+	}
+	k.manual = manual
+
+	cfg := sarama.NewConfig()
+
+	if err := k.SetConfig(cfg); err != nil {
+		return err
+	}
+	cfg.Producer.Partitioner = k.newPartitioner
 
-	k.saramaConfig = config
+	k.saramaConfig = cfg
 
 	// Legacy support ssl config
 	if k.Certificate != "" {
@@ -160,13 +213,13 @@ func (k *Kafka) Init() error {
 	}
 
 	if k.Socks5ProxyEnabled {
-		config.Net.Proxy.Enable = true
+		cfg.Net.Proxy.Enable = true
 
 		dialer, err := k.Socks5ProxyConfig.GetDialer()
 		if err != nil {
 			return fmt.Errorf("connecting to proxy server failed: %s", err)
 		}
-		config.Net.Proxy.Dialer = dialer
+		cfg.Net.Proxy.Dialer = dialer
 	}
 
 	return nil
@@ -178,10 +231,25 @@ func (k *Kafka) Connect() error {
 		return err
 	}
 	k.producer = producer
+
+	if k.manual {
+		client, err := k.clientFunc(k.Brokers, k.saramaConfig)
+		if err != nil {
+			//nolint:errcheck,revive // Ignore the returned error as connecting failed anyway
+			producer.Close()
+			return err
+		}
+		k.client = client
+	}
 	return nil
 }
 
 func (k *Kafka) Close() error {
+	if k.client != nil {
+		if err := k.client.Close(); err != nil {
+			k.Log.Errorf("Closing client failed: %v", err)
+		}
+	}
 	return k.producer.Close()
 }
 
@@ -204,6 +272,161 @@ func (k *Kafka) routingKey(metric telegraf.Metric) (string, error) {
 	return k.RoutingKey, nil
 }
 
+// partitioner returns the name of the partitioner used for the topic
+func (k *Kafka) partitioner(topic string) string {
+	if partitioner, found := k.TopicPartitioners[topic]; found {
+		return partitioner
+	}
+	return k.Partitioner
+}
+
+func (k *Kafka) newPartitioner(topic string) sarama.Partitioner {
+	switch k.partitioner(topic) {
+	case "random":
+		return sarama.NewRandomPartitioner(topic)
+	case "round_robin":
+		return sarama.NewRoundRobinPartitioner(topic)
+	case "manual":
+		return sarama.NewManualPartitioner(topic)
+	}
+	if len(k.PartitionTags) > 0 {
+		return &tagHashPartitioner{}
+	}
+	return sarama.NewHashPartitioner(topic)
+}
+
+// partition sets the partitioning information of the message for the
+// partitioner of the topic
+func (k *Kafka) partition(metric telegraf.Metric, m *sarama.ProducerMessage) error {
+	switch k.partitioner(m.Topic) {
+	case "manual":
+		value, ok := metric.GetTag(k.PartitionTag)
+		if !ok {
+			return fmt.Errorf("partition tag %q not found", k.PartitionTag)
+		}
+		partition, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid partition %q: %v", value, err)
+		}
+		m.Partition = int32(partition)
+
+		// A message for a partition not existing fails the whole batch, so
+		// drop the metric instead. If the partitions are unknown, leave the
+		// error to the producer.
+		exists, err := k.partitionExists(m.Topic, m.Partition)
+		if err != nil {
+			k.Log.Debugf("Getting partitions of topic %q failed: %v", m.Topic, err)
+		} else if !exists {
+			return fmt.Errorf("partition %d does not exist in topic %q", m.Partition, m.Topic)
+		}
+	case "hash":
+		if len(k.PartitionTags) > 0 {
+			m.Metadata = partitionKey(metric, k.PartitionTags)
+		}
+	}
+	return nil
+}
+
+// partitionExists checks if the topic has the given partition. The
+// partitions are cached by the client, so refresh them once if the partition
+// is not found in case partitions were added to the topic.
+func (k *Kafka) partitionExists(topic string, partition int32) (bool, error) {
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			if err := k.client.RefreshMetadata(topic); err != nil {
+				return false, err
+			}
+		}
+		partitions, err := k.client.Partitions(topic)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range partitions {
+			if p == partition {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// partitionKey joins the values of the given tags, missing tags are treated
+// as empty values
+func partitionKey(metric telegraf.Metric, tags []string) []byte {
+	var key []byte
+	for i, tag := range tags {
+		if i > 0 {
+			key = append(key, 0)
+		}
+		value, _ := metric.GetTag(tag)
+		key = append(key, value...)
+	}
+	return key
+}
+
+// tagHashPartitioner chooses the partition by the hash of the partition tags
+// stored in the message metadata
+type tagHashPartitioner struct{}
+
+func (*tagHashPartitioner) Partition(message *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	key, _ := message.Metadata.([]byte)
+	hasher := fnv.New32a()
+	_, _ = hasher.Write(key)
+	return int32(hasher.Sum32() % uint32(numPartitions)), nil
+}
+
+func (*tagHashPartitioner) RequiresConsistency() bool {
+	return true
+}
+
+func (k *Kafka) headers(metric telegraf.Metric) []sarama.RecordHeader {
+	var headers []sarama.RecordHeader
+	for _, tag := range k.HeaderTags {
+		if value, ok := metric.GetTag(tag); ok {
+			headers = append(headers, sarama.RecordHeader{Key: []byte(tag), Value: []byte(value)})
+		}
+	}
+	return headers
+}
+
+// updateStats records the delivered and failed messages per topic
+func (k *Kafka) updateStats(msgs []*sarama.ProducerMessage, err error) {
+	failed := make(map[*sarama.ProducerMessage]bool)
+	if err != nil {
+		errs, ok := err.(sarama.ProducerErrors)
+		if !ok {
+			for _, m := range msgs {
+				k.stat("messages_failed", m.Topic).Incr(1)
+			}
+			return
+		}
+		for _, prodErr := range errs {
+			failed[prodErr.Msg] = true
+		}
+	}
+
+	for _, m := range msgs {
+		if failed[m] {
+			k.stat("messages_failed", m.Topic).Incr(1)
+		} else {
+			k.stat("messages_delivered", m.Topic).Incr(1)
+		}
+	}
+}
+
+func (k *Kafka) stat(name, topic string) selfstat.Stat {
+	if k.stats == nil {
+		k.stats = make(map[string]selfstat.Stat)
+	}
+	key := name + "/" + topic
+	s, found := k.stats[key]
+	if !found {
+		s = selfstat.Register("kafka", name, map[string]string{"topic": topic})
+		k.stats[key] = s
+	}
+	return s
+}
+
 func (k *Kafka) Write(metrics []telegraf.Metric) error {
 	msgs := make([]*sarama.ProducerMessage, 0, len(metrics))
 	for _, metric := range metrics {
@@ -233,10 +456,18 @@ func (k *Kafka) Write(metrics []telegraf.Metric) error {
 		if key != "" {
 			m.Key = sarama.StringEncoder(key)
 		}
+
+		if err := k.partition(metric, m); err != nil {
+			k.Log.Errorf("Dropping metric %q: %v", metric.Name(), err)
+			continue
+		}
+		m.Headers = k.headers(metric)
+
 		msgs = append(msgs, m)
 	}
 
 	err := k.producer.SendMessages(msgs)
+	k.updateStats(msgs, err)
 	if err != nil {
 		// We could have many errors, return only the first encountered.
 		if errs, ok := err.(sarama.ProducerErrors); ok {
@@ -267,6 +498,7 @@ func init() {
 				RequiredAcks: -1,
 			},
 			producerFunc: sarama.NewSyncProducer,
+			clientFunc:   sarama.NewClient,
 		}
 	})
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -229,6 +230,31 @@ func NewMockProducer(_ []string, _ *sarama.Config) (sarama.SyncProducer, error)
 	return &MockProducer{}, nil
 }
 
+// MockClient provides the partitions of the topics, all other methods of the
+// client are not implemented
+type MockClient struct {
+	sarama.Client
+	partitions map[string][]int32
+	refreshed  int
+}
+
+func (c *MockClient) Partitions(topic string) ([]int32, error) {
+	partitions, ok := c.partitions[topic]
+	if !ok {
+		return nil, sarama.ErrUnknownTopicOrPartition
+	}
+	return partitions, nil
+}
+
+func (c *MockClient) RefreshMetadata(_ ...string) error {
+	c.refreshed++
+	return nil
+}
+
+func (c *MockClient) Close() error {
+	return nil
+}
+
 func TestTopicTag(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -349,3 +375,234 @@ func TestTopicTag(t *testing.T) {
 		})
 	}
 }
+
+func TestHeaderTags(t *testing.T) {
+	plugin := &Kafka{
+		Brokers:      []string{"127.0.0.1"},
+		Topic:        "telegraf",
+		HeaderTags:   []string{"host", "region"},
+		producerFunc: NewMockProducer,
+		Log:          testutil.Logger{},
+	}
+	plugin.SetSerializer(influx.NewSerializer())
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	producer := &MockProducer{}
+	plugin.producer = producer
+
+	m := metric.New(
+		"cpu",
+		map[string]string{"host": "server01", "cpu": "cpu0"},
+		map[string]interface{}{"time_idle": 42.0},
+		time.Unix(0, 0),
+	)
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+
+	require.Len(t, producer.sent, 1)
+	expected := []sarama.RecordHeader{{Key: []byte("host"), Value: []byte("server01")}}
+	require.Equal(t, expected, producer.sent[0].Headers)
+}
+
+func TestPartitioner(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "a", "partition": "2"},
+			map[string]interface{}{"time_idle": 42.0},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"mem",
+			map[string]string{"host": "a", "partition": "1"},
+			map[string]interface{}{"free": 42.0},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"disk",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"free": 42.0},
+			time.Unix(0, 0),
+		),
+	}
+
+	tests := []struct {
+		name   string
+		plugin *Kafka
+		check  func(t *testing.T, plugin *Kafka, sent []*sarama.ProducerMessage)
+	}{
+		{
+			name: "manual",
+			plugin: &Kafka{
+				Partitioner:  "manual",
+				PartitionTag: "partition",
+			},
+			check: func(t *testing.T, _ *Kafka, sent []*sarama.ProducerMessage) {
+				// The metric without partition tag is dropped
+				require.Len(t, sent, 2)
+				require.Equal(t, int32(2), sent[0].Partition)
+				require.Equal(t, int32(1), sent[1].Partition)
+			},
+		},
+		{
+			name: "manual with missing partition",
+			plugin: &Kafka{
+				Partitioner:  "manual",
+				PartitionTag: "partition",
+				clientFunc: func(_ []string, _ *sarama.Config) (sarama.Client, error) {
+					return &MockClient{partitions: map[string][]int32{"telegraf": {0, 1}}}, nil
+				},
+			},
+			check: func(t *testing.T, plugin *Kafka, sent []*sarama.ProducerMessage) {
+				// Only the metric for the missing partition is dropped after
+				// refreshing the partitions of the topic
+				require.Len(t, sent, 1)
+				require.Equal(t, int32(1), sent[0].Partition)
+				require.Equal(t, 1, plugin.client.(*MockClient).refreshed)
+			},
+		},
+		{
+			name: "manual with unknown partitions",
+			plugin: &Kafka{
+				Partitioner:  "manual",
+				PartitionTag: "partition",
+				clientFunc: func(_ []string, _ *sarama.Config) (sarama.Client, error) {
+					return &MockClient{}, nil
+				},
+			},
+			check: func(t *testing.T, _ *Kafka, sent []*sarama.ProducerMessage) {
+				// The messages are left to the producer if the partitions
+				// cannot be looked up
+				require.Len(t, sent, 2)
+			},
+		},
+		{
+			name: "hash by tags",
+			plugin: &Kafka{
+				PartitionTags: []string{"host"},
+			},
+			check: func(t *testing.T, plugin *Kafka, sent []*sarama.ProducerMessage) {
+				require.Len(t, sent, 3)
+				partitioner := plugin.saramaConfig.Producer.Partitioner("telegraf")
+				require.True(t, partitioner.RequiresConsistency())
+
+				partitions := make([]int32, 0, len(sent))
+				for _, m := range sent {
+					require.Nil(t, m.Key)
+					p, err := partitioner.Partition(m, 1024)
+					require.NoError(t, err)
+					partitions = append(partitions, p)
+				}
+				require.Equal(t, partitions[0], partitions[1])
+				require.NotEqual(t, partitions[0], partitions[2])
+			},
+		},
+		{
+			name: "per topic",
+			plugin: &Kafka{
+				TopicTag:          "topic",
+				Partitioner:       "round_robin",
+				TopicPartitioners: map[string]string{"other": "random"},
+			},
+			check: func(t *testing.T, plugin *Kafka, sent []*sarama.ProducerMessage) {
+				require.Len(t, sent, 3)
+				require.IsType(t, sarama.NewRoundRobinPartitioner("telegraf"), plugin.saramaConfig.Producer.Partitioner("telegraf"))
+				require.IsType(t, sarama.NewRandomPartitioner("other"), plugin.saramaConfig.Producer.Partitioner("other"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.plugin.Brokers = []string{"127.0.0.1"}
+			tt.plugin.Topic = "telegraf"
+			tt.plugin.producerFunc = NewMockProducer
+			if tt.plugin.clientFunc == nil {
+				tt.plugin.clientFunc = func(_ []string, _ *sarama.Config) (sarama.Client, error) {
+					return &MockClient{partitions: map[string][]int32{"telegraf": {0, 1, 2}}}, nil
+				}
+			}
+			tt.plugin.Log = testutil.Logger{}
+			tt.plugin.SetSerializer(influx.NewSerializer())
+			require.NoError(t, tt.plugin.Init())
+			require.NoError(t, tt.plugin.Connect())
+
+			producer := &MockProducer{}
+			tt.plugin.producer = producer
+			require.NoError(t, tt.plugin.Write(metrics))
+			tt.check(t, tt.plugin, producer.sent)
+		})
+	}
+}
+
+type FailingProducer struct {
+	MockProducer
+	err sarama.KError
+}
+
+func (p *FailingProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	errs := make(sarama.ProducerErrors, 0, 1)
+	for _, m := range msgs {
+		if m.Topic == "failing" {
+			errs = append(errs, &sarama.ProducerError{Msg: m, Err: p.err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func TestDeliveryStats(t *testing.T) {
+	plugin := &Kafka{
+		Brokers:      []string{"127.0.0.1"},
+		Topic:        "delivered",
+		TopicTag:     "topic",
+		producerFunc: NewMockProducer,
+		Log:          testutil.Logger{},
+	}
+	plugin.SetSerializer(influx.NewSerializer())
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	plugin.producer = &FailingProducer{err: sarama.ErrNotEnoughReplicas}
+
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{"topic": "failing"}, map[string]interface{}{"value": 3.0}, time.Unix(0, 0)),
+	}
+	require.ErrorIs(t, plugin.Write(metrics), sarama.ErrNotEnoughReplicas)
+
+	require.Equal(t, int64(2), plugin.stat("messages_delivered", "delivered").Get())
+	require.Equal(t, int64(0), plugin.stat("messages_failed", "delivered").Get())
+	require.Equal(t, int64(0), plugin.stat("messages_delivered", "failing").Get())
+	require.Equal(t, int64(1), plugin.stat("messages_failed", "failing").Get())
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *Kafka
+		expected string
+	}{
+		{
+			name:     "invalid partitioner",
+			plugin:   &Kafka{Partitioner: "sticky"},
+			expected: "unknown partitioner provided: sticky",
+		},
+		{
+			name:     "invalid topic partitioner",
+			plugin:   &Kafka{TopicPartitioners: map[string]string{"telegraf": "sticky"}},
+			expected: "unknown partitioner provided: sticky",
+		},
+		{
+			name:     "manual partitioner without tag",
+			plugin:   &Kafka{TopicPartitioners: map[string]string{"telegraf": "manual"}},
+			expected: "partition_tag must be set for the manual partitioner",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.EqualError(t, tt.plugin.Init(), tt.expected)
+		})
+	}
+}
@@ -11,14 +11,18 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/golang/snappy"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/templating"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
@@ -44,9 +48,18 @@ type Loki struct {
 	TokenURL     string            `toml:"token_url"`
 	Scopes       []string          `toml:"scopes"`
 	GZipRequest  bool              `toml:"gzip_request"`
-
-	url    string
-	client *http.Client
+	Format       string            `toml:"format"`
+	LabelTags    []string          `toml:"label_tags"`
+	MaxLabels    int               `toml:"max_labels"`
+	NonLabelTags string            `toml:"non_label_tags"`
+	LineTemplate string            `toml:"line_template"`
+	TenantTag    string            `toml:"tenant_tag"`
+	Log          telegraf.Logger   `toml:"-"`
+
+	url          string
+	client       *http.Client
+	labelFilter  filter.Filter
+	lineTemplate *template.Template
 	tls.ClientConfig
 }
 
@@ -93,6 +106,38 @@ func (l *Loki) Connect() (err error) {
 
 	l.url = fmt.Sprintf("%s%s", l.Domain, l.Endpoint)
 
+	switch l.Format {
+	case "":
+		l.Format = "json"
+	case "json":
+	case "protobuf":
+		if l.GZipRequest {
+			return fmt.Errorf("gzip_request is not supported for the protobuf format")
+		}
+	default:
+		return fmt.Errorf("invalid format %q", l.Format)
+	}
+
+	switch l.NonLabelTags {
+	case "":
+		l.NonLabelTags = "line"
+	case "line", "metadata", "drop":
+	default:
+		return fmt.Errorf("invalid non_label_tags %q", l.NonLabelTags)
+	}
+
+	if len(l.LabelTags) > 0 {
+		if l.labelFilter, err = filter.Compile(l.LabelTags); err != nil {
+			return fmt.Errorf("invalid label_tags: %w", err)
+		}
+	}
+
+	if l.LineTemplate != "" {
+		if l.lineTemplate, err = templating.New("line", l.LineTemplate); err != nil {
+			return fmt.Errorf("parsing line template failed: %w", err)
+		}
+	}
+
 	if l.Timeout == 0 {
 		l.Timeout = config.Duration(defaultClientTimeout)
 	}
@@ -113,7 +158,10 @@ func (l *Loki) Close() error {
 }
 
 func (l *Loki) Write(metrics []telegraf.Metric) error {
-	s := Streams{}
+	// Streams per tenant, metrics without tenant tag use the empty tenant
+	// and the headers configured for the plugin
+	var tenants []string
+	streams := make(map[string]Streams)
 
 	sort.SliceStable(metrics, func(i, j int) bool {
 		return metrics[i].Time().Before(metrics[j].Time())
@@ -122,23 +170,104 @@ func (l *Loki) Write(metrics []telegraf.Metric) error {
 	for _, m := range metrics {
 		m.AddTag("__name", m.Name())
 
-		tags := m.TagList()
-		var line string
+		labels, others := l.splitTags(m)
+
+		line, err := l.line(m, others)
+		if err != nil {
+			l.Log.Errorf("Dropping metric %q: rendering line template failed: %v", m.Name(), err)
+			continue
+		}
+		entry := Log{fmt.Sprintf("%d", m.Time().UnixNano()), line}
+		if l.NonLabelTags == "metadata" && len(others) > 0 {
+			metadata := make(map[string]string, len(others))
+			for _, t := range others {
+				metadata[t.Key] = t.Value
+			}
+			entry = append(entry, metadata)
+		}
+
+		var tenant string
+		if l.TenantTag != "" {
+			tenant, _ = m.GetTag(l.TenantTag)
+		}
+		s, found := streams[tenant]
+		if !found {
+			s = Streams{}
+			streams[tenant] = s
+			tenants = append(tenants, tenant)
+		}
+		s.insertLog(labels, entry)
+	}
+
+	for _, tenant := range tenants {
+		if err := l.writeMetrics(streams[tenant], tenant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		for _, f := range m.FieldList() {
-			line += fmt.Sprintf("%s=\"%v\" ", f.Key, f.Value)
+// splitTags returns the tags used as stream labels and the remaining tags,
+// skipping the tenant tag
+func (l *Loki) splitTags(m telegraf.Metric) (labels, others []*telegraf.Tag) {
+	var count int
+	for _, t := range m.TagList() {
+		switch {
+		case l.TenantTag != "" && t.Key == l.TenantTag:
+		case t.Key == "__name":
+			labels = append(labels, t)
+		case l.labelFilter != nil && !l.labelFilter.Match(t.Key):
+			others = append(others, t)
+		case l.MaxLabels > 0 && count >= l.MaxLabels:
+			others = append(others, t)
+		default:
+			labels = append(labels, t)
+			count++
 		}
+	}
+	return labels, others
+}
 
-		s.insertLog(tags, Log{fmt.Sprintf("%d", m.Time().UnixNano()), line})
+// line renders the log line of the metric using the template if configured,
+// otherwise the tags not used as labels and the fields are written in
+// `key="value"` format
+func (l *Loki) line(m telegraf.Metric, others []*telegraf.Tag) (string, error) {
+	if l.lineTemplate != nil {
+		var buf bytes.Buffer
+		if err := l.lineTemplate.Execute(&buf, templating.NewMetric(m)); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
 	}
 
-	return l.writeMetrics(s)
+	var line string
+	if l.NonLabelTags == "line" {
+		for _, t := range others {
+			line += fmt.Sprintf("%s=\"%v\" ", t.Key, t.Value)
+		}
+	}
+	for _, f := range m.FieldList() {
+		line += fmt.Sprintf("%s=\"%v\" ", f.Key, f.Value)
+	}
+	return line, nil
 }
 
-func (l *Loki) writeMetrics(s Streams) error {
-	bs, err := json.Marshal(s)
-	if err != nil {
-		return fmt.Errorf("json.Marshal: %w", err)
+func (l *Loki) writeMetrics(s Streams, tenant string) error {
+	var bs []byte
+	var err error
+	contentType := "application/json"
+	if l.Format == "protobuf" {
+		bs, err = s.MarshalProtobuf()
+		if err != nil {
+			return fmt.Errorf("encoding protobuf failed: %w", err)
+		}
+		bs = snappy.Encode(nil, bs)
+		contentType = "application/x-protobuf"
+	} else {
+		bs, err = json.Marshal(s)
+		if err != nil {
+			return fmt.Errorf("json.Marshal: %w", err)
+		}
 	}
 
 	var reqBodyBuffer io.Reader = bytes.NewBuffer(bs)
@@ -167,9 +296,12 @@ func (l *Loki) writeMetrics(s Streams) error {
 		}
 		req.Header.Set(k, v)
 	}
+	if tenant != "" {
+		req.Header.Set("X-Scope-OrgID", tenant)
+	}
 
 	req.Header.Set("User-Agent", internal.ProductToken())
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	if l.GZipRequest {
 		req.Header.Set("Content-Encoding", "gzip")
 	}
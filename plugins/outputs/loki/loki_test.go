@@ -8,10 +8,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/golang/snappy"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
@@ -427,3 +430,277 @@ func TestMetricSorting(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestLabelSelection(t *testing.T) {
+	m := testutil.MustMetric(
+		"log",
+		map[string]string{
+			"host":   "server01",
+			"region": "eu",
+			"path":   "/var/log/syslog",
+		},
+		map[string]interface{}{
+			"message": "started",
+		},
+		time.Unix(123, 0),
+	)
+
+	tests := []struct {
+		name     string
+		plugin   *Loki
+		labels   map[string]string
+		line     string
+		metadata map[string]interface{}
+	}{
+		{
+			name:   "label tags with rest in line",
+			plugin: &Loki{LabelTags: []string{"host", "reg*"}},
+			labels: map[string]string{"__name": "log", "host": "server01", "region": "eu"},
+			line:   `path="/var/log/syslog" message="started" `,
+		},
+		{
+			name: "label tags with rest as metadata",
+			plugin: &Loki{
+				LabelTags:    []string{"host"},
+				NonLabelTags: "metadata",
+			},
+			labels:   map[string]string{"__name": "log", "host": "server01"},
+			line:     `message="started" `,
+			metadata: map[string]interface{}{"path": "/var/log/syslog", "region": "eu"},
+		},
+		{
+			name: "dropped tags",
+			plugin: &Loki{
+				LabelTags:    []string{"host"},
+				NonLabelTags: "drop",
+			},
+			labels: map[string]string{"__name": "log", "host": "server01"},
+			line:   `message="started" `,
+		},
+		{
+			name:   "label limit",
+			plugin: &Loki{MaxLabels: 2},
+			labels: map[string]string{"__name": "log", "host": "server01", "path": "/var/log/syslog"},
+			line:   `region="eu" message="started" `,
+		},
+		{
+			name: "line template",
+			plugin: &Loki{
+				LabelTags:    []string{"host"},
+				LineTemplate: `[{{.Tag "region"}}] {{.Field "message"}}`,
+			},
+			labels: map[string]string{"__name": "log", "host": "server01"},
+			line:   "[eu] started",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				payload, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				var s Request
+				require.NoError(t, json.Unmarshal(payload, &s))
+				require.Len(t, s.Streams, 1)
+				require.Len(t, s.Streams[0].Logs, 1)
+				require.Equal(t, tt.labels, s.Streams[0].Labels)
+				require.Equal(t, tt.line, s.Streams[0].Logs[0][1])
+				if tt.metadata == nil {
+					require.Len(t, s.Streams[0].Logs[0], 2)
+				} else {
+					require.Len(t, s.Streams[0].Logs[0], 3)
+					require.Equal(t, tt.metadata, s.Streams[0].Logs[0][2])
+				}
+
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer ts.Close()
+
+			tt.plugin.Domain = ts.URL
+			tt.plugin.Log = testutil.Logger{}
+			require.NoError(t, tt.plugin.Connect())
+			require.NoError(t, tt.plugin.Write([]telegraf.Metric{m.Copy()}))
+		})
+	}
+}
+
+func TestTenantTag(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"log",
+			map[string]string{"tenant": "a"},
+			map[string]interface{}{"message": "first"},
+			time.Unix(123, 0),
+		),
+		testutil.MustMetric(
+			"log",
+			map[string]string{"tenant": "b"},
+			map[string]interface{}{"message": "second"},
+			time.Unix(124, 0),
+		),
+		testutil.MustMetric(
+			"log",
+			map[string]string{},
+			map[string]interface{}{"message": "third"},
+			time.Unix(125, 0),
+		),
+	}
+
+	var mu sync.Mutex
+	received := make(map[string]Request)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var s Request
+		require.NoError(t, json.Unmarshal(payload, &s))
+
+		mu.Lock()
+		received[r.Header.Get("X-Scope-OrgID")] = s
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	plugin := &Loki{
+		Domain:    ts.URL,
+		Headers:   map[string]string{"X-Scope-OrgID": "default"},
+		TenantTag: "tenant",
+	}
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write(metrics))
+
+	require.Len(t, received, 3)
+	for tenant, line := range map[string]string{"a": "first", "b": "second", "default": "third"} {
+		require.Len(t, received[tenant].Streams, 1)
+		require.Equal(t, map[string]string{"__name": "log"}, received[tenant].Streams[0].Labels)
+		require.Equal(t, `message="`+line+`" `, received[tenant].Streams[0].Logs[0][1])
+	}
+}
+
+// decodeProtobuf decodes a snappy compressed push request in protobuf format
+func decodeProtobuf(t *testing.T, body []byte) []Stream {
+	data, err := snappy.Decode(nil, body)
+	require.NoError(t, err)
+
+	fields := func(b []byte) map[protowire.Number][][]byte {
+		result := make(map[protowire.Number][][]byte)
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+			switch typ {
+			case protowire.BytesType:
+				v, n := protowire.ConsumeBytes(b)
+				require.GreaterOrEqual(t, n, 0)
+				result[num] = append(result[num], v)
+				b = b[n:]
+			case protowire.VarintType:
+				v, n := protowire.ConsumeVarint(b)
+				require.GreaterOrEqual(t, n, 0)
+				result[num] = append(result[num], protowire.AppendVarint(nil, v))
+				b = b[n:]
+			default:
+				require.Failf(t, "unexpected wire type", "%v", typ)
+			}
+		}
+		return result
+	}
+	varint := func(b []byte) int64 {
+		v, _ := protowire.ConsumeVarint(b)
+		return int64(v)
+	}
+
+	var streams []Stream
+	for _, rawStream := range fields(data)[1] {
+		stream := fields(rawStream)
+		s := Stream{Labels: map[string]string{"labels": string(stream[1][0])}}
+		for _, rawEntry := range stream[2] {
+			entry := fields(rawEntry)
+			timestamp := fields(entry[1][0])
+			nanos := varint(timestamp[1][0])*1e9 + varint(timestamp[2][0])
+			l := Log{fmt.Sprintf("%d", nanos), string(entry[2][0])}
+			if len(entry[3]) > 0 {
+				metadata := make(map[string]string)
+				for _, rawPair := range entry[3] {
+					pair := fields(rawPair)
+					metadata[string(pair[1][0])] = string(pair[2][0])
+				}
+				l = append(l, metadata)
+			}
+			s.Logs = append(s.Logs, l)
+		}
+		streams = append(streams, s)
+	}
+	return streams
+}
+
+func TestProtobuf(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		payload, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		streams := decodeProtobuf(t, payload)
+		require.Len(t, streams, 1)
+		require.Equal(t, map[string]string{"labels": `{__name="log", key1="value1"}`}, streams[0].Labels)
+		require.Len(t, streams[0].Logs, 2)
+		require.Equal(t, Log{"456000000000", `line="older log" `, map[string]string{"field": "3.14"}}, streams[0].Logs[0])
+		require.Equal(t, Log{"1230000000000", `line="newer log" `, map[string]string{"field": "3.14"}}, streams[0].Logs[1])
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	plugin := &Loki{
+		Domain:       ts.URL,
+		Format:       "protobuf",
+		LabelTags:    []string{"key1"},
+		NonLabelTags: "metadata",
+		LineTemplate: `line="{{.Field "line"}}" `,
+	}
+	require.NoError(t, plugin.Connect())
+
+	metrics := getOutOfOrderMetrics()
+	for _, m := range metrics {
+		m.AddTag("field", fmt.Sprintf("%v", m.Fields()["field"]))
+	}
+	require.NoError(t, plugin.Write(metrics))
+}
+
+func TestConnectError(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *Loki
+		expected string
+	}{
+		{
+			name:     "invalid format",
+			plugin:   &Loki{Format: "xml"},
+			expected: `invalid format "xml"`,
+		},
+		{
+			name:     "gzip with protobuf",
+			plugin:   &Loki{Format: "protobuf", GZipRequest: true},
+			expected: "gzip_request is not supported for the protobuf format",
+		},
+		{
+			name:     "invalid non-label tags",
+			plugin:   &Loki{NonLabelTags: "labels"},
+			expected: `invalid non_label_tags "labels"`,
+		},
+		{
+			name:     "invalid template",
+			plugin:   &Loki{LineTemplate: "{{.Name"},
+			expected: "parsing line template failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.plugin.Domain = "http://localhost:3100"
+			require.ErrorContains(t, tt.plugin.Connect(), tt.expected)
+		})
+	}
+}
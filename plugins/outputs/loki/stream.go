@@ -3,13 +3,19 @@ package loki
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
+	"google.golang.org/protobuf/encoding/protowire"
+
 	"github.com/influxdata/telegraf"
 )
 
 type (
-	Log []string
+	// Log contains the timestamp, the line and the optional structured
+	// metadata of a log entry
+	Log []interface{}
 
 	Streams map[string]*Stream
 
@@ -68,3 +74,111 @@ func newStream(ts []*telegraf.Tag) *Stream {
 
 	return s
 }
+
+// MarshalProtobuf encodes the streams as Loki push request in protobuf format
+func (s Streams) MarshalProtobuf() ([]byte, error) {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf []byte
+	for _, k := range keys {
+		stream, err := s[k].marshalProtobuf()
+		if err != nil {
+			return nil, err
+		}
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, stream)
+	}
+	return buf, nil
+}
+
+func (s *Stream) marshalProtobuf() ([]byte, error) {
+	var buf []byte
+	buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+	buf = protowire.AppendString(buf, s.labelString())
+	for _, l := range s.Logs {
+		entry, err := l.marshalProtobuf()
+		if err != nil {
+			return nil, err
+		}
+		buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, entry)
+	}
+	return buf, nil
+}
+
+// labelString formats the labels in the Prometheus label format
+func (s *Stream) labelString() string {
+	names := make([]string, 0, len(s.Labels))
+	for name := range s.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+strconv.Quote(s.Labels[name]))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+func (l Log) marshalProtobuf() ([]byte, error) {
+	if len(l) < 2 {
+		return nil, fmt.Errorf("invalid log entry %v", l)
+	}
+	ts, ok := l[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid timestamp %v", l[0])
+	}
+	nanos, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q: %w", ts, err)
+	}
+	line, ok := l[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid line %v", l[1])
+	}
+
+	seconds, fraction := nanos/1e9, nanos%1e9
+	if fraction < 0 {
+		seconds--
+		fraction += 1e9
+	}
+
+	var timestamp []byte
+	timestamp = protowire.AppendTag(timestamp, 1, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, uint64(seconds))
+	timestamp = protowire.AppendTag(timestamp, 2, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, uint64(fraction))
+
+	var buf []byte
+	buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+	buf = protowire.AppendBytes(buf, timestamp)
+	buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+	buf = protowire.AppendString(buf, line)
+
+	if len(l) > 2 {
+		metadata, ok := l[2].(map[string]string)
+		if !ok {
+			return nil, fmt.Errorf("invalid structured metadata %v", l[2])
+		}
+		names := make([]string, 0, len(metadata))
+		for name := range metadata {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			var pair []byte
+			pair = protowire.AppendTag(pair, 1, protowire.BytesType)
+			pair = protowire.AppendString(pair, name)
+			pair = protowire.AppendTag(pair, 2, protowire.BytesType)
+			pair = protowire.AppendString(pair, metadata[name])
+			buf = protowire.AppendTag(buf, 3, protowire.BytesType)
+			buf = protowire.AppendBytes(buf, pair)
+		}
+	}
+	return buf, nil
+}
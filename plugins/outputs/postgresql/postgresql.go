@@ -0,0 +1,464 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package postgresql
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+// DO NOT REMOVE THE NEXT TWO LINES! This is required to embed the sampleConfig data.
+//go:embed sample.conf
+var sampleConfig string
+
+// Name of the column referencing the tag table
+const tagIDColumn = "tag_id"
+
+type Postgresql struct {
+	Connection                string          `toml:"connection"`
+	Schema                    string          `toml:"schema"`
+	TimestampColumn           string          `toml:"timestamp_column"`
+	TagsAsForeignKeys         bool            `toml:"tags_as_foreign_keys"`
+	TagTableSuffix            string          `toml:"tag_table_suffix"`
+	ForeignTagConstraint      bool            `toml:"foreign_tag_constraint"`
+	CreateTemplate            string          `toml:"create_template"`
+	AddColumnTemplate         string          `toml:"add_column_template"`
+	TagTableCreateTemplate    string          `toml:"tag_table_create_template"`
+	TagTableAddColumnTemplate string          `toml:"tag_table_add_column_template"`
+	Timescaledb               bool            `toml:"timescaledb"`
+	TimescaledbChunkInterval  config.Duration `toml:"timescaledb_chunk_interval"`
+	Uint64Type                string          `toml:"uint64_type"`
+	Timeout                   config.Duration `toml:"timeout"`
+	PoolMaxConnections        int32           `toml:"pool_max_connections"`
+	PoolMinConnections        int32           `toml:"pool_min_connections"`
+	PoolMaxConnectionLifetime config.Duration `toml:"pool_max_connection_lifetime"`
+	PoolMaxConnectionIdleTime config.Duration `toml:"pool_max_connection_idle_time"`
+	Log                       telegraf.Logger `toml:"-"`
+
+	poolConfig *pgxpool.Config
+	pool       *pgxpool.Pool
+
+	tables     map[string]*table
+	tablesLock sync.Mutex
+}
+
+func (*Postgresql) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Postgresql) Init() error {
+	switch p.Uint64Type {
+	case "numeric", "bigint":
+	default:
+		return fmt.Errorf("invalid uint64 type %q", p.Uint64Type)
+	}
+
+	if p.TimestampColumn == "" {
+		return errors.New("timestamp column must not be empty")
+	}
+	if p.TagsAsForeignKeys && p.TagTableSuffix == "" {
+		return errors.New("tag table suffix must not be empty")
+	}
+	if p.Timescaledb && p.TimescaledbChunkInterval <= 0 {
+		return errors.New("timescaledb chunk interval must be positive")
+	}
+	if p.PoolMinConnections > 0 && p.PoolMaxConnections > 0 && p.PoolMinConnections > p.PoolMaxConnections {
+		return errors.New("pool min connections must not exceed the max connections")
+	}
+
+	cfg, err := pgxpool.ParseConfig(p.Connection)
+	if err != nil {
+		return fmt.Errorf("parsing connection failed: %w", err)
+	}
+
+	// Settings in the plugin take precedence over the connection string
+	if p.PoolMaxConnections > 0 {
+		cfg.MaxConns = p.PoolMaxConnections
+	}
+	if p.PoolMinConnections > 0 {
+		cfg.MinConns = p.PoolMinConnections
+	}
+	if p.PoolMaxConnectionLifetime > 0 {
+		cfg.MaxConnLifetime = time.Duration(p.PoolMaxConnectionLifetime)
+	}
+	if p.PoolMaxConnectionIdleTime > 0 {
+		cfg.MaxConnIdleTime = time.Duration(p.PoolMaxConnectionIdleTime)
+	}
+	p.poolConfig = cfg
+
+	return nil
+}
+
+func (p *Postgresql) Connect() error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	pool, err := pgxpool.ConnectConfig(ctx, p.poolConfig)
+	if err != nil {
+		return fmt.Errorf("connecting failed: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return fmt.Errorf("ping failed: %w", err)
+	}
+
+	p.pool = pool
+	p.tables = make(map[string]*table)
+
+	return nil
+}
+
+func (p *Postgresql) Close() error {
+	if p.pool != nil {
+		p.pool.Close()
+	}
+	return nil
+}
+
+// context returns a context limited by the configured timeout
+func (p *Postgresql) context() (context.Context, context.CancelFunc) {
+	if p.Timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), time.Duration(p.Timeout))
+}
+
+func (p *Postgresql) Write(metrics []telegraf.Metric) error {
+	// Group the metrics by measurement as every measurement is written to
+	// its own table
+	var names []string
+	groups := make(map[string][]telegraf.Metric)
+	for _, m := range metrics {
+		if _, found := groups[m.Name()]; !found {
+			names = append(names, m.Name())
+		}
+		groups[m.Name()] = append(groups[m.Name()], m)
+	}
+
+	// Write the measurements concurrently, the number of parallel writes is
+	// limited by the size of the connection pool
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			errs[i] = p.writeMeasurement(name, groups[name])
+		}(i, name)
+	}
+	wg.Wait()
+
+	var messages []string
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		// Data errors will not resolve by retrying the write, so drop the
+		// metrics instead of blocking the output
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && isPermanent(pgErr) {
+			p.Log.Errorf("Dropping %d metrics of measurement %q: %v", len(groups[names[i]]), names[i], err)
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("writing measurement %q failed: %v", names[i], err))
+	}
+	if len(messages) > 0 {
+		return errors.New(strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+func (p *Postgresql) writeMeasurement(name string, metrics []telegraf.Metric) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	metricTable := p.table(name)
+	var tagTable *table
+	if p.TagsAsForeignKeys {
+		tagTable = p.table(name + p.TagTableSuffix)
+	}
+
+	d := p.buildData(metrics)
+	if tagTable != nil {
+		if err := p.updateTagTable(ctx, tagTable, d.tagColumns); err != nil {
+			return err
+		}
+	}
+	if err := p.updateMetricTable(ctx, metricTable, tagTable, d.columns); err != nil {
+		return err
+	}
+	p.dropIncompatible(name, d, metricTable.columnTypes())
+
+	var tagIDs []int64
+	err := p.pool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if tagTable != nil {
+			var err error
+			if tagIDs, err = p.insertTags(ctx, tx, tagTable, d); err != nil {
+				return err
+			}
+		}
+
+		names := make([]string, 0, len(d.columns))
+		for _, c := range d.columns {
+			names = append(names, c.name)
+		}
+		_, err := tx.CopyFrom(ctx, pgx.Identifier{p.Schema, name}, names, pgx.CopyFromRows(d.rows))
+		return err
+	})
+	if err != nil {
+		// The cached state might be outdated if the tables were changed
+		// externally, so check the tables again with the next write
+		metricTable.reset()
+		if tagTable != nil {
+			tagTable.reset()
+		}
+		return err
+	}
+
+	if tagTable != nil {
+		tagTable.addTagIDs(tagIDs)
+	}
+	return nil
+}
+
+// dropIncompatible replaces values not matching the type of an existing column
+// by NULL, as a single value failing to encode would fail the whole copy
+func (p *Postgresql) dropIncompatible(name string, d *data, types map[string]string) {
+	for i, c := range d.columns {
+		datatype := types[c.name]
+
+		var dropped int
+		for _, row := range d.rows {
+			if row[i] != nil && !compatible(datatype, row[i]) {
+				row[i] = nil
+				dropped++
+			}
+		}
+		if dropped > 0 {
+			p.Log.Warnf("Dropped %d values of column %q in table %q not matching the column type %q",
+				dropped, c.name, name, datatype)
+		}
+	}
+}
+
+// insertTags inserts the tag sets not yet known to be in the tag table and
+// returns the IDs of the inserted tag sets
+func (p *Postgresql) insertTags(ctx context.Context, tx pgx.Tx, tagTable *table, d *data) ([]int64, error) {
+	names := make([]string, 0, len(d.tagColumns))
+	for _, c := range d.tagColumns {
+		names = append(names, quoteIdent(c.name))
+	}
+	placeholders := make([]string, 0, len(d.tagColumns))
+	for i := range d.tagColumns {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING",
+		p.tableIdent(tagTable.name), strings.Join(names, ", "), strings.Join(placeholders, ", "))
+
+	ids := make([]int64, 0, len(d.tagRows))
+	batch := &pgx.Batch{}
+	for _, id := range d.tagIDs {
+		if tagTable.hasTagID(id) {
+			continue
+		}
+		batch.Queue(query, d.tagRows[id]...)
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	for range ids {
+		if _, err := results.Exec(); err != nil {
+			results.Close() //nolint:errcheck,revive // We already have an error to return.
+			return nil, fmt.Errorf("inserting tags failed: %w", err)
+		}
+	}
+	return ids, results.Close()
+}
+
+// isPermanent checks if the error is caused by the data and thus will occur
+// again when retrying the write
+func isPermanent(err *pgconn.PgError) bool {
+	// See https://www.postgresql.org/docs/current/errcodes-appendix.html
+	if len(err.Code) < 2 {
+		return false
+	}
+	switch err.Code[:2] {
+	case "22": // Data exception
+		return true
+	case "23": // Integrity constraint violation
+		return true
+	}
+	return false
+}
+
+// column of a table with the datatype used when creating the column
+type column struct {
+	name     string
+	datatype string
+}
+
+// data to write for a single measurement
+type data struct {
+	columns []column
+	rows    [][]interface{}
+
+	// Tag table contents, only set when storing tags as foreign keys
+	tagColumns []column
+	tagIDs     []int64
+	tagRows    map[int64][]interface{}
+}
+
+// buildData converts the metrics of a measurement to rows, using the union of
+// all tags and fields as columns
+func (p *Postgresql) buildData(metrics []telegraf.Metric) *data {
+	tagKeys := make(map[string]bool)
+	fieldTypes := make(map[string]string)
+	for _, m := range metrics {
+		for _, tag := range m.TagList() {
+			tagKeys[tag.Key] = true
+		}
+		for _, field := range m.FieldList() {
+			if _, found := fieldTypes[field.Key]; !found {
+				fieldTypes[field.Key] = p.deriveDatatype(field.Value)
+			}
+		}
+	}
+	tags := sortedKeys(tagKeys)
+	fields := make([]string, 0, len(fieldTypes))
+	for k := range fieldTypes {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+
+	d := &data{
+		columns: []column{{p.TimestampColumn, "timestamp with time zone"}},
+		rows:    make([][]interface{}, 0, len(metrics)),
+	}
+	if p.TagsAsForeignKeys {
+		d.columns = append(d.columns, column{tagIDColumn, "bigint"})
+		d.tagColumns = []column{{tagIDColumn, "bigint"}}
+		for _, k := range tags {
+			d.tagColumns = append(d.tagColumns, column{k, "text"})
+		}
+		d.tagRows = make(map[int64][]interface{})
+	} else {
+		for _, k := range tags {
+			d.columns = append(d.columns, column{k, "text"})
+		}
+	}
+	for _, k := range fields {
+		d.columns = append(d.columns, column{k, fieldTypes[k]})
+	}
+
+	for _, m := range metrics {
+		row := make([]interface{}, 0, len(d.columns))
+		row = append(row, m.Time())
+		if p.TagsAsForeignKeys {
+			id := tagID(m)
+			row = append(row, id)
+			if _, found := d.tagRows[id]; !found {
+				tagRow := make([]interface{}, 0, len(d.tagColumns))
+				tagRow = append(tagRow, id)
+				tagRow = append(tagRow, tagValues(m, tags)...)
+				d.tagRows[id] = tagRow
+				d.tagIDs = append(d.tagIDs, id)
+			}
+		} else {
+			row = append(row, tagValues(m, tags)...)
+		}
+		for _, k := range fields {
+			v, found := m.GetField(k)
+			if !found {
+				row = append(row, nil)
+				continue
+			}
+			row = append(row, v)
+		}
+		d.rows = append(d.rows, row)
+	}
+
+	return d
+}
+
+func (p *Postgresql) deriveDatatype(value interface{}) string {
+	switch value.(type) {
+	case int64:
+		return "bigint"
+	case uint64:
+		if p.Uint64Type == "bigint" {
+			return "bigint"
+		}
+		return "numeric(20,0)"
+	case float64:
+		return "double precision"
+	case bool:
+		return "boolean"
+	default:
+		return "text"
+	}
+}
+
+// tagID identifies the tag set of the metric, the measurement name is part of
+// the hash but identical for all rows of a tag table
+func tagID(m telegraf.Metric) int64 {
+	return int64(m.HashID())
+}
+
+// tagValues returns the values of the given tags with nil for missing tags
+func tagValues(m telegraf.Metric, keys []string) []interface{} {
+	values := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		v, found := m.GetTag(k)
+		if !found {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	outputs.Add("postgresql", func() telegraf.Output { return newPostgresql() })
+}
+
+func newPostgresql() *Postgresql {
+	return &Postgresql{
+		Schema:                    "public",
+		TimestampColumn:           "time",
+		TagTableSuffix:            "_tag",
+		CreateTemplate:            "CREATE TABLE IF NOT EXISTS {TABLE} ({COLUMNS})",
+		AddColumnTemplate:         "ALTER TABLE {TABLE} ADD COLUMN IF NOT EXISTS {COLUMN}",
+		TagTableCreateTemplate:    "CREATE TABLE IF NOT EXISTS {TABLE} ({COLUMNS}, PRIMARY KEY (tag_id))",
+		TagTableAddColumnTemplate: "ALTER TABLE {TABLE} ADD COLUMN IF NOT EXISTS {COLUMN}",
+		TimescaledbChunkInterval:  config.Duration(7 * 24 * time.Hour),
+		Uint64Type:                "numeric",
+		Timeout:                   config.Duration(10 * time.Second),
+	}
+}
@@ -0,0 +1,298 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(p *Postgresql)
+		expected string
+	}{
+		{
+			name:     "invalid uint64 type",
+			modify:   func(p *Postgresql) { p.Uint64Type = "int" },
+			expected: `invalid uint64 type "int"`,
+		},
+		{
+			name:     "empty timestamp column",
+			modify:   func(p *Postgresql) { p.TimestampColumn = "" },
+			expected: "timestamp column must not be empty",
+		},
+		{
+			name: "empty tag table suffix",
+			modify: func(p *Postgresql) {
+				p.TagsAsForeignKeys = true
+				p.TagTableSuffix = ""
+			},
+			expected: "tag table suffix must not be empty",
+		},
+		{
+			name: "invalid chunk interval",
+			modify: func(p *Postgresql) {
+				p.Timescaledb = true
+				p.TimescaledbChunkInterval = 0
+			},
+			expected: "timescaledb chunk interval must be positive",
+		},
+		{
+			name: "pool min exceeds max",
+			modify: func(p *Postgresql) {
+				p.PoolMinConnections = 5
+				p.PoolMaxConnections = 2
+			},
+			expected: "pool min connections must not exceed the max connections",
+		},
+		{
+			name:     "invalid connection",
+			modify:   func(p *Postgresql) { p.Connection = "postgres://localhost:port" },
+			expected: "parsing connection failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPostgresql()
+			tt.modify(p)
+			require.ErrorContains(t, p.Init(), tt.expected)
+		})
+	}
+}
+
+func TestInitPool(t *testing.T) {
+	p := newPostgresql()
+	p.Connection = "host=localhost pool_max_conns=2 pool_min_conns=1"
+	p.PoolMaxConnections = 8
+	p.PoolMaxConnectionIdleTime = config.Duration(time.Minute)
+	require.NoError(t, p.Init())
+
+	require.Equal(t, int32(8), p.poolConfig.MaxConns)
+	require.Equal(t, int32(1), p.poolConfig.MinConns)
+	require.Equal(t, time.Hour, p.poolConfig.MaxConnLifetime)
+	require.Equal(t, time.Minute, p.poolConfig.MaxConnIdleTime)
+}
+
+func TestBuildData(t *testing.T) {
+	ts := time.Unix(1621289085, 0).UTC()
+	metrics := []telegraf.Metric{
+		metric.New("cpu",
+			map[string]string{"host": "a", "cpu": "0"},
+			map[string]interface{}{"idle": 42.0, "count": int64(1)},
+			ts,
+		),
+		metric.New("cpu",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"idle": 23.0, "up": true, "total": uint64(5)},
+			ts,
+		),
+	}
+
+	p := newPostgresql()
+	d := p.buildData(metrics)
+	require.Equal(t, []column{
+		{"time", "timestamp with time zone"},
+		{"cpu", "text"},
+		{"host", "text"},
+		{"count", "bigint"},
+		{"idle", "double precision"},
+		{"total", "numeric(20,0)"},
+		{"up", "boolean"},
+	}, d.columns)
+	require.Equal(t, [][]interface{}{
+		{ts, "0", "a", int64(1), 42.0, nil, nil},
+		{ts, nil, "b", nil, 23.0, uint64(5), true},
+	}, d.rows)
+	require.Nil(t, d.tagColumns)
+
+	p.TagsAsForeignKeys = true
+	p.Uint64Type = "bigint"
+	d = p.buildData(metrics)
+	idA := tagID(metrics[0])
+	idB := tagID(metrics[1])
+	require.NotEqual(t, idA, idB)
+	require.Equal(t, []column{
+		{"time", "timestamp with time zone"},
+		{"tag_id", "bigint"},
+		{"count", "bigint"},
+		{"idle", "double precision"},
+		{"total", "bigint"},
+		{"up", "boolean"},
+	}, d.columns)
+	require.Equal(t, [][]interface{}{
+		{ts, idA, int64(1), 42.0, nil, nil},
+		{ts, idB, nil, 23.0, uint64(5), true},
+	}, d.rows)
+	require.Equal(t, []column{
+		{"tag_id", "bigint"},
+		{"cpu", "text"},
+		{"host", "text"},
+	}, d.tagColumns)
+	require.Equal(t, []int64{idA, idB}, d.tagIDs)
+	require.Equal(t, map[int64][]interface{}{
+		idA: {idA, "0", "a"},
+		idB: {idB, nil, "b"},
+	}, d.tagRows)
+}
+
+func TestGenerateStatements(t *testing.T) {
+	p := newPostgresql()
+	p.Schema = "my schema"
+	p.ForeignTagConstraint = true
+	p.TagsAsForeignKeys = true
+	p.TimescaledbChunkInterval = config.Duration(24 * time.Hour)
+
+	columns := []column{{"time", "timestamp with time zone"}, {"tag_id", "bigint"}, {`va"lue`, "bigint"}}
+	require.Equal(t,
+		`CREATE TABLE IF NOT EXISTS "my schema"."cpu" ("time" timestamp with time zone, "tag_id" bigint, "va""lue" bigint)`,
+		p.generateCreateTable(p.CreateTemplate, "cpu", columns),
+	)
+	require.Equal(t,
+		`CREATE TABLE IF NOT EXISTS "my schema"."cpu" ("time" timestamp with time zone, `+
+			`"tag_id" bigint REFERENCES "my schema"."cpu_tag" ("tag_id"), "va""lue" bigint)`,
+		p.generateCreateTable(p.CreateTemplate, "cpu", p.withForeignKey(columns, &table{name: "cpu_tag"})),
+	)
+	require.Equal(t,
+		`ALTER TABLE "my schema"."cpu" ADD COLUMN IF NOT EXISTS "va""lue" bigint`,
+		p.generateAddColumn(p.AddColumnTemplate, "cpu", columns[2]),
+	)
+	require.Equal(t,
+		`SELECT create_hypertable('"my schema"."cpu"', 'time', chunk_time_interval => INTERVAL '86400000000 microseconds', if_not_exists => true)`,
+		p.generateHypertable("cpu"),
+	)
+}
+
+func TestDropIncompatible(t *testing.T) {
+	ts := time.Unix(1621289085, 0).UTC()
+	metrics := []telegraf.Metric{
+		metric.New("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"count": int64(1), "idle": int64(42), "state": "ok"},
+			ts,
+		),
+		metric.New("cpu",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"count": uint64(math.MaxUint64), "idle": 23.5, "state": true},
+			ts,
+		),
+	}
+
+	p := newPostgresql()
+	p.Log = testutil.Logger{}
+	d := p.buildData(metrics)
+	p.dropIncompatible("cpu", d, map[string]string{
+		"time":  "timestamp with time zone",
+		"host":  "text",
+		"count": "integer",
+		"idle":  "numeric",
+		"state": "character varying",
+	})
+	require.Equal(t, [][]interface{}{
+		{ts, "a", int64(1), int64(42), "ok"},
+		{ts, "b", nil, 23.5, nil},
+	}, d.rows)
+}
+
+func TestIsPermanent(t *testing.T) {
+	require.True(t, isPermanent(&pgconn.PgError{Code: "22P02"}))
+	require.True(t, isPermanent(&pgconn.PgError{Code: "23503"}))
+	require.False(t, isPermanent(&pgconn.PgError{Code: "42703"}))
+	require.False(t, isPermanent(&pgconn.PgError{Code: "57P01"}))
+	require.False(t, isPermanent(&pgconn.PgError{}))
+}
+
+func TestWriteIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	const password = "telegraf"
+	servicePort := "5432"
+	container := testutil.Container{
+		Image: "postgres",
+		Env: map[string]string{
+			"POSTGRES_PASSWORD": password,
+		},
+		ExposedPorts: []string{servicePort},
+		WaitingFor: wait.ForAll(
+			wait.ForListeningPort(nat.Port(servicePort)),
+			wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+		),
+	}
+	require.NoError(t, container.Start(), "failed to start container")
+	defer func() {
+		require.NoError(t, container.Terminate(), "terminating container failed")
+	}()
+
+	address := fmt.Sprintf("postgres://postgres:%s@%s:%s/postgres",
+		password, container.Address, container.Ports[servicePort],
+	)
+
+	p := newPostgresql()
+	p.Log = testutil.Logger{}
+	p.Connection = address
+	p.TagsAsForeignKeys = true
+	p.ForeignTagConstraint = true
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+	defer p.Close()
+
+	ts := time.Unix(1621289085, 0).UTC()
+	require.NoError(t, p.Write([]telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": 42.0}, ts),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": int64(5)}, ts),
+	}))
+
+	// New tags and fields extend the existing tables
+	require.NoError(t, p.Write([]telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": 23.0}, ts.Add(time.Second)),
+		metric.New("cpu", map[string]string{"host": "b", "cpu": "0"}, map[string]interface{}{"user": uint64(7)}, ts),
+	}))
+
+	// Values not matching the column type are replaced by NULL
+	require.NoError(t, p.Write([]telegraf.Metric{
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": "many"}, ts),
+	}))
+
+	conn, err := pgx.Connect(context.Background(), address)
+	require.NoError(t, err)
+	defer conn.Close(context.Background())
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT host, coalesce(cpu, ''), coalesce(idle, 0), coalesce("user", 0)::bigint `+
+			`FROM cpu JOIN cpu_tag USING (tag_id) ORDER BY time, host`)
+	require.NoError(t, err)
+	var actual []string
+	for rows.Next() {
+		var host, cpu string
+		var idle float64
+		var user int64
+		require.NoError(t, rows.Scan(&host, &cpu, &idle, &user))
+		actual = append(actual, fmt.Sprintf("%s,%s,%v,%d", host, cpu, idle, user))
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []string{"a,,42,0", "b,0,0,7", "a,,23,0"}, actual)
+
+	var count int
+	require.NoError(t, conn.QueryRow(context.Background(), "SELECT count(*) FROM cpu_tag").Scan(&count))
+	require.Equal(t, 2, count)
+	var values int
+	require.NoError(t, conn.QueryRow(context.Background(), "SELECT count(*), count(used) FROM mem").Scan(&count, &values))
+	require.Equal(t, 2, count)
+	require.Equal(t, 1, values)
+}
@@ -0,0 +1,265 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// table caches the known state of a database table. Measurements are written
+// concurrently, so all access is guarded by the lock of the table.
+type table struct {
+	name string
+
+	sync.Mutex
+	columns map[string]string
+	tagIDs  map[int64]bool
+}
+
+// columnTypes returns a copy of the cached column names and types
+func (t *table) columnTypes() map[string]string {
+	t.Lock()
+	defer t.Unlock()
+
+	types := make(map[string]string, len(t.columns))
+	for name, datatype := range t.columns {
+		types[name] = datatype
+	}
+	return types
+}
+
+// reset forgets the cached state so it is queried again with the next write
+func (t *table) reset() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.columns = nil
+	t.tagIDs = nil
+}
+
+func (t *table) hasTagID(id int64) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	return t.tagIDs[id]
+}
+
+func (t *table) addTagIDs(ids []int64) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.tagIDs == nil {
+		t.tagIDs = make(map[int64]bool, len(ids))
+	}
+	for _, id := range ids {
+		t.tagIDs[id] = true
+	}
+}
+
+// table returns the cache entry of the table with the given name
+func (p *Postgresql) table(name string) *table {
+	p.tablesLock.Lock()
+	defer p.tablesLock.Unlock()
+
+	t, found := p.tables[name]
+	if !found {
+		t = &table{name: name}
+		p.tables[name] = t
+	}
+	return t
+}
+
+// Quote an identifier (table or column name)
+func quoteIdent(name string) string {
+	return pgx.Identifier{name}.Sanitize()
+}
+
+// Quote a string literal
+func quoteStr(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// tableIdent returns the table name qualified by the schema
+func (p *Postgresql) tableIdent(name string) string {
+	return pgx.Identifier{p.Schema, name}.Sanitize()
+}
+
+func (p *Postgresql) generateCreateTable(template, name string, columns []column) string {
+	definitions := make([]string, 0, len(columns))
+	for _, c := range columns {
+		definitions = append(definitions, quoteIdent(c.name)+" "+c.datatype)
+	}
+
+	query := template
+	query = strings.ReplaceAll(query, "{TABLE}", p.tableIdent(name))
+	query = strings.ReplaceAll(query, "{TABLELITERAL}", quoteStr(p.tableIdent(name)))
+	query = strings.ReplaceAll(query, "{COLUMNS}", strings.Join(definitions, ", "))
+
+	return query
+}
+
+func (p *Postgresql) generateAddColumn(template, name string, c column) string {
+	query := template
+	query = strings.ReplaceAll(query, "{TABLE}", p.tableIdent(name))
+	query = strings.ReplaceAll(query, "{TABLELITERAL}", quoteStr(p.tableIdent(name)))
+	query = strings.ReplaceAll(query, "{COLUMN}", quoteIdent(c.name)+" "+c.datatype)
+
+	return query
+}
+
+func (p *Postgresql) generateHypertable(name string) string {
+	return fmt.Sprintf("SELECT create_hypertable(%s, %s, chunk_time_interval => INTERVAL '%d microseconds', if_not_exists => true)",
+		quoteStr(p.tableIdent(name)),
+		quoteStr(p.TimestampColumn),
+		time.Duration(p.TimescaledbChunkInterval).Microseconds(),
+	)
+}
+
+// tableColumns queries the names and types of the existing columns of the table
+func (p *Postgresql) tableColumns(ctx context.Context, name string) (map[string]string, error) {
+	rows, err := p.pool.Query(ctx,
+		"SELECT column_name, data_type FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2",
+		p.Schema, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]string)
+	for rows.Next() {
+		var column, datatype string
+		if err := rows.Scan(&column, &datatype); err != nil {
+			return nil, err
+		}
+		columns[column] = datatype
+	}
+	return columns, rows.Err()
+}
+
+// updateMetricTable creates the metric table or adds missing columns
+func (p *Postgresql) updateMetricTable(ctx context.Context, t, tagTable *table, columns []column) error {
+	create := []string{p.generateCreateTable(p.CreateTemplate, t.name, p.withForeignKey(columns, tagTable))}
+	if p.Timescaledb {
+		create = append(create, p.generateHypertable(t.name))
+	}
+	return p.updateTable(ctx, t, columns, create, p.AddColumnTemplate)
+}
+
+// updateTagTable creates the tag table or adds missing tag columns
+func (p *Postgresql) updateTagTable(ctx context.Context, t *table, columns []column) error {
+	create := []string{p.generateCreateTable(p.TagTableCreateTemplate, t.name, columns)}
+	return p.updateTable(ctx, t, columns, create, p.TagTableAddColumnTemplate)
+}
+
+// withForeignKey adds the reference to the tag table to the tag ID column
+// definition if the constraint is enabled
+func (p *Postgresql) withForeignKey(columns []column, tagTable *table) []column {
+	if tagTable == nil || !p.ForeignTagConstraint {
+		return columns
+	}
+
+	result := make([]column, 0, len(columns))
+	for _, c := range columns {
+		if c.name == tagIDColumn {
+			c.datatype += fmt.Sprintf(" REFERENCES %s (%s)", p.tableIdent(tagTable.name), quoteIdent(tagIDColumn))
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+func (p *Postgresql) updateTable(ctx context.Context, t *table, columns []column, create []string, addColumnTemplate string) error {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.columns == nil {
+		existing, err := p.tableColumns(ctx, t.name)
+		if err != nil {
+			return fmt.Errorf("querying columns of table %q failed: %w", t.name, err)
+		}
+
+		if len(existing) == 0 {
+			// Create the table within a transaction so a failing hypertable
+			// conversion does not leave a plain table behind
+			err := p.pool.BeginFunc(ctx, func(tx pgx.Tx) error {
+				for _, query := range create {
+					if _, err := tx.Exec(ctx, query); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("creating table %q failed: %w", t.name, err)
+			}
+			for _, c := range columns {
+				existing[c.name] = c.datatype
+			}
+		}
+		t.columns = existing
+	}
+
+	for _, c := range columns {
+		if _, found := t.columns[c.name]; found {
+			continue
+		}
+		if addColumnTemplate == "" {
+			return fmt.Errorf("column %q missing in table %q", c.name, t.name)
+		}
+		if _, err := p.pool.Exec(ctx, p.generateAddColumn(addColumnTemplate, t.name, c)); err != nil {
+			t.columns = nil
+			return fmt.Errorf("adding column %q to table %q failed: %w", c.name, t.name, err)
+		}
+		t.columns[c.name] = c.datatype
+	}
+
+	return nil
+}
+
+// compatible checks if the value can be written to a column of the given
+// type. Unknown types are left to the database to convert.
+func compatible(datatype string, value interface{}) bool {
+	if i := strings.IndexByte(datatype, '('); i >= 0 {
+		datatype = datatype[:i]
+	}
+
+	switch datatype {
+	case "smallint":
+		return inRange(value, math.MinInt16, math.MaxInt16)
+	case "integer":
+		return inRange(value, math.MinInt32, math.MaxInt32)
+	case "bigint":
+		return inRange(value, math.MinInt64, math.MaxInt64)
+	case "numeric", "double precision", "real":
+		switch value.(type) {
+		case int64, uint64, float64:
+			return true
+		}
+		return false
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "text", "character varying", "character":
+		_, ok := value.(string)
+		return ok
+	case "timestamp with time zone", "timestamp without time zone":
+		_, ok := value.(time.Time)
+		return ok
+	}
+	return true
+}
+
+func inRange(value interface{}, min, max int64) bool {
+	switch v := value.(type) {
+	case int64:
+		return v >= min && v <= max
+	case uint64:
+		return v <= uint64(max)
+	}
+	return false
+}
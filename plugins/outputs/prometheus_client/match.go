@@ -0,0 +1,202 @@
+package prometheus_client
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/pkg/labels"
+)
+
+// matchGatherer filters the gathered metrics by series selectors in the same
+// way as the federation endpoint of Prometheus. A series is kept if it
+// matches any of the selectors.
+type matchGatherer struct {
+	gatherer  prometheus.Gatherer
+	selectors [][]*labels.Matcher
+}
+
+func (g *matchGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+
+	result := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		metrics := make([]*dto.Metric, 0, len(family.Metric))
+		for _, m := range family.Metric {
+			if g.match(family.GetName(), m) {
+				metrics = append(metrics, m)
+			}
+		}
+		if len(metrics) > 0 {
+			family.Metric = metrics
+			result = append(result, family)
+		}
+	}
+	return result, err
+}
+
+func (g *matchGatherer) match(name string, m *dto.Metric) bool {
+	for _, matchers := range g.selectors {
+		if matchAll(name, m, matchers) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAll checks the series against all matchers of a selector, missing
+// labels are matched as empty value
+func matchAll(name string, m *dto.Metric, matchers []*labels.Matcher) bool {
+	for _, matcher := range matchers {
+		var value string
+		if matcher.Name == labels.MetricName {
+			value = name
+		} else {
+			for _, label := range m.Label {
+				if label.GetName() == matcher.Name {
+					value = label.GetValue()
+					break
+				}
+			}
+		}
+		if !matcher.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseSelector parses a series selector such as `name{label="value"}` into
+// its label matchers. Only the selector syntax of PromQL is supported.
+func parseSelector(selector string) ([]*labels.Matcher, error) {
+	var matchers []*labels.Matcher
+
+	s := strings.TrimSpace(selector)
+	name := identifier(s, true)
+	if name != "" {
+		m, err := labels.NewMatcher(labels.MatchEqual, labels.MetricName, name)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+		s = strings.TrimSpace(s[len(name):])
+	}
+
+	if s != "" {
+		if s[0] != '{' {
+			return nil, fmt.Errorf("unexpected %q", s)
+		}
+		s = strings.TrimSpace(s[1:])
+		for !strings.HasPrefix(s, "}") {
+			label := identifier(s, false)
+			if label == "" {
+				return nil, fmt.Errorf("expected label name at %q", s)
+			}
+			if label == labels.MetricName && name != "" {
+				return nil, errors.New("metric name must not be set twice")
+			}
+			s = strings.TrimSpace(s[len(label):])
+
+			var op string
+			var typ labels.MatchType
+			switch {
+			case strings.HasPrefix(s, "=~"):
+				op, typ = "=~", labels.MatchRegexp
+			case strings.HasPrefix(s, "!~"):
+				op, typ = "!~", labels.MatchNotRegexp
+			case strings.HasPrefix(s, "!="):
+				op, typ = "!=", labels.MatchNotEqual
+			case strings.HasPrefix(s, "="):
+				op, typ = "=", labels.MatchEqual
+			default:
+				return nil, fmt.Errorf("expected match operator at %q", s)
+			}
+			s = strings.TrimSpace(s[len(op):])
+
+			value, rest, err := unquote(s)
+			if err != nil {
+				return nil, err
+			}
+			m, err := labels.NewMatcher(typ, label, value)
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, m)
+
+			s = strings.TrimSpace(rest)
+			if strings.HasPrefix(s, ",") {
+				s = strings.TrimSpace(s[1:])
+			} else if !strings.HasPrefix(s, "}") {
+				return nil, fmt.Errorf("expected \",\" or \"}\" at %q", s)
+			}
+		}
+		if rest := strings.TrimSpace(s[1:]); rest != "" {
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+	}
+
+	// Same as Prometheus, refuse selectors matching all series
+	for _, m := range matchers {
+		if !m.Matches("") {
+			return matchers, nil
+		}
+	}
+	return nil, errors.New("selector must contain at least one non-empty matcher")
+}
+
+// identifier returns the metric or label name at the start of the string
+func identifier(s string, metric bool) string {
+	for i, c := range s {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c == ':' && metric:
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// unquote decodes the string at the start of s enclosed in double quotes,
+// single quotes or backticks and returns the remainder of s
+func unquote(s string) (value, rest string, err error) {
+	if s == "" {
+		return "", "", errors.New("expected string")
+	}
+
+	quote := s[0]
+	switch quote {
+	case '`':
+		end := strings.IndexByte(s[1:], '`')
+		if end < 0 {
+			return "", "", errors.New("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case '"', '\'':
+	default:
+		return "", "", fmt.Errorf("expected string at %q", s)
+	}
+
+	var buf strings.Builder
+	for s = s[1:]; s != ""; {
+		if s[0] == quote {
+			return buf.String(), s[1:], nil
+		}
+		r, multibyte, tail, err := strconv.UnquoteChar(s, quote)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid string: %w", err)
+		}
+		if multibyte || r < utf8.RuneSelf {
+			buf.WriteRune(r)
+		} else {
+			buf.WriteByte(byte(r))
+		}
+		s = tail
+	}
+	return "", "", errors.New("unterminated string")
+}
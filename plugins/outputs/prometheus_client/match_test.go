@@ -0,0 +1,84 @@
+package prometheus_client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		expected []string
+	}{
+		{
+			name:     "metric name",
+			selector: "cpu_time_user",
+			expected: []string{`__name__="cpu_time_user"`},
+		},
+		{
+			name:     "metric name with colon",
+			selector: "job:cpu_time:rate5m",
+			expected: []string{`__name__="job:cpu_time:rate5m"`},
+		},
+		{
+			name:     "matchers only",
+			selector: `{__name__=~"cpu_.*", cpu!="cpu0"}`,
+			expected: []string{`__name__=~"cpu_.*"`, `cpu!="cpu0"`},
+		},
+		{
+			name:     "all operators",
+			selector: ` cpu { a = "1", b != '2', c =~ "3.*", d !~ ` + "`4`" + `, } `,
+			expected: []string{`__name__="cpu"`, `a="1"`, `b!="2"`, `c=~"3.*"`, `d!~"4"`},
+		},
+		{
+			name:     "escapes",
+			selector: `cpu{a="x\"y\n", b='it\'s'}`,
+			expected: []string{`__name__="cpu"`, `a="x\"y\n"`, `b="it's"`},
+		},
+		{
+			name:     "empty matchers",
+			selector: "cpu{}",
+			expected: []string{`__name__="cpu"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matchers, err := parseSelector(tt.selector)
+			require.NoError(t, err)
+			actual := make([]string, 0, len(matchers))
+			for _, m := range matchers {
+				actual = append(actual, m.String())
+			}
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestParseSelectorInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+	}{
+		{name: "empty", selector: ""},
+		{name: "unterminated matchers", selector: "cpu{"},
+		{name: "missing operator", selector: `cpu{a"1"}`},
+		{name: "missing value", selector: `cpu{a=}`},
+		{name: "unquoted value", selector: `cpu{a=1}`},
+		{name: "unterminated string", selector: `cpu{a="1}`},
+		{name: "missing comma", selector: `cpu{a="1" b="2"}`},
+		{name: "trailing characters", selector: `cpu{a="1"} x`},
+		{name: "invalid metric name", selector: "1cpu"},
+		{name: "metric name twice", selector: `cpu{__name__="mem"}`},
+		{name: "invalid regexp", selector: `cpu{a=~"("}`},
+		{name: "only empty matchers", selector: `{a=""}`},
+		{name: "matching everything", selector: `{a=~".*"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSelector(tt.selector)
+			require.Error(t, err)
+		})
+	}
+}
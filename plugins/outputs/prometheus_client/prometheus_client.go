@@ -5,20 +5,24 @@ import (
 	"context"
 	"crypto/tls"
 	_ "embed"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/prometheus/pkg/labels"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/models"
 	tlsint "github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	v1 "github.com/influxdata/telegraf/plugins/outputs/prometheus_client/v1"
@@ -52,6 +56,9 @@ type PrometheusClient struct {
 	CollectorsExclude  []string        `toml:"collectors_exclude"`
 	StringAsLabel      bool            `toml:"string_as_label"`
 	ExportTimestamp    bool            `toml:"export_timestamp"`
+	EnableOpenMetrics  bool            `toml:"enable_openmetrics"`
+	ExemplarTags       []string        `toml:"exemplar_tags"`
+	Paths              []*PathConfig   `toml:"paths"`
 	tlsint.ServerConfig
 
 	Log telegraf.Logger `toml:"-"`
@@ -62,6 +69,39 @@ type PrometheusClient struct {
 	wg        sync.WaitGroup
 }
 
+// PathConfig defines an additional path exposing the metrics selected by the
+// name and tag filters
+type PathConfig struct {
+	Path     string              `toml:"path"`
+	NamePass []string            `toml:"namepass"`
+	NameDrop []string            `toml:"namedrop"`
+	TagPass  map[string][]string `toml:"tagpass"`
+	TagDrop  map[string][]string `toml:"tagdrop"`
+
+	filter    models.Filter
+	collector Collector
+}
+
+// tagFilters converts the tag filter map into a sorted list, an empty map
+// must result in a nil list as the filter would drop all metrics otherwise
+func tagFilters(filters map[string][]string) []models.TagFilter {
+	if len(filters) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]models.TagFilter, 0, len(names))
+	for _, name := range names {
+		result = append(result, models.TagFilter{Name: name, Filter: filters[name]})
+	}
+	return result
+}
+
 func (*PrometheusClient) SampleConfig() string {
 	return sampleConfig
 }
@@ -93,22 +133,11 @@ func (p *PrometheusClient) Init() error {
 		}
 	}
 
-	switch p.MetricVersion {
-	default:
-		fallthrough
-	case 1:
-		p.collector = v1.NewCollector(time.Duration(p.ExpirationInterval), p.StringAsLabel, p.Log)
-		err := registry.Register(p.collector)
-		if err != nil {
-			return err
-		}
-	case 2:
-		p.collector = v2.NewCollector(time.Duration(p.ExpirationInterval), p.StringAsLabel, p.ExportTimestamp)
-		err := registry.Register(p.collector)
-		if err != nil {
-			return err
-		}
+	collector, err := p.newCollector(registry)
+	if err != nil {
+		return err
 	}
+	p.collector = collector
 
 	ipRange := make([]*net.IPNet, 0, len(p.IPRange))
 	for _, cidr := range p.IPRange {
@@ -122,7 +151,6 @@ func (p *PrometheusClient) Init() error {
 
 	authHandler := internal.AuthHandler(p.BasicUsername, p.BasicPassword, "prometheus", onAuthError)
 	rangeHandler := internal.IPRangeHandler(ipRange, onError)
-	promHandler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
 	landingPageHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		_, err := w.Write([]byte("Telegraf Output Plugin: Prometheus Client "))
 		if err != nil {
@@ -134,7 +162,35 @@ func (p *PrometheusClient) Init() error {
 	if p.Path == "" {
 		p.Path = "/metrics"
 	}
-	mux.Handle(p.Path, authHandler(rangeHandler(promHandler)))
+	mux.Handle(p.Path, authHandler(rangeHandler(p.handler(registry))))
+
+	paths := map[string]bool{p.Path: true}
+	for _, path := range p.Paths {
+		if path.Path == "" {
+			return errors.New("path must not be empty")
+		}
+		if paths[path.Path] {
+			return fmt.Errorf("duplicate path %q", path.Path)
+		}
+		paths[path.Path] = true
+
+		path.filter = models.Filter{
+			NamePass: path.NamePass,
+			NameDrop: path.NameDrop,
+			TagPass:  tagFilters(path.TagPass),
+			TagDrop:  tagFilters(path.TagDrop),
+		}
+		if err := path.filter.Compile(); err != nil {
+			return fmt.Errorf("invalid filter for path %q: %w", path.Path, err)
+		}
+
+		pathRegistry := prometheus.NewRegistry()
+		path.collector, err = p.newCollector(pathRegistry)
+		if err != nil {
+			return err
+		}
+		mux.Handle(path.Path, authHandler(rangeHandler(p.handler(pathRegistry))))
+	}
 	mux.Handle("/", authHandler(rangeHandler(landingPageHandler)))
 
 	tlsConfig, err := p.TLSConfig()
@@ -151,6 +207,53 @@ func (p *PrometheusClient) Init() error {
 	return nil
 }
 
+// newCollector creates a collector for the configured metric version and
+// registers it with the registry
+func (p *PrometheusClient) newCollector(registry *prometheus.Registry) (Collector, error) {
+	var collector Collector
+	switch p.MetricVersion {
+	default:
+		fallthrough
+	case 1:
+		collector = v1.NewCollector(time.Duration(p.ExpirationInterval), p.StringAsLabel, p.Log)
+	case 2:
+		collector = v2.NewCollector(time.Duration(p.ExpirationInterval), p.StringAsLabel, p.ExportTimestamp, p.ExemplarTags)
+	}
+	if err := registry.Register(collector); err != nil {
+		return nil, err
+	}
+	return collector, nil
+}
+
+// handler exposes the metrics of the gatherer, the series can be filtered
+// by passing one or more selectors in the "match[]" query parameter
+func (p *PrometheusClient) handler(gatherer prometheus.Gatherer) http.Handler {
+	opts := promhttp.HandlerOpts{
+		ErrorHandling:     promhttp.ContinueOnError,
+		EnableOpenMetrics: p.EnableOpenMetrics,
+	}
+	promHandler := promhttp.HandlerFor(gatherer, opts)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selectors := r.URL.Query()["match[]"]
+		if len(selectors) == 0 {
+			promHandler.ServeHTTP(w, r)
+			return
+		}
+
+		matchers := make([][]*labels.Matcher, 0, len(selectors))
+		for _, selector := range selectors {
+			m, err := parseSelector(selector)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid selector %q: %v", selector, err), http.StatusBadRequest)
+				return
+			}
+			matchers = append(matchers, m)
+		}
+		promhttp.HandlerFor(&matchGatherer{gatherer: gatherer, selectors: matchers}, opts).ServeHTTP(w, r)
+	})
+}
+
 func (p *PrometheusClient) listen() (net.Listener, error) {
 	if p.server.TLSConfig != nil {
 		return tls.Listen("tcp", p.Listen, p.server.TLSConfig)
@@ -217,7 +320,22 @@ func (p *PrometheusClient) Close() error {
 }
 
 func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
-	return p.collector.Add(metrics)
+	if err := p.collector.Add(metrics); err != nil {
+		return err
+	}
+
+	for _, path := range p.Paths {
+		selected := make([]telegraf.Metric, 0, len(metrics))
+		for _, m := range metrics {
+			if path.filter.Select(m) {
+				selected = append(selected, m)
+			}
+		}
+		if err := path.collector.Add(selected); err != nil {
+			return fmt.Errorf("adding metrics for path %q failed: %w", path.Path, err)
+		}
+	}
+	return nil
 }
 
 func init() {
@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -464,3 +465,231 @@ rpc_duration_seconds_count 2693
 		})
 	}
 }
+
+func TestPaths(t *testing.T) {
+	output := &PrometheusClient{
+		Listen:            ":0",
+		MetricVersion:     2,
+		CollectorsExclude: []string{"gocollector", "process"},
+		Path:              "/metrics",
+		Paths: []*PathConfig{
+			{Path: "/metrics/cpu", NamePass: []string{"cpu"}},
+			{Path: "/metrics/prod", TagPass: map[string][]string{"env": {"prod"}}},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, output.Init())
+	require.NoError(t, output.Connect())
+	defer func() {
+		require.NoError(t, output.Close())
+	}()
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"env": "dev"},
+			map[string]interface{}{"time_idle": 42.0},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"mem",
+			map[string]string{"env": "prod"},
+			map[string]interface{}{"used": 23.0},
+			time.Unix(0, 0),
+		),
+	}
+	require.NoError(t, output.Write(metrics))
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{
+			path: "/metrics",
+			expected: `
+# HELP cpu_time_idle Telegraf collected metric
+# TYPE cpu_time_idle untyped
+cpu_time_idle{env="dev"} 42
+# HELP mem_used Telegraf collected metric
+# TYPE mem_used untyped
+mem_used{env="prod"} 23
+`,
+		},
+		{
+			path: "/metrics/cpu",
+			expected: `
+# HELP cpu_time_idle Telegraf collected metric
+# TYPE cpu_time_idle untyped
+cpu_time_idle{env="dev"} 42
+`,
+		},
+		{
+			path: "/metrics/prod",
+			expected: `
+# HELP mem_used Telegraf collected metric
+# TYPE mem_used untyped
+mem_used{env="prod"} 23
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			u, err := url.Parse(output.URL())
+			require.NoError(t, err)
+			u.Path = tt.path
+
+			resp, err := http.Get(u.String())
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, strings.TrimSpace(tt.expected), strings.TrimSpace(string(body)))
+		})
+	}
+}
+
+func TestDuplicatePath(t *testing.T) {
+	output := &PrometheusClient{
+		Listen:        ":0",
+		MetricVersion: 2,
+		Path:          "/metrics",
+		Paths:         []*PathConfig{{Path: "/metrics"}},
+		Log:           testutil.Logger{},
+	}
+	require.EqualError(t, output.Init(), `duplicate path "/metrics"`)
+}
+
+func TestMatchSelector(t *testing.T) {
+	output := &PrometheusClient{
+		Listen:            ":0",
+		MetricVersion:     2,
+		CollectorsExclude: []string{"gocollector", "process"},
+		Path:              "/metrics",
+		Log:               testutil.Logger{},
+	}
+	require.NoError(t, output.Init())
+	require.NoError(t, output.Connect())
+	defer func() {
+		require.NoError(t, output.Close())
+	}()
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"time_idle": 42.0, "time_user": 1.0},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"cpu": "cpu1"},
+			map[string]interface{}{"time_idle": 43.0, "time_user": 2.0},
+			time.Unix(0, 0),
+		),
+	}
+	require.NoError(t, output.Write(metrics))
+
+	tests := []struct {
+		name     string
+		match    []string
+		status   int
+		expected string
+	}{
+		{
+			name:   "metric name",
+			match:  []string{"cpu_time_user"},
+			status: http.StatusOK,
+			expected: `
+# HELP cpu_time_user Telegraf collected metric
+# TYPE cpu_time_user untyped
+cpu_time_user{cpu="cpu0"} 1
+cpu_time_user{cpu="cpu1"} 2
+`,
+		},
+		{
+			name:   "multiple selectors",
+			match:  []string{`cpu_time_idle{cpu="cpu1"}`, `{__name__=~"cpu_time_u.*",cpu="cpu0"}`},
+			status: http.StatusOK,
+			expected: `
+# HELP cpu_time_idle Telegraf collected metric
+# TYPE cpu_time_idle untyped
+cpu_time_idle{cpu="cpu1"} 43
+# HELP cpu_time_user Telegraf collected metric
+# TYPE cpu_time_user untyped
+cpu_time_user{cpu="cpu0"} 1
+`,
+		},
+		{
+			name:   "invalid selector",
+			match:  []string{"cpu{"},
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(output.URL())
+			require.NoError(t, err)
+			u.RawQuery = url.Values{"match[]": tt.match}.Encode()
+
+			resp, err := http.Get(u.String())
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, tt.status, resp.StatusCode)
+			if tt.status != http.StatusOK {
+				return
+			}
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, strings.TrimSpace(tt.expected), strings.TrimSpace(string(body)))
+		})
+	}
+}
+
+func TestOpenMetricsExemplar(t *testing.T) {
+	output := &PrometheusClient{
+		Listen:            ":0",
+		MetricVersion:     2,
+		CollectorsExclude: []string{"gocollector", "process"},
+		Path:              "/metrics",
+		EnableOpenMetrics: true,
+		ExemplarTags:      []string{"trace_id"},
+		Log:               testutil.Logger{},
+	}
+	require.NoError(t, output.Init())
+	require.NoError(t, output.Connect())
+	defer func() {
+		require.NoError(t, output.Close())
+	}()
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"http",
+			map[string]string{"host": "example.org", "trace_id": "abc"},
+			map[string]interface{}{"requests_total": 10.0},
+			time.Unix(10, 0),
+			telegraf.Counter,
+		),
+	}
+	require.NoError(t, output.Write(metrics))
+
+	req, err := http.NewRequest("GET", output.URL(), nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/openmetrics-text")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Type"), "application/openmetrics-text")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	expected := `
+# HELP http_requests Telegraf collected metric
+# TYPE http_requests counter
+http_requests_total{host="example.org"} 10.0 # {trace_id="abc"} 10.0 10.0
+# EOF
+`
+	require.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(string(body)))
+}
@@ -43,8 +43,8 @@ type Collector struct {
 	coll           *serializer.Collection
 }
 
-func NewCollector(expire time.Duration, stringsAsLabel bool, exportTimestamp bool) *Collector {
-	config := serializer.FormatConfig{}
+func NewCollector(expire time.Duration, stringsAsLabel bool, exportTimestamp bool, exemplarTags []string) *Collector {
+	config := serializer.FormatConfig{ExemplarTags: exemplarTags}
 	if stringsAsLabel {
 		config.StringHandling = serializer.StringAsLabel
 	}
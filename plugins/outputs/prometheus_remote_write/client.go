@@ -0,0 +1,137 @@
+package prometheus_remote_write
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// Maximum length of the response body included in error messages
+const maxErrMsgLen = 256
+
+// permanentError is returned for requests rejected by the receiver that must
+// not be retried
+type permanentError struct {
+	statusCode int
+	message    string
+}
+
+func (e *permanentError) Error() string {
+	return fmt.Sprintf("received status %d: %s", e.statusCode, e.message)
+}
+
+// send posts the request and retries recoverable errors with exponential
+// backoff, honoring the Retry-After header of the receiver
+func (p *PrometheusRemoteWrite) send(req *prompb.WriteRequest) error {
+	data, err := req.Marshal()
+	if err != nil {
+		return fmt.Errorf("marshalling request failed: %w", err)
+	}
+	body := snappy.Encode(nil, data)
+
+	backoff := time.Duration(p.MinBackoff)
+	for attempt := 0; ; attempt++ {
+		delay, err := p.post(body)
+		if err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return err
+		}
+		if attempt >= p.MaxRetries {
+			return err
+		}
+
+		wait := backoff
+		if delay > wait {
+			if delay > time.Duration(p.MaxRetryAfter) {
+				return fmt.Errorf("%w, retry after %s exceeds the limit", err, delay)
+			}
+			wait = delay
+		}
+		p.Log.Debugf("Retrying request in %s: %v", wait, err)
+		time.Sleep(wait)
+
+		backoff *= 2
+		if backoff > time.Duration(p.MaxBackoff) {
+			backoff = time.Duration(p.MaxBackoff)
+		}
+	}
+}
+
+// post sends the encoded request once and returns the delay requested by the
+// receiver for retrying, if any
+func (p *PrometheusRemoteWrite) post(body []byte) (time.Duration, error) {
+	req, err := http.NewRequest(http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", internal.ProductToken())
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for k, v := range p.Headers {
+		if k == "Host" {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	if p.Username != "" || p.Password != "" {
+		req.SetBasicAuth(p.Username, p.Password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return 0, nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrMsgLen))
+	message := string(bytes.TrimSpace(msg))
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+
+	// Only server errors and rate limiting are recoverable, the receiver
+	// rejects the request in all other cases
+	if resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests {
+		return retryAfter(resp.Header.Get("Retry-After")), fmt.Errorf("received status %d: %s", resp.StatusCode, message)
+	}
+	return 0, &permanentError{statusCode: resp.StatusCode, message: message}
+}
+
+// retryAfter parses the Retry-After header given in seconds or as HTTP date
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,420 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package prometheus_remote_write
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	httpconfig "github.com/influxdata/telegraf/plugins/common/http"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheusremotewrite"
+)
+
+// DO NOT REMOVE THE NEXT TWO LINES! This is required to embed the sampleConfig data.
+//go:embed sample.conf
+var sampleConfig string
+
+// Time after which the last timestamp of a series is forgotten, older samples
+// are rejected by Prometheus anyway
+const seriesExpiry = time.Hour
+
+type PrometheusRemoteWrite struct {
+	URL               string            `toml:"url"`
+	Username          string            `toml:"username"`
+	Password          string            `toml:"password"`
+	Headers           map[string]string `toml:"headers"`
+	ExternalLabels    map[string]string `toml:"external_labels"`
+	Shards            int               `toml:"shards"`
+	MaxSamplesPerSend int               `toml:"max_samples_per_send"`
+	MaxRetries        int               `toml:"max_retries"`
+	MinBackoff        config.Duration   `toml:"min_backoff"`
+	MaxBackoff        config.Duration   `toml:"max_backoff"`
+	MaxRetryAfter     config.Duration   `toml:"max_retry_after"`
+	DropOutOfOrder    bool              `toml:"drop_out_of_order"`
+	SendMetadata      bool              `toml:"send_metadata"`
+	MetadataInterval  config.Duration   `toml:"metadata_interval"`
+	StringAsLabel     bool              `toml:"string_as_label"`
+	Log               telegraf.Logger   `toml:"-"`
+	httpconfig.HTTPClientConfig
+
+	client         *http.Client
+	serializer     *prometheusremotewrite.Serializer
+	externalLabels []prompb.Label
+
+	// Last timestamp sent for each series, used to drop out-of-order samples
+	lastTimestamps map[prometheusremotewrite.MetricKey]int64
+	lastExpiry     time.Time
+
+	// Metric families seen and the time the metadata was last sent
+	metadata     map[string]prompb.MetricMetadata_MetricType
+	lastMetadata time.Time
+}
+
+// series is a time series with the key identifying its labels
+type series struct {
+	key prometheusremotewrite.MetricKey
+	prompb.TimeSeries
+}
+
+func (*PrometheusRemoteWrite) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *PrometheusRemoteWrite) Init() error {
+	if p.URL == "" {
+		return errors.New("url must not be empty")
+	}
+	if _, err := url.Parse(p.URL); err != nil {
+		return fmt.Errorf("parsing url failed: %w", err)
+	}
+	if p.Shards < 1 {
+		return errors.New("shards must be at least 1")
+	}
+	if p.MaxSamplesPerSend < 1 {
+		return errors.New("max samples per send must be at least 1")
+	}
+	if p.MaxRetries < 0 {
+		return errors.New("max retries must not be negative")
+	}
+	if p.MinBackoff <= 0 || p.MaxBackoff < p.MinBackoff {
+		return errors.New("backoff must be positive with max backoff not below min backoff")
+	}
+
+	p.externalLabels = make([]prompb.Label, 0, len(p.ExternalLabels))
+	for name, value := range p.ExternalLabels {
+		if sanitized, ok := prometheus.SanitizeLabelName(name); !ok || sanitized != name {
+			return fmt.Errorf("invalid external label name %q", name)
+		}
+		p.externalLabels = append(p.externalLabels, prompb.Label{Name: name, Value: value})
+	}
+
+	p.serializer = &prometheusremotewrite.Serializer{StringAsLabel: p.StringAsLabel}
+	if err := p.serializer.Init(); err != nil {
+		return err
+	}
+
+	p.lastTimestamps = make(map[prometheusremotewrite.MetricKey]int64)
+	p.metadata = make(map[string]prompb.MetricMetadata_MetricType)
+
+	return nil
+}
+
+func (p *PrometheusRemoteWrite) Connect() error {
+	client, err := p.HTTPClientConfig.CreateClient(context.Background(), p.Log)
+	if err != nil {
+		return err
+	}
+	p.client = client
+
+	return nil
+}
+
+func (p *PrometheusRemoteWrite) Close() error {
+	if p.client != nil {
+		p.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (p *PrometheusRemoteWrite) Write(metrics []telegraf.Metric) error {
+	all, err := p.timeSeries(metrics)
+	if err != nil {
+		return err
+	}
+	if p.DropOutOfOrder {
+		all = p.dropOutOfOrder(all)
+	}
+
+	// Distribute the series to the shards by their labels, so all samples of
+	// a series are sent in order by the same shard
+	shards := make([][]series, p.Shards)
+	for _, s := range all {
+		i := int(uint64(s.key) % uint64(p.Shards))
+		shards[i] = append(shards[i], s)
+	}
+
+	sent := make([][]series, p.Shards)
+	errs := make([]error, p.Shards)
+	var wg sync.WaitGroup
+	for i := range shards {
+		if len(shards[i]) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sent[i], errs[i] = p.sendShard(shards[i])
+		}(i)
+	}
+	wg.Wait()
+
+	// Remember the sent samples even if other shards failed, so the samples
+	// are not sent twice when retrying the write. The timestamps are only
+	// expired when dropping out-of-order samples, so only record them then.
+	if p.DropOutOfOrder {
+		for _, shard := range sent {
+			for _, s := range shard {
+				p.lastTimestamps[s.key] = s.Samples[len(s.Samples)-1].Timestamp
+			}
+		}
+	}
+
+	if p.SendMetadata && time.Since(p.lastMetadata) >= time.Duration(p.MetadataInterval) {
+		if err := p.sendMetadata(); err != nil {
+			p.Log.Warnf("Sending metadata failed: %v", err)
+		} else {
+			p.lastMetadata = time.Now()
+		}
+	}
+
+	var messages []string
+	for i, err := range errs {
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("shard %d: %v", i, err))
+		}
+	}
+	if len(messages) > 0 {
+		return errors.New(strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// timeSeries converts the metrics to time series containing all samples of
+// the metrics in chronological order
+func (p *PrometheusRemoteWrite) timeSeries(metrics []telegraf.Metric) ([]series, error) {
+	// The serializer only keeps the latest sample of each series, so convert
+	// the metrics of every timestamp separately. Timestamps are in
+	// milliseconds in remote write.
+	var timestamps []int64
+	groups := make(map[int64][]telegraf.Metric)
+	for _, m := range metrics {
+		ts := m.Time().UnixNano() / int64(time.Millisecond)
+		if _, found := groups[ts]; !found {
+			timestamps = append(timestamps, ts)
+		}
+		groups[ts] = append(groups[ts], m)
+		p.addMetadata(m)
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	var result []series
+	index := make(map[prometheusremotewrite.MetricKey]int)
+	for _, ts := range timestamps {
+		converted, err := p.serializer.TimeSeries(groups[ts])
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range converted {
+			labels := p.withExternalLabels(s.Labels)
+			key := prometheusremotewrite.MakeMetricKey(labels)
+			if i, found := index[key]; found {
+				result[i].Samples = append(result[i].Samples, s.Samples...)
+				continue
+			}
+			index[key] = len(result)
+			result = append(result, series{key: key, TimeSeries: prompb.TimeSeries{Labels: labels, Samples: s.Samples}})
+		}
+	}
+	return result, nil
+}
+
+// withExternalLabels adds the external labels not already present and sorts
+// the labels by name as required by the protocol
+func (p *PrometheusRemoteWrite) withExternalLabels(labels []prompb.Label) []prompb.Label {
+	result := make([]prompb.Label, 0, len(labels)+len(p.externalLabels))
+	result = append(result, labels...)
+	for _, external := range p.externalLabels {
+		found := false
+		for _, l := range labels {
+			if l.Name == external.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, external)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// dropOutOfOrder removes the samples not newer than the last sample sent for
+// the series, as Prometheus rejects them
+func (p *PrometheusRemoteWrite) dropOutOfOrder(all []series) []series {
+	if time.Since(p.lastExpiry) >= seriesExpiry {
+		limit := time.Now().Add(-seriesExpiry).UnixNano() / int64(time.Millisecond)
+		for key, ts := range p.lastTimestamps {
+			if ts < limit {
+				delete(p.lastTimestamps, key)
+			}
+		}
+		p.lastExpiry = time.Now()
+	}
+
+	var dropped int
+	result := all[:0]
+	for _, s := range all {
+		last, found := p.lastTimestamps[s.key]
+		if !found {
+			result = append(result, s)
+			continue
+		}
+		samples := s.Samples[:0]
+		for _, sample := range s.Samples {
+			if sample.Timestamp <= last {
+				dropped++
+				continue
+			}
+			samples = append(samples, sample)
+		}
+		if len(samples) > 0 {
+			s.Samples = samples
+			result = append(result, s)
+		}
+	}
+	if dropped > 0 {
+		p.Log.Debugf("Dropped %d out-of-order samples", dropped)
+	}
+	return result
+}
+
+// sendShard sends the series of a shard in requests of limited size and
+// returns the series sent successfully
+func (p *PrometheusRemoteWrite) sendShard(shard []series) ([]series, error) {
+	var sent []series
+	var batch []series
+	var samples int
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		req := &prompb.WriteRequest{Timeseries: make([]prompb.TimeSeries, 0, len(batch))}
+		for _, s := range batch {
+			req.Timeseries = append(req.Timeseries, s.TimeSeries)
+		}
+		err := p.send(req)
+		var permanent *permanentError
+		switch {
+		case errors.As(err, &permanent):
+			// Retrying the samples won't succeed, so drop them
+			p.Log.Errorf("Dropping %d samples: %v", samples, err)
+		case err != nil:
+			return err
+		}
+		sent = append(sent, batch...)
+		batch = nil
+		samples = 0
+		return nil
+	}
+
+	for _, s := range shard {
+		// Split series with more samples than fit into a single request
+		for len(s.Samples) > 0 {
+			n := p.MaxSamplesPerSend - samples
+			if n > len(s.Samples) {
+				n = len(s.Samples)
+			}
+			part := s
+			part.Samples = s.Samples[:n]
+			batch = append(batch, part)
+			samples += n
+			s.Samples = s.Samples[n:]
+
+			if samples >= p.MaxSamplesPerSend {
+				if err := flush(); err != nil {
+					return sent, err
+				}
+			}
+		}
+	}
+	return sent, flush()
+}
+
+func (p *PrometheusRemoteWrite) addMetadata(m telegraf.Metric) {
+	if !p.SendMetadata {
+		return
+	}
+
+	var metricType prompb.MetricMetadata_MetricType
+	switch m.Type() {
+	case telegraf.Counter:
+		metricType = prompb.MetricMetadata_COUNTER
+	case telegraf.Gauge:
+		metricType = prompb.MetricMetadata_GAUGE
+	case telegraf.Histogram:
+		metricType = prompb.MetricMetadata_HISTOGRAM
+	case telegraf.Summary:
+		metricType = prompb.MetricMetadata_SUMMARY
+	default:
+		metricType = prompb.MetricMetadata_UNKNOWN
+	}
+
+	for _, field := range m.FieldList() {
+		if _, ok := field.Value.(string); ok {
+			continue
+		}
+		name, ok := prometheus.SanitizeMetricName(prometheus.MetricName(m.Name(), field.Key, m.Type()))
+		if !ok {
+			continue
+		}
+		if _, found := p.metadata[name]; !found {
+			// Send new metric families with the next write
+			p.lastMetadata = time.Time{}
+		}
+		p.metadata[name] = metricType
+	}
+}
+
+func (p *PrometheusRemoteWrite) sendMetadata() error {
+	if len(p.metadata) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(p.metadata))
+	for name := range p.metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	req := &prompb.WriteRequest{Metadata: make([]prompb.MetricMetadata, 0, len(names))}
+	for _, name := range names {
+		req.Metadata = append(req.Metadata, prompb.MetricMetadata{
+			Type:             p.metadata[name],
+			MetricFamilyName: name,
+		})
+	}
+	return p.send(req)
+}
+
+func init() {
+	outputs.Add("prometheus_remote_write", func() telegraf.Output { return newPrometheusRemoteWrite() })
+}
+
+func newPrometheusRemoteWrite() *PrometheusRemoteWrite {
+	return &PrometheusRemoteWrite{
+		Shards:            4,
+		MaxSamplesPerSend: 500,
+		MaxRetries:        3,
+		MinBackoff:        config.Duration(30 * time.Millisecond),
+		MaxBackoff:        config.Duration(5 * time.Second),
+		MaxRetryAfter:     config.Duration(time.Minute),
+		DropOutOfOrder:    true,
+		SendMetadata:      true,
+		MetadataInterval:  config.Duration(time.Minute),
+	}
+}
@@ -0,0 +1,378 @@
+package prometheus_remote_write
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// receiver records the remote write requests and responds with the queued
+// status codes, or 204 if none are left
+type receiver struct {
+	sync.Mutex
+	requests []*prompb.WriteRequest
+	headers  []http.Header
+	statuses []int
+	delay    string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	compressed, err := io.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var wr prompb.WriteRequest
+	if err := wr.Unmarshal(data); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.requests = append(r.requests, &wr)
+	r.headers = append(r.headers, req.Header)
+
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status = r.statuses[0]
+		r.statuses = r.statuses[1:]
+	}
+	if r.delay != "" {
+		w.Header().Set("Retry-After", r.delay)
+	}
+	w.WriteHeader(status)
+}
+
+// samples returns the received samples as text sorted by series and time
+func (r *receiver) samples() []string {
+	r.Lock()
+	defer r.Unlock()
+
+	var result []string
+	for _, req := range r.requests {
+		for _, ts := range req.Timeseries {
+			var labels string
+			for _, l := range ts.Labels {
+				labels += fmt.Sprintf("%s=%q,", l.Name, l.Value)
+			}
+			for _, s := range ts.Samples {
+				result = append(result, fmt.Sprintf("{%s} %v %d", labels, s.Value, s.Timestamp))
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func newPlugin(t *testing.T, url string) *PrometheusRemoteWrite {
+	plugin := newPrometheusRemoteWrite()
+	plugin.URL = url
+	plugin.MinBackoff = config.Duration(time.Millisecond)
+	plugin.MaxBackoff = config.Duration(10 * time.Millisecond)
+	plugin.SendMetadata = false
+	plugin.Log = testutil.Logger{}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	return plugin
+}
+
+func TestWrite(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	plugin := newPrometheusRemoteWrite()
+	plugin.URL = server.URL
+	plugin.Username = "user"
+	plugin.Password = "secret"
+	plugin.Shards = 1
+	plugin.SendMetadata = false
+	plugin.ExternalLabels = map[string]string{"cluster": "eu", "host": "default"}
+	plugin.Log = testutil.Logger{}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": 2.0}, time.Unix(20, 0)),
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": 1.0}, time.Unix(10, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"idle": 3.0}, time.Unix(10, 0)),
+	}
+	require.NoError(t, plugin.Write(metrics))
+
+	require.Len(t, r.requests, 1)
+	header := r.headers[0]
+	require.Equal(t, "snappy", header.Get("Content-Encoding"))
+	require.Equal(t, "application/x-protobuf", header.Get("Content-Type"))
+	require.Equal(t, "0.1.0", header.Get("X-Prometheus-Remote-Write-Version"))
+	require.Equal(t, "Basic dXNlcjpzZWNyZXQ=", header.Get("Authorization"))
+
+	// All samples of a series are sent in order with labels sorted by name
+	expected := []prompb.TimeSeries{
+		{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "cpu_idle"},
+				{Name: "cluster", Value: "eu"},
+				{Name: "host", Value: "a"},
+			},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 10000}, {Value: 2, Timestamp: 20000}},
+		},
+		{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "cpu_idle"},
+				{Name: "cluster", Value: "eu"},
+				{Name: "host", Value: "default"},
+			},
+			Samples: []prompb.Sample{{Value: 3, Timestamp: 10000}},
+		},
+	}
+	actual := r.requests[0].Timeseries
+	sort.Slice(actual, func(i, j int) bool { return actual[i].Labels[2].Value < actual[j].Labels[2].Value })
+	require.Equal(t, expected, actual)
+}
+
+func TestWriteSharding(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	plugin := newPlugin(t, server.URL)
+	plugin.Shards = 3
+	plugin.MaxSamplesPerSend = 5
+	defer plugin.Close()
+
+	var metrics []telegraf.Metric
+	var expected []string
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 3; j++ {
+			host := fmt.Sprintf("host%d", i)
+			metrics = append(metrics, metric.New("cpu",
+				map[string]string{"host": host},
+				map[string]interface{}{"idle": float64(j)},
+				time.Unix(int64(j), 0),
+			))
+			expected = append(expected, fmt.Sprintf(`{__name__="cpu_idle",host=%q,} %d %d`, host, j, j*1000))
+		}
+	}
+	sort.Strings(expected)
+	require.NoError(t, plugin.Write(metrics))
+
+	require.Equal(t, expected, r.samples())
+	require.GreaterOrEqual(t, len(r.requests), 6)
+	for _, req := range r.requests {
+		var count int
+		for _, ts := range req.Timeseries {
+			count += len(ts.Samples)
+		}
+		require.LessOrEqual(t, count, 5)
+	}
+}
+
+func TestWriteRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		delay    string
+		requests int
+		err      string
+	}{
+		{
+			name:     "success",
+			requests: 1,
+		},
+		{
+			name:     "server error",
+			statuses: []int{http.StatusInternalServerError, http.StatusBadGateway},
+			requests: 3,
+		},
+		{
+			name:     "too many requests",
+			statuses: []int{http.StatusTooManyRequests},
+			delay:    "0",
+			requests: 2,
+		},
+		{
+			name:     "bad request is dropped",
+			statuses: []int{http.StatusBadRequest},
+			requests: 1,
+		},
+		{
+			name:     "retries exhausted",
+			statuses: []int{503, 503, 503, 503},
+			requests: 4,
+			err:      "received status 503",
+		},
+		{
+			name:     "retry after exceeding limit",
+			statuses: []int{http.StatusServiceUnavailable},
+			delay:    "3600",
+			requests: 1,
+			err:      "retry after 1h0m0s exceeds the limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &receiver{statuses: tt.statuses, delay: tt.delay}
+			server := httptest.NewServer(r)
+			defer server.Close()
+
+			plugin := newPlugin(t, server.URL)
+			defer plugin.Close()
+
+			m := metric.New("cpu", map[string]string{}, map[string]interface{}{"idle": 42.0}, time.Unix(0, 0))
+			err := plugin.Write([]telegraf.Metric{m})
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Len(t, r.requests, tt.requests)
+		})
+	}
+}
+
+func TestWriteOutOfOrder(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	plugin := newPlugin(t, server.URL)
+	defer plugin.Close()
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"idle": 1.0}, time.Unix(20, 0))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+
+	// Samples not newer than the last sent sample are dropped, while new
+	// series are sent
+	require.NoError(t, plugin.Write([]telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"idle": 2.0}, time.Unix(10, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"idle": 1.0}, time.Unix(20, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"idle": 3.0}, time.Unix(30, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"used": 4.0}, time.Unix(10, 0)),
+	}))
+
+	require.Equal(t, []string{
+		`{__name__="cpu_idle",} 1 20000`,
+		`{__name__="cpu_idle",} 3 30000`,
+		`{__name__="mem_used",} 4 10000`,
+	}, r.samples())
+
+	require.Len(t, plugin.lastTimestamps, 2)
+
+	// Without dropping, the last timestamps of the series are not tracked
+	plugin.DropOutOfOrder = false
+	require.NoError(t, plugin.Write([]telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"idle": 2.0}, time.Unix(10, 0)),
+		metric.New("swap", map[string]string{}, map[string]interface{}{"used": 5.0}, time.Unix(10, 0)),
+	}))
+	require.Contains(t, r.samples(), `{__name__="cpu_idle",} 2 10000`)
+	require.Contains(t, r.samples(), `{__name__="swap_used",} 5 10000`)
+	require.Len(t, plugin.lastTimestamps, 2)
+}
+
+func TestWriteMetadata(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	plugin := newPlugin(t, server.URL)
+	plugin.Shards = 1
+	plugin.SendMetadata = true
+	plugin.MetadataInterval = config.Duration(time.Hour)
+	defer plugin.Close()
+
+	counter := metric.New("requests", map[string]string{}, map[string]interface{}{"total": 1.0}, time.Unix(0, 0), telegraf.Counter)
+	gauge := metric.New("cpu", map[string]string{}, map[string]interface{}{"idle": 1.0, "state": "ok"}, time.Unix(0, 0), telegraf.Gauge)
+	require.NoError(t, plugin.Write([]telegraf.Metric{counter, gauge}))
+
+	require.Len(t, r.requests, 2)
+	require.Equal(t, []prompb.MetricMetadata{
+		{Type: prompb.MetricMetadata_GAUGE, MetricFamilyName: "cpu_idle"},
+		{Type: prompb.MetricMetadata_COUNTER, MetricFamilyName: "requests_total"},
+	}, r.requests[1].Metadata)
+
+	// Metadata is only sent again after the interval or for new families
+	gauge = metric.New("cpu", map[string]string{}, map[string]interface{}{"idle": 2.0}, time.Unix(10, 0), telegraf.Gauge)
+	require.NoError(t, plugin.Write([]telegraf.Metric{gauge}))
+	require.Len(t, r.requests, 3)
+
+	untyped := metric.New("mem", map[string]string{}, map[string]interface{}{"used": 1.0}, time.Unix(0, 0))
+	require.NoError(t, plugin.Write([]telegraf.Metric{untyped}))
+	require.Len(t, r.requests, 5)
+	require.Len(t, r.requests[4].Metadata, 3)
+}
+
+func TestRetryAfter(t *testing.T) {
+	require.Equal(t, time.Duration(0), retryAfter(""))
+	require.Equal(t, time.Duration(0), retryAfter("invalid"))
+	require.Equal(t, time.Duration(0), retryAfter("-5"))
+	require.Equal(t, 120*time.Second, retryAfter("120"))
+
+	d := retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	require.Greater(t, d, 59*time.Minute)
+	require.LessOrEqual(t, d, time.Hour)
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(p *PrometheusRemoteWrite)
+		expected string
+	}{
+		{
+			name:     "missing url",
+			modify:   func(p *PrometheusRemoteWrite) { p.URL = "" },
+			expected: "url must not be empty",
+		},
+		{
+			name:     "no shards",
+			modify:   func(p *PrometheusRemoteWrite) { p.Shards = 0 },
+			expected: "shards must be at least 1",
+		},
+		{
+			name:     "no samples per send",
+			modify:   func(p *PrometheusRemoteWrite) { p.MaxSamplesPerSend = 0 },
+			expected: "max samples per send must be at least 1",
+		},
+		{
+			name:     "backoff",
+			modify:   func(p *PrometheusRemoteWrite) { p.MaxBackoff = config.Duration(time.Millisecond) },
+			expected: "max backoff not below min backoff",
+		},
+		{
+			name:     "invalid external label",
+			modify:   func(p *PrometheusRemoteWrite) { p.ExternalLabels = map[string]string{"data center": "x"} },
+			expected: `invalid external label name "data center"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPrometheusRemoteWrite()
+			plugin.URL = "http://localhost:9090/api/v1/write"
+			tt.modify(plugin)
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,250 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	internalaws "github.com/influxdata/telegraf/config/aws"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/templating"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// DO NOT REMOVE THE NEXT TWO LINES! This is required to embed the sampleConfig data.
+//go:embed sample.conf
+var sampleConfig string
+
+// Layout of the timestamp in the object names, sorting lexically by time
+const objectTimeLayout = "20060102T150405.000000000Z"
+
+type S3 struct {
+	Bucket            string          `toml:"bucket"`
+	KeyTemplate       string          `toml:"key_template"`
+	FileExtension     string          `toml:"file_extension"`
+	ContentType       string          `toml:"content_type"`
+	Compression       string          `toml:"compression"`
+	UsePathStyle      bool            `toml:"use_path_style"`
+	UploadPartSize    config.Size     `toml:"upload_part_size"`
+	UploadConcurrency int             `toml:"upload_concurrency"`
+	Timeout           config.Duration `toml:"timeout"`
+	Log               telegraf.Logger `toml:"-"`
+	internalaws.CredentialConfig
+
+	keyTemplate *template.Template
+	encoder     internal.ContentEncoder
+	uploader    *manager.Uploader
+	serializer  serializers.Serializer
+
+	// Objects uploaded since the last completely successful write
+	uploaded map[string]bool
+}
+
+func (*S3) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *S3) SetSerializer(serializer serializers.Serializer) {
+	s.serializer = serializer
+}
+
+func (s *S3) Init() error {
+	if s.Bucket == "" {
+		return errors.New("bucket must not be empty")
+	}
+
+	tmpl, err := templating.New("key", s.KeyTemplate)
+	if err != nil {
+		return fmt.Errorf("parsing key template failed: %w", err)
+	}
+	s.keyTemplate = tmpl
+
+	switch s.Compression {
+	case "", "none":
+		s.Compression = "identity"
+	case "gzip", "zlib":
+	default:
+		return fmt.Errorf("invalid compression %q", s.Compression)
+	}
+	if s.encoder, err = internal.NewContentEncoder(s.Compression); err != nil {
+		return err
+	}
+
+	if s.UploadPartSize < config.Size(manager.MinUploadPartSize) {
+		return fmt.Errorf("upload part size must be at least %d bytes", manager.MinUploadPartSize)
+	}
+	if s.UploadConcurrency < 1 {
+		return errors.New("upload concurrency must be at least 1")
+	}
+
+	return nil
+}
+
+func (s *S3) Connect() error {
+	cfg, err := s.CredentialConfig.Credentials()
+	if err != nil {
+		return err
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = s.UsePathStyle
+	})
+	s.uploader = manager.NewUploader(client, func(u *manager.Uploader) {
+		u.PartSize = int64(s.UploadPartSize)
+		u.Concurrency = s.UploadConcurrency
+	})
+
+	return nil
+}
+
+func (s *S3) Close() error {
+	return nil
+}
+
+func (s *S3) Write(metrics []telegraf.Metric) error {
+	// Group the metrics by the key prefix, every group is written to a
+	// separate object
+	var prefixes []string
+	groups := make(map[string][]telegraf.Metric)
+	for _, m := range metrics {
+		prefix, err := s.prefix(m)
+		if err != nil {
+			s.Log.Errorf("Dropping metric %q: rendering key template failed: %v", m.Name(), err)
+			continue
+		}
+		if _, found := groups[prefix]; !found {
+			prefixes = append(prefixes, prefix)
+		}
+		groups[prefix] = append(groups[prefix], m)
+	}
+
+	// Upload all groups even if some fail. The whole write is retried in that
+	// case, so objects already uploaded are skipped on the next write.
+	var errs []string
+	for _, prefix := range prefixes {
+		if err := s.upload(prefix, groups[prefix]); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("uploading %d of %d objects failed: %s", len(errs), len(prefixes), strings.Join(errs, "; "))
+	}
+	s.uploaded = make(map[string]bool)
+	return nil
+}
+
+// prefix renders the key template for the metric
+func (s *S3) prefix(m telegraf.Metric) (string, error) {
+	var buf bytes.Buffer
+	if err := s.keyTemplate.Execute(&buf, templating.NewMetric(m)); err != nil {
+		return "", err
+	}
+	return strings.Trim(buf.String(), "/"), nil
+}
+
+// key returns the object key of the metrics within the given prefix. The key
+// is derived from the metrics, so uploading the same metrics again, e.g. when
+// retrying a write, overwrites the existing object instead of duplicating it.
+func (s *S3) key(prefix string, metrics []telegraf.Metric) string {
+	var earliest time.Time
+	h := sha256.New()
+	var ts [8]byte
+	for _, m := range metrics {
+		if earliest.IsZero() || m.Time().Before(earliest) {
+			earliest = m.Time()
+		}
+		h.Write([]byte(m.Name()))
+		for _, tag := range m.TagList() {
+			fmt.Fprintf(h, "\x00%s=%s", tag.Key, tag.Value)
+		}
+		for _, field := range m.FieldList() {
+			fmt.Fprintf(h, "\x00%s=%T:%v", field.Key, field.Value, field.Value)
+		}
+		binary.BigEndian.PutUint64(ts[:], uint64(m.Time().UnixNano()))
+		h.Write(ts[:])
+	}
+	id := hex.EncodeToString(h.Sum(nil)[:16])
+	return path.Join(prefix, earliest.UTC().Format(objectTimeLayout)+"-"+id+s.FileExtension)
+}
+
+func (s *S3) upload(prefix string, metrics []telegraf.Metric) error {
+	key := s.key(prefix, metrics)
+	if s.uploaded[key] {
+		s.Log.Debugf("Skipping %q already uploaded", key)
+		return nil
+	}
+
+	body, err := s.serializer.SerializeBatch(metrics)
+	if err != nil {
+		// Serialization errors are caused by the metrics and will occur
+		// again when retrying, so drop the metrics
+		s.Log.Errorf("Dropping %d metrics: serializing failed: %v", len(metrics), err)
+		return nil
+	}
+	if body, err = s.encoder.Encode(body); err != nil {
+		return fmt.Errorf("compressing failed: %w", err)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
+	}
+	if s.ContentType != "" {
+		input.ContentType = aws.String(s.ContentType)
+	}
+	if s.Compression != "identity" {
+		input.ContentEncoding = aws.String(contentEncoding(s.Compression))
+	}
+
+	ctx := context.Background()
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.Timeout))
+		defer cancel()
+	}
+	if _, err := s.uploader.Upload(ctx, input); err != nil {
+		return fmt.Errorf("uploading %q failed: %w", key, err)
+	}
+	s.uploaded[key] = true
+	return nil
+}
+
+// contentEncoding returns the HTTP content encoding of the compression
+func contentEncoding(compression string) string {
+	if compression == "zlib" {
+		return "deflate"
+	}
+	return compression
+}
+
+func init() {
+	outputs.Add("s3", func() telegraf.Output { return newS3() })
+}
+
+func newS3() *S3 {
+	return &S3{
+		KeyTemplate:       `{{.Name}}/{{.Time.UTC.Format "2006-01-02/15"}}`,
+		UploadPartSize:    config.Size(manager.DefaultUploadPartSize),
+		UploadConcurrency: manager.DefaultUploadConcurrency,
+		Timeout:           config.Duration(5 * time.Minute),
+		uploaded:          make(map[string]bool),
+	}
+}
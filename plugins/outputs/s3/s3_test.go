@@ -0,0 +1,367 @@
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// fakeS3 implements the parts of the S3 API used by the uploader, storing
+// the objects in memory
+type fakeS3 struct {
+	sync.Mutex
+	objects   map[string][]byte
+	encodings map[string]string
+	uploads   map[string]map[int][]byte
+	parts     int
+	puts      int
+
+	// Uploads of keys with this prefix are rejected
+	fail string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		objects:   make(map[string][]byte),
+		encodings: make(map[string]string),
+		uploads:   make(map[string]map[int][]byte),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if f.fail != "" && strings.HasPrefix(key, f.fail) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	query := r.URL.Query()
+	_, initiate := query["uploads"]
+	switch {
+	case r.Method == http.MethodPost && initiate:
+		id := strconv.Itoa(len(f.uploads))
+		f.uploads[id] = make(map[int][]byte)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+	case r.Method == http.MethodPut && query.Get("uploadId") != "":
+		n, err := strconv.Atoi(query.Get("partNumber"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.uploads[query.Get("uploadId")][n] = body
+		f.parts++
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, n))
+	case r.Method == http.MethodPost && query.Get("uploadId") != "":
+		parts := f.uploads[query.Get("uploadId")]
+		numbers := make([]int, 0, len(parts))
+		for n := range parts {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		var buf bytes.Buffer
+		for _, n := range numbers {
+			buf.Write(parts[n])
+		}
+		f.objects[key] = buf.Bytes()
+		fmt.Fprint(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+	case r.Method == http.MethodPut:
+		f.puts++
+		f.objects[key] = body
+		f.encodings[key] = r.Header.Get("Content-Encoding")
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+		_ = xml.NewEncoder(w).Encode(struct {
+			XMLName xml.Name `xml:"Error"`
+			Code    string
+		}{Code: "NotImplemented"})
+	}
+}
+
+func (f *fakeS3) keys() []string {
+	f.Lock()
+	defer f.Unlock()
+
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newPlugin(t *testing.T, url string) *S3 {
+	plugin := newS3()
+	plugin.Bucket = "telegraf"
+	plugin.Region = "us-east-1"
+	plugin.AccessKey = "key"
+	plugin.SecretKey = "secret"
+	plugin.EndpointURL = url
+	plugin.UsePathStyle = true
+	plugin.Log = testutil.Logger{}
+
+	serializer := &influx.Serializer{}
+	require.NoError(t, serializer.Init())
+	plugin.SetSerializer(serializer)
+	return plugin
+}
+
+func TestWrite(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	plugin := newPlugin(t, server.URL)
+	plugin.KeyTemplate = `{{.Name}}/{{.Tag "host"}}/{{.Time.UTC.Format "2006-01-02"}}`
+	plugin.FileExtension = ".influx"
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	ts := time.Unix(1654095845, 0)
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, ts),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2.0}, ts),
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 3.0}, ts.Add(time.Second)),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"value": 4.0}, ts.Add(24*time.Hour)),
+	}
+	require.NoError(t, plugin.Write(metrics))
+
+	keys := fake.keys()
+	require.Len(t, keys, 3)
+	expected := []struct {
+		prefix  string
+		content string
+	}{
+		{
+			prefix:  "telegraf/cpu/a/2022-06-01/",
+			content: "cpu,host=a value=1 1654095845000000000\ncpu,host=a value=3 1654095846000000000\n",
+		},
+		{
+			prefix:  "telegraf/cpu/b/2022-06-01/",
+			content: "cpu,host=b value=2 1654095845000000000\n",
+		},
+		{
+			prefix:  "telegraf/mem/a/2022-06-02/",
+			content: "mem,host=a value=4 1654182245000000000\n",
+		},
+	}
+	for i, e := range expected {
+		require.True(t, strings.HasPrefix(keys[i], e.prefix), "key %q", keys[i])
+		require.True(t, strings.HasSuffix(keys[i], ".influx"), "key %q", keys[i])
+		require.Equal(t, e.content, string(fake.objects[keys[i]]))
+	}
+}
+
+func TestWriteSameMetrics(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	plugin := newPlugin(t, server.URL)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	ts := time.Unix(1654095845, 0)
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, ts.Add(time.Second)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, ts),
+	}
+	require.NoError(t, plugin.Write(metrics))
+	keys := fake.keys()
+	require.Len(t, keys, 1)
+	require.True(t, strings.HasPrefix(keys[0], "telegraf/cpu/2022-06-01/15/20220601T150405.000000000Z-"), "key %q", keys[0])
+
+	// Writing the same metrics again replaces the object
+	require.NoError(t, plugin.Write(metrics))
+	require.Equal(t, keys, fake.keys())
+	require.Equal(t, 2, fake.puts)
+
+	// Different metrics result in a different object
+	metrics[0] = metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 3.0}, ts.Add(time.Second))
+	require.NoError(t, plugin.Write(metrics))
+	require.Len(t, fake.keys(), 2)
+}
+
+func TestWritePartialFailure(t *testing.T) {
+	fake := newFakeS3()
+	fake.fail = "telegraf/mem/"
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	plugin := newPlugin(t, server.URL)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	ts := time.Unix(1654095845, 0)
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, ts),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"value": 2.0}, ts),
+		metric.New("swap", map[string]string{}, map[string]interface{}{"value": 3.0}, ts),
+	}
+
+	// All other objects are uploaded even though one upload fails
+	require.ErrorContains(t, plugin.Write(metrics), "uploading 1 of 3 objects failed")
+	keys := fake.keys()
+	require.Len(t, keys, 2)
+	require.True(t, strings.HasPrefix(keys[0], "telegraf/cpu/"), "key %q", keys[0])
+	require.True(t, strings.HasPrefix(keys[1], "telegraf/swap/"), "key %q", keys[1])
+	require.Equal(t, 2, fake.puts)
+
+	// The retry only uploads the missing object
+	fake.Lock()
+	fake.fail = ""
+	fake.Unlock()
+	require.NoError(t, plugin.Write(metrics))
+	keys = fake.keys()
+	require.Len(t, keys, 3)
+	require.True(t, strings.HasPrefix(keys[1], "telegraf/mem/"), "key %q", keys[1])
+	require.Equal(t, 3, fake.puts)
+}
+
+func TestWriteCompressed(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	plugin := newPlugin(t, server.URL)
+	plugin.Compression = "gzip"
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+
+	keys := fake.keys()
+	require.Len(t, keys, 1)
+	require.Equal(t, "gzip", fake.encodings[keys[0]])
+
+	r, err := gzip.NewReader(bytes.NewReader(fake.objects[keys[0]]))
+	require.NoError(t, err)
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "cpu value=42 0\n", string(content))
+}
+
+func TestWriteMultipart(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	plugin := newPlugin(t, server.URL)
+	plugin.KeyTemplate = "archive"
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	// Exceed the minimum part size of 5MiB
+	metrics := make([]telegraf.Metric, 0, 60000)
+	var expected strings.Builder
+	for i := 0; i < cap(metrics); i++ {
+		m := metric.New("multipart",
+			map[string]string{"source": strings.Repeat("x", 64)},
+			map[string]interface{}{"value": int64(i)},
+			time.Unix(int64(i), 0),
+		)
+		metrics = append(metrics, m)
+		fmt.Fprintf(&expected, "multipart,source=%s value=%di %d\n", strings.Repeat("x", 64), i, m.Time().UnixNano())
+	}
+	require.Greater(t, expected.Len(), 5*1024*1024)
+	require.NoError(t, plugin.Write(metrics))
+
+	keys := fake.keys()
+	require.Len(t, keys, 1)
+	require.True(t, strings.HasPrefix(keys[0], "telegraf/archive/"))
+	require.Equal(t, 2, fake.parts)
+	require.Equal(t, expected.String(), string(fake.objects[keys[0]]))
+}
+
+func TestWriteTemplateError(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	plugin := newPlugin(t, server.URL)
+	plugin.KeyTemplate = `{{.Field "value" | len}}`
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+	require.Empty(t, fake.keys())
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(s *S3)
+		expected string
+	}{
+		{
+			name:     "missing bucket",
+			modify:   func(s *S3) { s.Bucket = "" },
+			expected: "bucket must not be empty",
+		},
+		{
+			name:     "invalid template",
+			modify:   func(s *S3) { s.KeyTemplate = "{{.Name" },
+			expected: "parsing key template failed",
+		},
+		{
+			name:     "invalid compression",
+			modify:   func(s *S3) { s.Compression = "lzma" },
+			expected: `invalid compression "lzma"`,
+		},
+		{
+			name:     "part size too small",
+			modify:   func(s *S3) { s.UploadPartSize = config.Size(1024) },
+			expected: "upload part size must be at least 5242880 bytes",
+		},
+		{
+			name:     "invalid concurrency",
+			modify:   func(s *S3) { s.UploadConcurrency = 0 },
+			expected: "upload concurrency must be at least 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin(t, "")
+			tt.modify(plugin)
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
@@ -4,8 +4,10 @@ package socket_writer
 import (
 	"crypto/tls"
 	_ "embed"
+	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 	tlsint "github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
 // DO NOT REMOVE THE NEXT TWO LINES! This is required to embed the sampleConfig data.
@@ -22,9 +25,16 @@ import (
 var sampleConfig string
 
 type SocketWriter struct {
-	ContentEncoding string `toml:"content_encoding"`
-	Address         string
-	KeepAlivePeriod *config.Duration
+	ContentEncoding     string `toml:"content_encoding"`
+	Address             string
+	KeepAlivePeriod     *config.Duration
+	Framing             string          `toml:"framing"`
+	ConnectTimeout      config.Duration `toml:"connect_timeout"`
+	WriteTimeout        config.Duration `toml:"write_timeout"`
+	ReconnectMinBackoff config.Duration `toml:"reconnect_min_backoff"`
+	ReconnectMaxBackoff config.Duration `toml:"reconnect_max_backoff"`
+	Mode                string          `toml:"mode"`
+	Destinations        []*Destination  `toml:"destination"`
 	tlsint.ClientConfig
 	Log telegraf.Logger `toml:"-"`
 
@@ -32,7 +42,24 @@ type SocketWriter struct {
 
 	encoder internal.ContentEncoder
 
-	net.Conn
+	destinations []*Destination
+	next         int
+}
+
+// Destination is an address to write to with its own TLS configuration and
+// connection health
+type Destination struct {
+	Address string `toml:"address"`
+	tlsint.ClientConfig
+
+	conn     net.Conn
+	failures int
+	retry    time.Time
+}
+
+// available checks if the destination is connected or may be reconnected
+func (d *Destination) available(now time.Time) bool {
+	return d.conn != nil || !now.Before(d.retry)
 }
 
 func (*SocketWriter) SampleConfig() string {
@@ -43,47 +70,98 @@ func (sw *SocketWriter) SetSerializer(s serializers.Serializer) {
 	sw.Serializer = s
 }
 
+func (sw *SocketWriter) Init() error {
+	switch sw.Framing {
+	case "", "none", "newline", "octet-counting":
+	default:
+		return fmt.Errorf("invalid framing %q", sw.Framing)
+	}
+
+	switch sw.Mode {
+	case "failover", "round_robin":
+	default:
+		return fmt.Errorf("invalid mode %q", sw.Mode)
+	}
+
+	if sw.ReconnectMinBackoff < 0 || sw.ReconnectMaxBackoff < sw.ReconnectMinBackoff {
+		return errors.New("reconnect backoff must not be negative with max backoff not below min backoff")
+	}
+
+	// The top-level address is the first destination for compatibility
+	sw.destinations = make([]*Destination, 0, len(sw.Destinations)+1)
+	if sw.Address != "" {
+		sw.destinations = append(sw.destinations, &Destination{Address: sw.Address, ClientConfig: sw.ClientConfig})
+	}
+	sw.destinations = append(sw.destinations, sw.Destinations...)
+	if len(sw.destinations) == 0 {
+		return errors.New("no address or destination configured")
+	}
+	for _, d := range sw.destinations {
+		if len(strings.SplitN(d.Address, "://", 2)) != 2 {
+			return fmt.Errorf("invalid address: %s", d.Address)
+		}
+	}
+
+	var err error
+	sw.encoder, err = internal.NewContentEncoder(sw.ContentEncoding)
+	return err
+}
+
+// Connect connects to the destinations. In failover mode only the first
+// reachable destination is connected, the others are connected on demand.
 func (sw *SocketWriter) Connect() error {
-	spl := strings.SplitN(sw.Address, "://", 2)
-	if len(spl) != 2 {
-		return fmt.Errorf("invalid address: %s", sw.Address)
+	var errs []string
+	for _, d := range sw.destinations {
+		if err := sw.connect(d); err != nil {
+			sw.failed(d, err)
+			errs = append(errs, err.Error())
+			continue
+		}
+		if sw.Mode == "failover" {
+			return nil
+		}
+	}
+
+	if len(errs) == len(sw.destinations) {
+		return fmt.Errorf("connecting failed: %s", strings.Join(errs, "; "))
 	}
+	return nil
+}
 
-	tlsCfg, err := sw.ClientConfig.TLSConfig()
+func (sw *SocketWriter) connect(d *Destination) error {
+	spl := strings.SplitN(d.Address, "://", 2)
+
+	tlsCfg, err := d.ClientConfig.TLSConfig()
 	if err != nil {
 		return err
 	}
 
+	dialer := &net.Dialer{Timeout: time.Duration(sw.ConnectTimeout)}
 	var c net.Conn
 	if tlsCfg == nil {
-		c, err = net.Dial(spl[0], spl[1])
+		c, err = dialer.Dial(spl[0], spl[1])
 	} else {
-		c, err = tls.Dial(spl[0], spl[1], tlsCfg)
+		c, err = tls.DialWithDialer(dialer, spl[0], spl[1], tlsCfg)
 	}
 	if err != nil {
 		return err
 	}
 
-	if err := sw.setKeepAlive(c); err != nil {
-		sw.Log.Debugf("Unable to configure keep alive (%s): %s", sw.Address, err)
-	}
-	//set encoder
-	sw.encoder, err = internal.NewContentEncoder(sw.ContentEncoding)
-	if err != nil {
-		return err
+	if err := sw.setKeepAlive(c, spl[0]); err != nil {
+		sw.Log.Debugf("Unable to configure keep alive (%s): %s", d.Address, err)
 	}
 
-	sw.Conn = c
+	d.conn = c
 	return nil
 }
 
-func (sw *SocketWriter) setKeepAlive(c net.Conn) error {
+func (sw *SocketWriter) setKeepAlive(c net.Conn, network string) error {
 	if sw.KeepAlivePeriod == nil {
 		return nil
 	}
 	tcpc, ok := c.(*net.TCPConn)
 	if !ok {
-		return fmt.Errorf("cannot set keep alive on a %s socket", strings.SplitN(sw.Address, "://", 2)[0])
+		return fmt.Errorf("cannot set keep alive on a %s socket", network)
 	}
 	if *sw.KeepAlivePeriod == 0 {
 		return tcpc.SetKeepAlive(false)
@@ -94,17 +172,47 @@ func (sw *SocketWriter) setKeepAlive(c net.Conn) error {
 	return tcpc.SetKeepAlivePeriod(time.Duration(*sw.KeepAlivePeriod))
 }
 
+// failed closes the connection of the destination and delays reconnecting
+// with an exponential backoff
+func (sw *SocketWriter) failed(d *Destination, err error) {
+	if d.conn != nil {
+		if cerr := d.conn.Close(); cerr != nil {
+			sw.Log.Debugf("Closing connection to %s failed: %v", d.Address, cerr)
+		}
+		d.conn = nil
+	}
+
+	backoff := time.Duration(sw.ReconnectMinBackoff)
+	for i := 0; i < d.failures && backoff < time.Duration(sw.ReconnectMaxBackoff); i++ {
+		backoff *= 2
+	}
+	if backoff > time.Duration(sw.ReconnectMaxBackoff) {
+		backoff = time.Duration(sw.ReconnectMaxBackoff)
+	}
+	d.failures++
+	d.retry = time.Now().Add(backoff)
+
+	sw.Log.Warnf("Destination %s failed, reconnecting in %s: %v", d.Address, backoff, err)
+}
+
+// frame returns the message with the configured framing applied
+func (sw *SocketWriter) frame(msg []byte) []byte {
+	switch sw.Framing {
+	case "newline":
+		if len(msg) == 0 || msg[len(msg)-1] != '\n' {
+			msg = append(msg, '\n')
+		}
+	case "octet-counting":
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	return msg
+}
+
 // Write writes the given metrics to the destination.
 // If an error is encountered, it is up to the caller to retry the same write again later.
 // Not parallel safe.
 func (sw *SocketWriter) Write(metrics []telegraf.Metric) error {
-	if sw.Conn == nil {
-		// previous write failed with permanent error and socket was closed.
-		if err := sw.Connect(); err != nil {
-			return err
-		}
-	}
-
+	messages := make([][]byte, 0, len(metrics))
 	for _, m := range metrics {
 		bs, err := sw.Serialize(m)
 		if err != nil {
@@ -118,35 +226,96 @@ func (sw *SocketWriter) Write(metrics []telegraf.Metric) error {
 			continue
 		}
 
-		if _, err := sw.Conn.Write(bs); err != nil {
-			//TODO log & keep going with remaining strings
-			if err, ok := err.(net.Error); !ok || !err.Temporary() {
-				// permanent error. close the connection
-				sw.Close() //nolint:revive // There is another error which will be returned here
-				sw.Conn = nil
-				return fmt.Errorf("closing connection: %v", err)
-			}
+		// Copy the message as the encoder reuses its buffer
+		messages = append(messages, sw.frame(append([]byte(nil), bs...)))
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	// Failover always prefers the destinations in the configured order while
+	// round-robin starts with the next destination for every write
+	start := 0
+	if sw.Mode == "round_robin" {
+		start = sw.next
+		sw.next = (sw.next + 1) % len(sw.destinations)
+	}
+
+	now := time.Now()
+	errs := make([]string, 0, len(sw.destinations))
+	for i := range sw.destinations {
+		d := sw.destinations[(start+i)%len(sw.destinations)]
+		if !d.available(now) {
+			errs = append(errs, fmt.Sprintf("%s: waiting to reconnect", d.Address))
+			continue
+		}
+
+		err := sw.write(d, messages)
+		if err == nil {
+			return nil
+		}
+		if nerr, ok := err.(net.Error); ok && nerr.Temporary() && d.conn != nil {
+			// Keep the connection on temporary errors and retry it with the next write
+			sw.Log.Debugf("Writing to %s failed temporarily: %v", d.Address, err)
+		} else {
+			sw.failed(d, err)
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", d.Address, err))
+	}
+	return fmt.Errorf("writing to all destinations failed: %s", strings.Join(errs, "; "))
+}
+
+func (sw *SocketWriter) write(d *Destination, messages [][]byte) error {
+	if d.conn == nil {
+		if err := sw.connect(d); err != nil {
 			return err
 		}
 	}
 
+	if sw.WriteTimeout > 0 {
+		if err := d.conn.SetWriteDeadline(time.Now().Add(time.Duration(sw.WriteTimeout))); err != nil {
+			return err
+		}
+	}
+	for _, msg := range messages {
+		if _, err := d.conn.Write(msg); err != nil {
+			return err
+		}
+	}
+
+	if d.failures > 0 {
+		sw.Log.Infof("Destination %s recovered", d.Address)
+		d.failures = 0
+	}
 	return nil
 }
 
-// Close closes the connection. Noop if already closed.
+// Close closes the connections. Noop if already closed.
 func (sw *SocketWriter) Close() error {
-	if sw.Conn == nil {
-		return nil
+	var errs []string
+	for _, d := range sw.destinations {
+		if d.conn == nil {
+			continue
+		}
+		if err := d.conn.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+		d.conn = nil
 	}
-	err := sw.Conn.Close()
-	sw.Conn = nil
-	return err
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
 }
 
 func newSocketWriter() *SocketWriter {
-	s, _ := serializers.NewInfluxSerializer()
 	return &SocketWriter{
-		Serializer: s,
+		Serializer:          influx.NewSerializer(),
+		Framing:             "none",
+		ConnectTimeout:      config.Duration(10 * time.Second),
+		ReconnectMinBackoff: config.Duration(time.Second),
+		ReconnectMaxBackoff: config.Duration(time.Minute),
+		Mode:                "failover",
 	}
 }
 
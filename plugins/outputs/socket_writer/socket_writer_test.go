@@ -2,14 +2,19 @@ package socket_writer
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
+	"io"
 	"net"
 	"runtime"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -18,8 +23,10 @@ func TestSocketWriter_tcp(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "tcp://" + listener.Addr().String()
 
+	require.NoError(t, sw.Init())
 	err = sw.Connect()
 	require.NoError(t, err)
 
@@ -34,8 +41,10 @@ func TestSocketWriter_udp(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "udp://" + listener.LocalAddr().String()
 
+	require.NoError(t, sw.Init())
 	err = sw.Connect()
 	require.NoError(t, err)
 
@@ -49,8 +58,10 @@ func TestSocketWriter_unix(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "unix://" + sock
 
+	require.NoError(t, sw.Init())
 	err = sw.Connect()
 	require.NoError(t, err)
 
@@ -71,8 +82,10 @@ func TestSocketWriter_unixgram(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "unixgram://" + sock
 
+	require.NoError(t, sw.Init())
 	err = sw.Connect()
 	require.NoError(t, err)
 
@@ -133,11 +146,13 @@ func TestSocketWriter_Write_err(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "tcp://" + listener.Addr().String()
 
+	require.NoError(t, sw.Init())
 	err = sw.Connect()
 	require.NoError(t, err)
-	err = sw.Conn.(*net.TCPConn).SetReadBuffer(256)
+	err = sw.destinations[0].conn.(*net.TCPConn).SetReadBuffer(256)
 	require.NoError(t, err)
 
 	lconn, err := listener.Accept()
@@ -151,12 +166,55 @@ func TestSocketWriter_Write_err(t *testing.T) {
 	err = lconn.Close()
 	require.NoError(t, err)
 
-	err = sw.Conn.Close()
+	err = sw.destinations[0].conn.Close()
 	require.NoError(t, err)
 
 	err = sw.Write(metrics)
 	require.Error(t, err)
-	require.Nil(t, sw.Conn)
+	require.Nil(t, sw.destinations[0].conn)
+}
+
+// temporaryError is a network error marked as temporary
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "temporary failure" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
+
+// temporaryFailingConn fails every write with a temporary error
+type temporaryFailingConn struct {
+	net.Conn
+}
+
+func (temporaryFailingConn) Write([]byte) (int, error) {
+	return 0, temporaryError{}
+}
+
+func TestSocketWriter_Write_temporary_err(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
+	sw.Address = "tcp://" + listener.Addr().String()
+
+	require.NoError(t, sw.Init())
+	require.NoError(t, sw.Connect())
+	defer sw.Close()
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+	defer lconn.Close()
+
+	// The connection must be kept for temporary errors
+	conn := &temporaryFailingConn{Conn: sw.destinations[0].conn}
+	sw.destinations[0].conn = conn
+
+	require.Error(t, sw.Write([]telegraf.Metric{testutil.TestMetric(1, "testerr")}))
+	require.Same(t, conn, sw.destinations[0].conn)
+	require.Zero(t, sw.destinations[0].failures)
+	require.True(t, sw.destinations[0].available(time.Now()))
 }
 
 func TestSocketWriter_Write_reconnect(t *testing.T) {
@@ -164,11 +222,13 @@ func TestSocketWriter_Write_reconnect(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "tcp://" + listener.Addr().String()
 
+	require.NoError(t, sw.Init())
 	err = sw.Connect()
 	require.NoError(t, err)
-	err = sw.Conn.(*net.TCPConn).SetReadBuffer(256)
+	err = sw.destinations[0].conn.(*net.TCPConn).SetReadBuffer(256)
 	require.NoError(t, err)
 
 	lconn, err := listener.Accept()
@@ -178,7 +238,7 @@ func TestSocketWriter_Write_reconnect(t *testing.T) {
 
 	err = lconn.Close()
 	require.NoError(t, err)
-	sw.Conn = nil
+	sw.destinations[0].conn = nil
 
 	wg := sync.WaitGroup{}
 	wg.Add(1)
@@ -207,11 +267,210 @@ func TestSocketWriter_udp_gzip(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "udp://" + listener.LocalAddr().String()
 	sw.ContentEncoding = "gzip"
 
+	require.NoError(t, sw.Init())
 	err = sw.Connect()
 	require.NoError(t, err)
 
 	testSocketWriterPacket(t, sw, listener)
 }
+
+func TestFraming(t *testing.T) {
+	tests := []struct {
+		framing  string
+		expected string
+	}{
+		{
+			framing:  "none",
+			expected: "test,tag1=value1 value=1i 1257894000000000000\n",
+		},
+		{
+			framing:  "newline",
+			expected: "test,tag1=value1 value=1i 1257894000000000000\n",
+		},
+		{
+			framing:  "octet-counting",
+			expected: "46 test,tag1=value1 value=1i 1257894000000000000\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.framing, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer listener.Close()
+
+			sw := newSocketWriter()
+			sw.Log = testutil.Logger{}
+			sw.Address = "tcp://" + listener.Addr().String()
+			sw.Framing = tt.framing
+			require.NoError(t, sw.Init())
+			require.NoError(t, sw.Connect())
+			defer sw.Close()
+
+			lconn, err := listener.Accept()
+			require.NoError(t, err)
+			defer lconn.Close()
+
+			require.NoError(t, sw.Write([]telegraf.Metric{testutil.TestMetric(1, "test")}))
+
+			buf := make([]byte, len(tt.expected))
+			_, err = io.ReadFull(lconn, buf)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(buf))
+		})
+	}
+}
+
+func TestNewlineFraming(t *testing.T) {
+	sw := newSocketWriter()
+	sw.Framing = "newline"
+	require.Equal(t, "foo\n", string(sw.frame([]byte("foo"))))
+	require.Equal(t, "foo\n", string(sw.frame([]byte("foo\n"))))
+}
+
+// unreachable returns the address of a closed TCP listener
+func unreachable(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return "tcp://" + addr
+}
+
+func TestFailover(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
+	sw.Address = unreachable(t)
+	sw.Destinations = []*Destination{{Address: "tcp://" + listener.Addr().String()}}
+	require.NoError(t, sw.Init())
+	require.NoError(t, sw.Connect())
+	defer sw.Close()
+
+	// The first destination is waiting to reconnect after the failure
+	primary := sw.destinations[0]
+	require.Nil(t, primary.conn)
+	require.Equal(t, 1, primary.failures)
+	require.False(t, primary.available(time.Now()))
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+	defer lconn.Close()
+
+	require.NoError(t, sw.Write([]telegraf.Metric{testutil.TestMetric(1, "test")}))
+
+	scnr := bufio.NewScanner(lconn)
+	require.True(t, scnr.Scan())
+	require.Equal(t, "test,tag1=value1 value=1i 1257894000000000000", scnr.Text())
+
+	// All destinations failing
+	require.NoError(t, listener.Close())
+	require.NoError(t, lconn.Close())
+	sw.destinations[1].conn.Close()
+	require.Error(t, sw.Write([]telegraf.Metric{testutil.TestMetric(1, "test")}))
+	require.Nil(t, sw.destinations[1].conn)
+	require.Equal(t, 1, sw.destinations[1].failures)
+}
+
+func TestRoundRobin(t *testing.T) {
+	var listeners []net.Listener
+	var destinations []*Destination
+	for i := 0; i < 2; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		listeners = append(listeners, listener)
+		destinations = append(destinations, &Destination{Address: "tcp://" + listener.Addr().String()})
+	}
+
+	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
+	sw.Mode = "round_robin"
+	sw.Destinations = destinations
+	require.NoError(t, sw.Init())
+	require.NoError(t, sw.Connect())
+	defer sw.Close()
+
+	var scanners []*bufio.Scanner
+	for _, listener := range listeners {
+		lconn, err := listener.Accept()
+		require.NoError(t, err)
+		defer lconn.Close()
+		scanners = append(scanners, bufio.NewScanner(lconn))
+	}
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, sw.Write([]telegraf.Metric{testutil.TestMetric(i, "test")}))
+	}
+
+	for i, scnr := range scanners {
+		for j := i; j < 4; j += 2 {
+			require.True(t, scnr.Scan())
+			require.Equal(t, fmt.Sprintf("test,tag1=value1 value=%di 1257894000000000000", j), scnr.Text())
+		}
+	}
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
+	sw.ReconnectMinBackoff = config.Duration(time.Second)
+	sw.ReconnectMaxBackoff = config.Duration(5 * time.Second)
+
+	d := &Destination{Address: "tcp://127.0.0.1:0"}
+	for _, expected := range []time.Duration{1, 2, 4, 5, 5} {
+		before := time.Now()
+		sw.failed(d, errors.New("failure"))
+		require.WithinDuration(t, before.Add(expected*time.Second), d.retry, 100*time.Millisecond)
+	}
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   func(*SocketWriter)
+		expected string
+	}{
+		{
+			name:     "no address",
+			plugin:   func(*SocketWriter) {},
+			expected: "no address or destination configured",
+		},
+		{
+			name: "invalid address",
+			plugin: func(sw *SocketWriter) {
+				sw.Destinations = []*Destination{{Address: "localhost:8094"}}
+			},
+			expected: "invalid address: localhost:8094",
+		},
+		{
+			name: "invalid framing",
+			plugin: func(sw *SocketWriter) {
+				sw.Address = "tcp://localhost:8094"
+				sw.Framing = "foo"
+			},
+			expected: `invalid framing "foo"`,
+		},
+		{
+			name: "invalid mode",
+			plugin: func(sw *SocketWriter) {
+				sw.Address = "tcp://localhost:8094"
+				sw.Mode = "foo"
+			},
+			expected: `invalid mode "foo"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sw := newSocketWriter()
+			tt.plugin(sw)
+			require.EqualError(t, sw.Init(), tt.expected)
+		})
+	}
+}
@@ -4,6 +4,8 @@ package sql
 import (
 	gosql "database/sql"
 	_ "embed"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -30,6 +32,7 @@ type ConvertStruct struct {
 	Defaultvalue    string
 	Unsigned        string
 	Bool            string
+	JSON            string `toml:"json"`
 	ConversionStyle string
 }
 
@@ -37,20 +40,60 @@ type SQL struct {
 	Driver              string
 	DataSourceName      string
 	TimestampColumn     string
+	TagsColumn          string `toml:"tags_column"`
 	TableTemplate       string
 	TableExistsTemplate string
+	TableUpdateTemplate string `toml:"table_update_template"`
+	InsertMode          string `toml:"insert_mode"`
 	InitSQL             string `toml:"init_sql"`
 	Convert             ConvertStruct
 
 	db     *gosql.DB
 	Log    telegraf.Logger `toml:"-"`
-	tables map[string]bool
+	tables map[string]map[string]bool
+}
+
+// Maximum number of placeholders in a multi-row insert statement, chosen to
+// stay below the limits of all supported databases
+const maxPlaceholders = 999
+
+// column of a row with the SQL datatype used when creating the column
+type column struct {
+	name     string
+	datatype string
+}
+
+// batch of rows inserted into the same table and columns
+type batch struct {
+	table   string
+	columns []column
+	rows    [][]interface{}
 }
 
 func (*SQL) SampleConfig() string {
 	return sampleConfig
 }
 
+func (p *SQL) Init() error {
+	switch p.InsertMode {
+	case "":
+		p.InsertMode = "prepared"
+	case "prepared":
+	case "multi_row":
+		if p.Driver == "clickhouse" {
+			return errors.New("clickhouse requires the prepared insert mode")
+		}
+	default:
+		return fmt.Errorf("invalid insert mode %q", p.InsertMode)
+	}
+
+	if p.Convert.JSON == "" {
+		p.Convert.JSON = p.Convert.Text
+	}
+
+	return nil
+}
+
 func (p *SQL) Connect() error {
 	db, err := gosql.Open(p.Driver, p.DataSourceName)
 	if err != nil {
@@ -70,7 +113,7 @@ func (p *SQL) Connect() error {
 	}
 
 	p.db = db
-	p.tables = make(map[string]bool)
+	p.tables = make(map[string]map[string]bool)
 
 	return nil
 }
@@ -131,57 +174,87 @@ func (p *SQL) deriveDatatype(value interface{}) string {
 	return datatype
 }
 
-func (p *SQL) generateCreateTable(metric telegraf.Metric) string {
-	var columns []string
-	//  ##  {KEY_COLUMNS} is a comma-separated list of key columns (timestamp and tags)
-	//var pk []string
+// row returns the columns and values of the metric
+func (p *SQL) row(metric telegraf.Metric) ([]column, []interface{}, error) {
+	var columns []column
+	var values []interface{}
 
 	if p.TimestampColumn != "" {
-		//pk = append(pk, quoteIdent(p.TimestampColumn))
-		columns = append(columns, fmt.Sprintf("%s %s", quoteIdent(p.TimestampColumn), p.Convert.Timestamp))
+		columns = append(columns, column{p.TimestampColumn, p.Convert.Timestamp})
+		values = append(values, metric.Time())
 	}
 
-	for _, tag := range metric.TagList() {
-		//pk = append(pk, quoteIdent(tag.Key))
-		columns = append(columns, fmt.Sprintf("%s %s", quoteIdent(tag.Key), p.Convert.Text))
+	if p.TagsColumn != "" {
+		tags, err := json.Marshal(metric.Tags())
+		if err != nil {
+			return nil, nil, fmt.Errorf("encoding tags failed: %w", err)
+		}
+		columns = append(columns, column{p.TagsColumn, p.Convert.JSON})
+		values = append(values, string(tags))
+	} else {
+		for _, tag := range metric.TagList() {
+			columns = append(columns, column{tag.Key, p.Convert.Text})
+			values = append(values, tag.Value)
+		}
 	}
 
-	var datatype string
 	for _, field := range metric.FieldList() {
-		datatype = p.deriveDatatype(field.Value)
-		columns = append(columns, fmt.Sprintf("%s %s", quoteIdent(field.Key), datatype))
+		columns = append(columns, column{field.Key, p.deriveDatatype(field.Value)})
+		values = append(values, field.Value)
+	}
+
+	return columns, values, nil
+}
+
+func (p *SQL) generateCreateTable(tablename string, columns []column) string {
+	definitions := make([]string, 0, len(columns))
+	for _, c := range columns {
+		definitions = append(definitions, fmt.Sprintf("%s %s", quoteIdent(c.name), c.datatype))
 	}
 
 	query := p.TableTemplate
-	query = strings.ReplaceAll(query, "{TABLE}", quoteIdent(metric.Name()))
-	query = strings.ReplaceAll(query, "{TABLELITERAL}", quoteStr(metric.Name()))
-	query = strings.ReplaceAll(query, "{COLUMNS}", strings.Join(columns, ","))
-	//query = strings.ReplaceAll(query, "{KEY_COLUMNS}", strings.Join(pk, ","))
+	query = strings.ReplaceAll(query, "{TABLE}", quoteIdent(tablename))
+	query = strings.ReplaceAll(query, "{TABLELITERAL}", quoteStr(tablename))
+	query = strings.ReplaceAll(query, "{COLUMNS}", strings.Join(definitions, ","))
 
 	return query
 }
 
-func (p *SQL) generateInsert(tablename string, columns []string) string {
-	var placeholders, quotedColumns []string
-	for _, column := range columns {
-		quotedColumns = append(quotedColumns, quoteIdent(column))
+func (p *SQL) generateAddColumn(tablename string, c column) string {
+	query := p.TableUpdateTemplate
+	query = strings.ReplaceAll(query, "{TABLE}", quoteIdent(tablename))
+	query = strings.ReplaceAll(query, "{TABLELITERAL}", quoteStr(tablename))
+	query = strings.ReplaceAll(query, "{COLUMN}", fmt.Sprintf("%s %s", quoteIdent(c.name), c.datatype))
+
+	return query
+}
+
+// generateInsert creates an insert statement for the given number of rows
+func (p *SQL) generateInsert(tablename string, columns []column, rows int) string {
+	quotedColumns := make([]string, 0, len(columns))
+	for _, c := range columns {
+		quotedColumns = append(quotedColumns, quoteIdent(c.name))
 	}
-	if p.Driver == "pgx" {
-		// Postgres uses $1 $2 $3 as placeholders
-		for i := 0; i < len(columns); i++ {
-			placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
-		}
-	} else {
-		// Everything else uses ? ? ? as placeholders
-		for i := 0; i < len(columns); i++ {
-			placeholders = append(placeholders, "?")
+
+	tuples := make([]string, 0, rows)
+	for i := 0; i < rows; i++ {
+		placeholders := make([]string, 0, len(columns))
+		for j := range columns {
+			if p.Driver == "pgx" {
+				// Postgres uses $1 $2 $3 as placeholders
+				placeholders = append(placeholders, fmt.Sprintf("$%d", i*len(columns)+j+1))
+			} else {
+				// Everything else uses ? ? ? as placeholders
+				placeholders = append(placeholders, "?")
+			}
 		}
+		tuples = append(tuples, "("+strings.Join(placeholders, ",")+")")
 	}
 
-	return fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)",
+	return fmt.Sprintf("INSERT INTO %s(%s) VALUES%s",
 		quoteIdent(tablename),
 		strings.Join(quotedColumns, ","),
-		strings.Join(placeholders, ","))
+		strings.Join(tuples, ","))
 }
 
 func (p *SQL) tableExists(tableName string) bool {
@@ -191,68 +264,169 @@ func (p *SQL) tableExists(tableName string) bool {
 	return err == nil
 }
 
-func (p *SQL) Write(metrics []telegraf.Metric) error {
-	var err error
+// tableColumns queries the names of the existing columns of the table
+func (p *SQL) tableColumns(tableName string) (map[string]bool, error) {
+	rows, err := p.db.Query("SELECT * FROM " + quoteIdent(tableName) + " WHERE 1=0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	for _, metric := range metrics {
-		tablename := metric.Name()
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]bool, len(names))
+	for _, name := range names {
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
 
-		// create table if needed
-		if !p.tables[tablename] && !p.tableExists(tablename) {
-			createStmt := p.generateCreateTable(metric)
-			_, err := p.db.Exec(createStmt)
-			if err != nil {
+// updateSchema creates the table or adds columns missing in the table
+func (p *SQL) updateSchema(tablename string, columns []column) error {
+	existing, found := p.tables[tablename]
+	if !found {
+		if !p.tableExists(tablename) {
+			if _, err := p.db.Exec(p.generateCreateTable(tablename, columns)); err != nil {
 				return err
 			}
+			existing = make(map[string]bool, len(columns))
+			for _, c := range columns {
+				existing[c.name] = true
+			}
+			p.tables[tablename] = existing
+			return nil
 		}
-		p.tables[tablename] = true
 
-		var columns []string
-		var values []interface{}
+		var err error
+		if existing, err = p.tableColumns(tablename); err != nil {
+			return fmt.Errorf("querying columns of table %q failed: %w", tablename, err)
+		}
+		p.tables[tablename] = existing
+	}
 
-		if p.TimestampColumn != "" {
-			columns = append(columns, p.TimestampColumn)
-			values = append(values, metric.Time())
+	for _, c := range columns {
+		if existing[c.name] {
+			continue
+		}
+		if p.TableUpdateTemplate == "" {
+			return fmt.Errorf("column %q missing in table %q", c.name, tablename)
 		}
+		if _, err := p.db.Exec(p.generateAddColumn(tablename, c)); err != nil {
+			return fmt.Errorf("adding column %q to table %q failed: %w", c.name, tablename, err)
+		}
+		existing[c.name] = true
+	}
 
-		for column, value := range metric.Tags() {
-			columns = append(columns, column)
-			values = append(values, value)
+	return nil
+}
+
+func (p *SQL) Write(metrics []telegraf.Metric) error {
+	// Update the schema before inserting any rows, as some databases commit
+	// transactions implicitly when changing the schema. Rows are grouped by
+	// table and columns to insert them using the same statement.
+	var batches []*batch
+	index := make(map[string]*batch)
+	for _, metric := range metrics {
+		tablename := metric.Name()
+		columns, values, err := p.row(metric)
+		if err != nil {
+			return err
 		}
 
-		for column, value := range metric.Fields() {
-			columns = append(columns, column)
-			values = append(values, value)
+		if err := p.updateSchema(tablename, columns); err != nil {
+			// The cached columns might be outdated if the schema was changed
+			// externally, so check the table again with the next write
+			delete(p.tables, tablename)
+			return err
 		}
 
-		sql := p.generateInsert(tablename, columns)
+		key := tablename
+		for _, c := range columns {
+			key += "\x00" + c.name
+		}
+		b, found := index[key]
+		if !found {
+			b = &batch{table: tablename, columns: columns}
+			index[key] = b
+			batches = append(batches, b)
+		}
+		b.rows = append(b.rows, values)
+	}
 
-		switch p.Driver {
-		case "clickhouse":
-			// ClickHouse needs to batch inserts with prepared statements
-			tx, err := p.db.Begin()
-			if err != nil {
-				return fmt.Errorf("begin failed: %v", err)
-			}
-			stmt, err := tx.Prepare(sql)
-			if err != nil {
-				return fmt.Errorf("prepare failed: %v", err)
+	// ClickHouse only supports a single insert statement per transaction
+	if p.Driver == "clickhouse" {
+		for _, b := range batches {
+			if err := p.writeTransaction([]*batch{b}); err != nil {
+				return err
 			}
-			defer stmt.Close() //nolint:revive // We cannot do anything about a failing close.
+		}
+		return nil
+	}
+	return p.writeTransaction(batches)
+}
 
-			_, err = stmt.Exec(values...)
-			if err != nil {
-				return fmt.Errorf("execution failed: %v", err)
-			}
-			err = tx.Commit()
-			if err != nil {
-				return fmt.Errorf("commit failed: %v", err)
-			}
-		default:
-			_, err = p.db.Exec(sql, values...)
-			if err != nil {
-				return fmt.Errorf("execution failed: %v", err)
+func (p *SQL) writeTransaction(batches []*batch) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin failed: %w", err)
+	}
+
+	for _, b := range batches {
+		if p.InsertMode == "multi_row" {
+			err = p.insertMultiRow(tx, b)
+		} else {
+			err = p.insertPrepared(tx, b)
+		}
+		if err != nil {
+			if rerr := tx.Rollback(); rerr != nil {
+				p.Log.Errorf("Rollback failed: %v", rerr)
 			}
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+func (p *SQL) insertPrepared(tx *gosql.Tx, b *batch) error {
+	stmt, err := tx.Prepare(p.generateInsert(b.table, b.columns, 1))
+	if err != nil {
+		return fmt.Errorf("prepare failed: %w", err)
+	}
+	defer stmt.Close() //nolint:revive // We cannot do anything about a failing close.
+
+	for _, values := range b.rows {
+		if _, err := stmt.Exec(values...); err != nil {
+			return fmt.Errorf("execution failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *SQL) insertMultiRow(tx *gosql.Tx, b *batch) error {
+	size := maxPlaceholders / len(b.columns)
+	if size < 1 {
+		size = 1
+	}
+
+	for start := 0; start < len(b.rows); start += size {
+		end := start + size
+		if end > len(b.rows) {
+			end = len(b.rows)
+		}
+
+		values := make([]interface{}, 0, (end-start)*len(b.columns))
+		for _, row := range b.rows[start:end] {
+			values = append(values, row...)
+		}
+		if _, err := tx.Exec(p.generateInsert(b.table, b.columns, end-start), values...); err != nil {
+			return fmt.Errorf("execution failed: %w", err)
 		}
 	}
 	return nil
@@ -266,6 +440,7 @@ func newSQL() *SQL {
 	return &SQL{
 		TableTemplate:       "CREATE TABLE {TABLE}({COLUMNS})",
 		TableExistsTemplate: "SELECT 1 FROM {TABLE} LIMIT 1",
+		TableUpdateTemplate: "ALTER TABLE {TABLE} ADD COLUMN {COLUMN}",
 		TimestampColumn:     "timestamp",
 		Convert: ConvertStruct{
 			Integer:         "INT",
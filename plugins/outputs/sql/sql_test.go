@@ -203,6 +203,7 @@ func TestMysqlIntegration(t *testing.T) {
 	//p.Convert.Timestamp = "TEXT" //disable mysql default current_timestamp()
 	p.InitSQL = "SET sql_mode='ANSI_QUOTES';"
 
+	require.NoError(t, p.Init())
 	require.NoError(t, p.Connect())
 	require.NoError(t, p.Write(
 		testMetrics,
@@ -284,6 +285,7 @@ func TestPostgresIntegration(t *testing.T) {
 	p.Convert.Unsigned = "bigint"
 	p.Convert.ConversionStyle = "literal"
 
+	require.NoError(t, p.Init())
 	require.NoError(t, p.Connect())
 	require.NoError(t, p.Write(
 		testMetrics,
@@ -377,6 +379,7 @@ func TestClickHouseIntegration(t *testing.T) {
 	p.Convert.Bool = "UInt8"
 	p.Convert.ConversionStyle = "literal"
 
+	require.NoError(t, p.Init())
 	require.NoError(t, p.Connect())
 
 	require.NoError(t, p.Write(testMetrics))
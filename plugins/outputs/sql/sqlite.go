@@ -1,8 +1,5 @@
-//go:build linux && freebsd && darwin && (!mips || !mips64)
-// +build linux
-// +build freebsd
-// +build darwin
-// +build !mips !mips64
+//go:build (linux && 386) || (linux && amd64) || (linux && arm) || (linux && arm64) || (darwin && amd64)
+// +build linux,386 linux,amd64 linux,arm linux,arm64 darwin,amd64
 
 package sql
 
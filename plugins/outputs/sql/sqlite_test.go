@@ -1,16 +1,17 @@
-//go:build linux && freebsd && (!mips || !mips64)
-// +build linux
-// +build freebsd
-// +build !mips !mips64
+//go:build (linux && 386) || (linux && amd64) || (linux && arm) || (linux && arm64) || (darwin && amd64)
+// +build linux,386 linux,amd64 linux,arm linux,arm64 darwin,amd64
 
 package sql
 
 import (
 	gosql "database/sql"
+	"fmt"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -28,6 +29,7 @@ func TestSqlite(t *testing.T) {
 	p.Driver = "sqlite"
 	p.DataSourceName = address
 
+	require.NoError(t, p.Init())
 	require.NoError(t, p.Connect())
 	require.NoError(t, p.Write(
 		testMetrics,
@@ -55,7 +57,8 @@ func TestSqlite(t *testing.T) {
 	require.True(t, rows.Next())
 	require.NoError(t, rows.Scan(&sql))
 	require.Equal(t,
-		`CREATE TABLE "metric_one"("timestamp" TIMESTAMP,"tag_one" TEXT,"tag_two" TEXT,"int64_one" INT,"int64_two" INT)`,
+		`CREATE TABLE "metric_one"("timestamp" TIMESTAMP,"tag_one" TEXT,"tag_two" TEXT,"int64_one" INT,"int64_two" INT,`+
+			`"bool_one" BOOL,"bool_two" BOOL,"uint64_one" INT UNSIGNED,"float64_one" DOUBLE)`,
 		sql,
 	)
 	require.True(t, rows.Next())
@@ -130,3 +133,152 @@ func TestSqlite(t *testing.T) {
 	require.False(t, rows.Next())
 	require.NoError(t, rows.Close()) //nolint:sqlclosecheck
 }
+
+func newSqlite(t *testing.T) (*SQL, *gosql.DB) {
+	address := filepath.Join(t.TempDir(), "db")
+
+	p := newSQL()
+	p.Log = testutil.Logger{}
+	p.Driver = "sqlite"
+	p.DataSourceName = address
+
+	db, err := gosql.Open("sqlite", address)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return p, db
+}
+
+func TestSqliteSchemaUpdate(t *testing.T) {
+	p, db := newSqlite(t)
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+	defer p.Close()
+
+	require.NoError(t, p.Write([]telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.5}, ts),
+	}))
+
+	// New tags and fields in later metrics are added as columns
+	require.NoError(t, p.Write([]telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "b", "cpu": "cpu0"}, map[string]interface{}{"usage": 2.5, "count": int64(2)}, ts),
+		metric.New("cpu", map[string]string{"host": "c"}, map[string]interface{}{"usage": 3.5}, ts),
+	}))
+
+	var sql string
+	require.NoError(t, db.QueryRow("select sql from sqlite_master where name = 'cpu'").Scan(&sql))
+	require.Equal(t, `CREATE TABLE "cpu"("timestamp" TIMESTAMP,"host" TEXT,"usage" DOUBLE, "cpu" TEXT, "count" INT)`, sql)
+
+	rows, err := db.Query(`select host, cpu, usage, count from cpu order by host`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var actual []string
+	for rows.Next() {
+		var host string
+		var cpu gosql.NullString
+		var usage float64
+		var count gosql.NullInt64
+		require.NoError(t, rows.Scan(&host, &cpu, &usage, &count))
+		actual = append(actual, fmt.Sprintf("%s %s %v %d", host, cpu.String, usage, count.Int64))
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []string{"a  1.5 0", "b cpu0 2.5 2", "c  3.5 0"}, actual)
+
+	// Columns added externally are detected after restarting
+	_, err = db.Exec(`alter table cpu add column "load" DOUBLE`)
+	require.NoError(t, err)
+	require.NoError(t, p.Close())
+	require.NoError(t, p.Connect())
+	require.NoError(t, p.Write([]telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "d"}, map[string]interface{}{"load": 0.5}, ts),
+	}))
+}
+
+func TestSqliteSchemaUpdateDisabled(t *testing.T) {
+	p, db := newSqlite(t)
+	p.TableUpdateTemplate = ""
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+	defer p.Close()
+
+	require.NoError(t, p.Write([]telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.5}, ts),
+	}))
+
+	// The whole batch fails without inserting any row
+	err := p.Write([]telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": 2.5}, ts),
+		metric.New("cpu", map[string]string{"host": "c"}, map[string]interface{}{"count": int64(2)}, ts),
+	})
+	require.EqualError(t, err, `column "count" missing in table "cpu"`)
+
+	var count int
+	require.NoError(t, db.QueryRow("select count(*) from cpu").Scan(&count))
+	require.Equal(t, 1, count)
+}
+
+func TestSqliteTransactionRollback(t *testing.T) {
+	p, db := newSqlite(t)
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+	defer p.Close()
+
+	_, err := db.Exec(`create table "mem"("timestamp" TIMESTAMP, "host" TEXT, "used" INT NOT NULL)`)
+	require.NoError(t, err)
+
+	// The second batch violates the constraint and rolls back the first
+	err = p.Write([]telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.5}, ts),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"free": int64(1)}, ts),
+	})
+	require.ErrorContains(t, err, "execution failed")
+
+	var count int
+	require.NoError(t, db.QueryRow("select count(*) from cpu").Scan(&count))
+	require.Equal(t, 0, count)
+}
+
+func TestSqliteMultiRowTagsColumn(t *testing.T) {
+	p, db := newSqlite(t)
+	p.InsertMode = "multi_row"
+	p.TagsColumn = "tags"
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+	defer p.Close()
+
+	// Enough rows to split the insert into multiple statements
+	metrics := make([]telegraf.Metric, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		metrics = append(metrics, metric.New(
+			"cpu",
+			map[string]string{"host": fmt.Sprintf("host%d", i), "cpu": "cpu0"},
+			map[string]interface{}{"usage": float64(i)},
+			ts,
+		))
+	}
+	require.NoError(t, p.Write(metrics))
+
+	var sql string
+	require.NoError(t, db.QueryRow("select sql from sqlite_master where name = 'cpu'").Scan(&sql))
+	require.Equal(t, `CREATE TABLE "cpu"("timestamp" TIMESTAMP,"tags" TEXT,"usage" DOUBLE)`, sql)
+
+	var count int
+	require.NoError(t, db.QueryRow("select count(*) from cpu").Scan(&count))
+	require.Equal(t, 1000, count)
+
+	var tags string
+	require.NoError(t, db.QueryRow("select tags from cpu where usage = 42").Scan(&tags))
+	require.JSONEq(t, `{"cpu": "cpu0", "host": "host42"}`, tags)
+}
+
+func TestInitError(t *testing.T) {
+	p := newSQL()
+	p.InsertMode = "bulk"
+	require.EqualError(t, p.Init(), `invalid insert mode "bulk"`)
+
+	p = newSQL()
+	p.Driver = "clickhouse"
+	p.InsertMode = "multi_row"
+	require.EqualError(t, p.Init(), "clickhouse requires the prepared insert mode")
+}
@@ -2,6 +2,8 @@ package all
 
 import (
 	//Blank imports for plugins to register themselves
+	_ "github.com/influxdata/telegraf/plugins/parsers/avro"
+	_ "github.com/influxdata/telegraf/plugins/parsers/binary"
 	_ "github.com/influxdata/telegraf/plugins/parsers/collectd"
 	_ "github.com/influxdata/telegraf/plugins/parsers/csv"
 	_ "github.com/influxdata/telegraf/plugins/parsers/dropwizard"
@@ -14,7 +16,9 @@ import (
 	_ "github.com/influxdata/telegraf/plugins/parsers/json_v2"
 	_ "github.com/influxdata/telegraf/plugins/parsers/logfmt"
 	_ "github.com/influxdata/telegraf/plugins/parsers/nagios"
+	_ "github.com/influxdata/telegraf/plugins/parsers/opentsdb"
 	_ "github.com/influxdata/telegraf/plugins/parsers/prometheus"
+	_ "github.com/influxdata/telegraf/plugins/parsers/protobuf"
 	_ "github.com/influxdata/telegraf/plugins/parsers/prometheusremotewrite"
 	_ "github.com/influxdata/telegraf/plugins/parsers/value"
 	_ "github.com/influxdata/telegraf/plugins/parsers/wavefront"
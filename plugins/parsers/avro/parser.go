@@ -0,0 +1,295 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	commonavro "github.com/influxdata/telegraf/plugins/common/avro"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+type Parser struct {
+	SchemaRegistry    string   `toml:"avro_schema_registry"`
+	SchemaFile        string   `toml:"avro_schema_file"`
+	Framing           string   `toml:"avro_framing"`
+	Measurement       string   `toml:"avro_measurement"`
+	MeasurementField  string   `toml:"avro_measurement_field"`
+	Tags              []string `toml:"avro_tags"`
+	Fields            []string `toml:"avro_fields"`
+	Timestamp         string   `toml:"avro_timestamp"`
+	TimestampFormat   string   `toml:"avro_timestamp_format"`
+	TimestampTimezone string   `toml:"avro_timestamp_timezone"`
+	FieldSeparator    string   `toml:"avro_field_separator"`
+
+	DefaultMetricName string            `toml:"-"`
+	DefaultTags       map[string]string `toml:"-"`
+	Log               telegraf.Logger   `toml:"-"`
+
+	registry *commonavro.SchemaRegistry
+	schema   *commonavro.Schema
+}
+
+func (p *Parser) Init() error {
+	switch p.Framing {
+	case "":
+		p.Framing = "confluent"
+	case "confluent", "none":
+	default:
+		return fmt.Errorf("invalid framing %q", p.Framing)
+	}
+
+	switch {
+	case p.SchemaRegistry != "" && p.SchemaFile != "":
+		return errors.New("only one of avro_schema_registry and avro_schema_file can be specified")
+	case p.SchemaRegistry != "":
+		if p.Framing != "confluent" {
+			return errors.New("using a schema registry requires the confluent framing")
+		}
+		p.registry = commonavro.NewSchemaRegistry(p.SchemaRegistry)
+	case p.SchemaFile != "":
+		schema, err := commonavro.NewSchemaFromFile(0, p.SchemaFile)
+		if err != nil {
+			return fmt.Errorf("loading schema %q failed: %w", p.SchemaFile, err)
+		}
+		p.schema = schema
+	default:
+		return errors.New("either avro_schema_registry or avro_schema_file must be specified")
+	}
+
+	if p.Measurement == "" {
+		p.Measurement = p.DefaultMetricName
+	}
+	if p.TimestampFormat == "" {
+		p.TimestampFormat = "unix"
+	}
+	if p.FieldSeparator == "" {
+		p.FieldSeparator = "_"
+	}
+
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	schema := p.schema
+	payload := buf
+	if p.Framing == "confluent" {
+		id, data, err := commonavro.SplitMessage(buf)
+		if err != nil {
+			return nil, err
+		}
+		payload = data
+		if p.registry != nil {
+			if schema, err = p.registry.SchemaByID(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	metrics := make([]telegraf.Metric, 0, 1)
+	for len(payload) > 0 {
+		native, remaining, err := schema.Codec.NativeFromBinary(payload)
+		if err != nil {
+			return nil, fmt.Errorf("decoding message failed: %w", err)
+		}
+		record, ok := native.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("decoded message is of type %T and not a record", native)
+		}
+
+		m, err := p.createMetric(schema, record)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+
+		// Records without any data do not consume bytes
+		if len(remaining) == len(payload) {
+			break
+		}
+		payload = remaining
+	}
+
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, errors.New("no metric in line")
+	}
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) createMetric(schema *commonavro.Schema, record map[string]interface{}) (telegraf.Metric, error) {
+	// Flatten the record using dotted paths as keys
+	values := make(map[string]interface{})
+	flatten(schema, "", record, values)
+
+	name := p.Measurement
+	if p.MeasurementField != "" {
+		if v, found := values[p.MeasurementField]; found {
+			name = fmt.Sprintf("%v", v)
+			delete(values, p.MeasurementField)
+		} else {
+			p.Log.Debugf("Measurement field %q not found in message", p.MeasurementField)
+		}
+	}
+
+	timestamp := time.Now()
+	if p.Timestamp != "" {
+		raw, found := values[p.Timestamp]
+		if !found {
+			return nil, fmt.Errorf("timestamp field %q not found in message", p.Timestamp)
+		}
+		if ts, ok := raw.(time.Time); ok {
+			timestamp = ts
+		} else {
+			ts, err := internal.ParseTimestamp(p.TimestampFormat, convert(raw), p.TimestampTimezone)
+			if err != nil {
+				return nil, fmt.Errorf("parsing timestamp failed: %w", err)
+			}
+			timestamp = ts
+		}
+		delete(values, p.Timestamp)
+	}
+
+	tags := make(map[string]string, len(p.Tags)+len(p.DefaultTags))
+	for _, path := range p.Tags {
+		for key, v := range values {
+			if !matchPath(key, path) {
+				continue
+			}
+			tags[p.outputName(key)] = fmt.Sprintf("%v", convert(v))
+			delete(values, key)
+		}
+	}
+	for k, v := range p.DefaultTags {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
+
+	fields := make(map[string]interface{}, len(values))
+	for key, v := range values {
+		if len(p.Fields) > 0 && !p.selected(key) {
+			continue
+		}
+		fields[p.outputName(key)] = convert(v)
+	}
+
+	return metric.New(name, tags, fields, timestamp), nil
+}
+
+func (p *Parser) selected(key string) bool {
+	for _, path := range p.Fields {
+		if matchPath(key, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) outputName(key string) string {
+	return strings.ReplaceAll(key, ".", p.FieldSeparator)
+}
+
+// matchPath checks if the key is the given path or an element nested below it
+func matchPath(key, path string) bool {
+	return key == path || strings.HasPrefix(key, path+".")
+}
+
+func flatten(schema *commonavro.Schema, prefix string, value interface{}, out map[string]interface{}) {
+	switch v := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		// Unions are represented as a map with the type name as only key
+		if len(v) == 1 {
+			for k, inner := range v {
+				if schema.IsUnionType(k) {
+					flatten(schema, prefix, inner, out)
+					return
+				}
+			}
+		}
+		for k, inner := range v {
+			flatten(schema, join(prefix, k), inner, out)
+		}
+	case []interface{}:
+		for i, inner := range v {
+			flatten(schema, join(prefix, strconv.Itoa(i)), inner, out)
+		}
+	default:
+		out[prefix] = v
+	}
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// convert maps the native Avro types to the types supported by metrics
+func convert(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UnixNano()
+	case time.Duration:
+		return v.Nanoseconds()
+	case *big.Rat:
+		f, _ := v.Float64()
+		return f
+	}
+	return value
+}
+
+// InitFromConfig is a compatibility function to construct the parser the old way
+func (p *Parser) InitFromConfig(config *parsers.Config) error {
+	p.DefaultMetricName = config.MetricName
+	p.DefaultTags = config.DefaultTags
+	p.SchemaRegistry = config.AvroSchemaRegistry
+	p.SchemaFile = config.AvroSchemaFile
+	p.Framing = config.AvroFraming
+	p.Measurement = config.AvroMeasurement
+	p.MeasurementField = config.AvroMeasurementField
+	p.Tags = config.AvroTags
+	p.Fields = config.AvroFields
+	p.Timestamp = config.AvroTimestamp
+	p.TimestampFormat = config.AvroTimestampFormat
+	p.TimestampTimezone = config.AvroTimestampTimezone
+	p.FieldSeparator = config.AvroFieldSeparator
+
+	return p.Init()
+}
+
+func init() {
+	parsers.Add("avro",
+		func(defaultMetricName string) telegraf.Parser {
+			return &Parser{DefaultMetricName: defaultMetricName}
+		},
+	)
+}
@@ -0,0 +1,239 @@
+package avro
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	commonavro "github.com/influxdata/telegraf/plugins/common/avro"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+var record = map[string]interface{}{
+	"name": "cpu",
+	"host": "server01",
+	"location": map[string]interface{}{
+		"region": "eu-west",
+		"rack":   goavro.Union("int", int32(4)),
+	},
+	"time":   int64(1656410400),
+	"usage":  goavro.Union("double", 42.5),
+	"status": "OK",
+	"cores":  []interface{}{int32(1), int32(2)},
+}
+
+func encode(t *testing.T, id int, rec map[string]interface{}) []byte {
+	schema, err := os.ReadFile("testdata/measurement.avsc")
+	require.NoError(t, err)
+	codec, err := goavro.NewCodec(string(schema))
+	require.NoError(t, err)
+
+	var buf []byte
+	if id >= 0 {
+		buf = commonavro.AppendHeader(buf, id)
+	}
+	buf, err = codec.BinaryFromNative(buf, rec)
+	require.NoError(t, err)
+	return buf
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		parser   *Parser
+		expected string
+	}{
+		{
+			name:     "no schema",
+			parser:   &Parser{},
+			expected: "either avro_schema_registry or avro_schema_file must be specified",
+		},
+		{
+			name:     "registry and file",
+			parser:   &Parser{SchemaRegistry: "http://localhost:8081", SchemaFile: "testdata/measurement.avsc"},
+			expected: "only one of avro_schema_registry and avro_schema_file can be specified",
+		},
+		{
+			name:     "registry without framing",
+			parser:   &Parser{SchemaRegistry: "http://localhost:8081", Framing: "none"},
+			expected: "using a schema registry requires the confluent framing",
+		},
+		{
+			name:     "invalid framing",
+			parser:   &Parser{SchemaFile: "testdata/measurement.avsc", Framing: "magic"},
+			expected: "invalid framing \"magic\"",
+		},
+		{
+			name:     "missing schema file",
+			parser:   &Parser{SchemaFile: "testdata/nonexistent.avsc"},
+			expected: "reading schema file failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.parser.Init(), tt.expected)
+		})
+	}
+}
+
+func TestParseSchemaFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		parser   *Parser
+		expected telegraf.Metric
+	}{
+		{
+			name: "defaults",
+			parser: &Parser{
+				SchemaFile: "testdata/measurement.avsc",
+				Framing:    "none",
+				Timestamp:  "time",
+			},
+			expected: metric.New(
+				"avro",
+				map[string]string{},
+				map[string]interface{}{
+					"name":            "cpu",
+					"host":            "server01",
+					"location_region": "eu-west",
+					"location_rack":   int64(4),
+					"usage":           42.5,
+					"status":          "OK",
+					"cores_0":         int64(1),
+					"cores_1":         int64(2),
+				},
+				time.Unix(1656410400, 0),
+			),
+		},
+		{
+			name: "measurement, tags and fields",
+			parser: &Parser{
+				SchemaFile:       "testdata/measurement.avsc",
+				Framing:          "none",
+				MeasurementField: "name",
+				Tags:             []string{"host", "location"},
+				Fields:           []string{"usage", "cores"},
+				Timestamp:        "time",
+				TimestampFormat:  "unix",
+				FieldSeparator:   ".",
+			},
+			expected: metric.New(
+				"cpu",
+				map[string]string{
+					"host":            "server01",
+					"location.region": "eu-west",
+					"location.rack":   "4",
+				},
+				map[string]interface{}{
+					"usage":   42.5,
+					"cores.0": int64(1),
+					"cores.1": int64(2),
+				},
+				time.Unix(1656410400, 0),
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.parser.DefaultMetricName = "avro"
+			tt.parser.Log = testutil.Logger{}
+			require.NoError(t, tt.parser.Init())
+
+			metrics, err := tt.parser.Parse(encode(t, -1, record))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, metrics)
+		})
+	}
+}
+
+func TestParseNullUnion(t *testing.T) {
+	parser := &Parser{
+		SchemaFile:        "testdata/measurement.avsc",
+		Framing:           "confluent",
+		MeasurementField:  "name",
+		Tags:              []string{"host"},
+		Fields:            []string{"usage", "location.rack"},
+		Timestamp:         "time",
+		DefaultMetricName: "avro",
+		Log:               testutil.Logger{},
+	}
+	require.NoError(t, parser.Init())
+
+	rec := map[string]interface{}{
+		"name":     "cpu",
+		"host":     "server01",
+		"location": map[string]interface{}{"region": "eu-west", "rack": nil},
+		"time":     int64(1656410400),
+		"usage":    nil,
+		"status":   "FAILED",
+		"cores":    []interface{}{},
+	}
+	metrics, err := parser.Parse(encode(t, 1, rec))
+	require.NoError(t, err)
+
+	expected := metric.New("cpu", map[string]string{"host": "server01"}, map[string]interface{}{}, time.Unix(1656410400, 0))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, metrics)
+}
+
+func TestParseSchemaRegistry(t *testing.T) {
+	schema, err := os.ReadFile("testdata/measurement.avsc")
+	require.NoError(t, err)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/schemas/ids/42" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{"schema": string(schema)}))
+	}))
+	defer server.Close()
+
+	parser := &Parser{
+		SchemaRegistry:    server.URL,
+		MeasurementField:  "name",
+		Tags:              []string{"host"},
+		Fields:            []string{"usage"},
+		Timestamp:         "time",
+		DefaultMetricName: "avro",
+		Log:               testutil.Logger{},
+	}
+	require.NoError(t, parser.Init())
+
+	expected := metric.New(
+		"cpu",
+		map[string]string{"host": "server01"},
+		map[string]interface{}{"usage": 42.5},
+		time.Unix(1656410400, 0),
+	)
+
+	// The schema must be fetched only once
+	for i := 0; i < 3; i++ {
+		metrics, err := parser.Parse(encode(t, 42, record))
+		require.NoError(t, err)
+		testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, metrics)
+	}
+	require.EqualValues(t, 1, atomic.LoadInt32(&requests))
+
+	// Unknown schemas
+	_, err = parser.Parse(encode(t, 7, record))
+	require.ErrorContains(t, err, "fetching schema 7 failed")
+
+	// Messages without header
+	_, err = parser.Parse([]byte{0x01, 0x02})
+	require.ErrorContains(t, err, "message too short")
+	_, err = parser.Parse(encode(t, -1, record))
+	require.ErrorContains(t, err, "invalid magic byte")
+}
@@ -0,0 +1,349 @@
+package binary
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	binaryconfig "github.com/influxdata/telegraf/plugins/parsers/temporary/binary"
+)
+
+// Size of the types in bytes, strings use the length of the entry
+var typeSizes = map[string]int{
+	"bool":    1,
+	"int8":    1,
+	"int16":   2,
+	"int32":   4,
+	"int64":   8,
+	"uint8":   1,
+	"uint16":  2,
+	"uint32":  4,
+	"uint64":  8,
+	"float32": 4,
+	"float64": 8,
+	"string":  0,
+}
+
+type Parser struct {
+	Endianness        string                `toml:"binary_endianness"`
+	AllowNoMatch      bool                  `toml:"binary_allow_no_match"`
+	Layouts           []binaryconfig.Config `toml:"binary"`
+	DefaultMetricName string                `toml:"-"`
+	DefaultTags       map[string]string     `toml:"-"`
+	Log               telegraf.Logger       `toml:"-"`
+
+	layouts []*layout
+}
+
+type layout struct {
+	binaryconfig.Config
+	order  binary.ByteOrder
+	filter []byte
+	size   int
+}
+
+func (p *Parser) Init() error {
+	order, err := byteOrder(p.Endianness, binary.BigEndian)
+	if err != nil {
+		return err
+	}
+
+	if len(p.Layouts) == 0 {
+		return errors.New("no layout defined")
+	}
+
+	p.layouts = make([]*layout, 0, len(p.Layouts))
+	for i, cfg := range p.Layouts {
+		l, err := p.compile(cfg, order)
+		if err != nil {
+			return fmt.Errorf("layout %d: %w", i+1, err)
+		}
+		p.layouts = append(p.layouts, l)
+	}
+
+	return nil
+}
+
+func (p *Parser) compile(cfg binaryconfig.Config, defaultOrder binary.ByteOrder) (*layout, error) {
+	l := &layout{Config: cfg}
+
+	var err error
+	if l.order, err = byteOrder(cfg.Endianness, defaultOrder); err != nil {
+		return nil, err
+	}
+	if l.MetricName == "" {
+		l.MetricName = p.DefaultMetricName
+	}
+	if l.TimestampFormat == "" {
+		l.TimestampFormat = "unix"
+	}
+
+	if f := cfg.Filter; f != nil {
+		if f.Length < 0 || f.Offset < 0 {
+			return nil, errors.New("filter length and offset must not be negative")
+		}
+		if f.Value != "" {
+			value := strings.TrimPrefix(strings.ToLower(f.Value), "0x")
+			if l.filter, err = hex.DecodeString(value); err != nil {
+				return nil, fmt.Errorf("decoding filter value %q failed: %w", f.Value, err)
+			}
+		}
+	}
+
+	if len(cfg.Entries) == 0 {
+		return nil, errors.New("no entries defined")
+	}
+	for i, e := range cfg.Entries {
+		size, err := checkEntry(e)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d (%q): %w", i+1, e.Name, err)
+		}
+		if e.Offset+size > l.size {
+			l.size = e.Offset + size
+		}
+	}
+
+	return l, nil
+}
+
+// checkEntry validates the entry and returns its size in bytes
+func checkEntry(e binaryconfig.Entry) (int, error) {
+	switch e.Assignment {
+	case "", "field", "tag":
+		if e.Name == "" {
+			return 0, errors.New("missing name")
+		}
+	case "measurement", "time":
+	default:
+		return 0, fmt.Errorf("invalid assignment %q", e.Assignment)
+	}
+
+	size, found := typeSizes[e.Type]
+	if !found {
+		return 0, fmt.Errorf("invalid type %q", e.Type)
+	}
+	if e.Offset < 0 {
+		return 0, errors.New("offset must not be negative")
+	}
+
+	switch e.Type {
+	case "string":
+		if e.Length <= 0 {
+			return 0, errors.New("strings require a length")
+		}
+		size = e.Length
+	case "float32", "float64":
+		if e.Bits != 0 {
+			return 0, errors.New("bits are not supported for floating-point types")
+		}
+	}
+
+	if e.Bits < 0 || e.BitOffset < 0 || e.Bits+e.BitOffset > 8*size {
+		return 0, fmt.Errorf("bits and bit offset exceed the %d bits of the type", 8*size)
+	}
+	if e.Bits == 0 && e.BitOffset != 0 {
+		return 0, errors.New("bit offset requires bits to be set")
+	}
+	if e.Scale != 0 && (e.Type == "string" || e.Type == "bool") {
+		return 0, fmt.Errorf("scaling not supported for type %q", e.Type)
+	}
+
+	return size, nil
+}
+
+func byteOrder(endianness string, fallback binary.ByteOrder) (binary.ByteOrder, error) {
+	switch endianness {
+	case "":
+		return fallback, nil
+	case "be":
+		return binary.BigEndian, nil
+	case "le":
+		return binary.LittleEndian, nil
+	}
+	return nil, fmt.Errorf("invalid endianness %q", endianness)
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0, 1)
+	for _, l := range p.layouts {
+		if !l.matches(buf) {
+			continue
+		}
+		if len(buf) < l.size {
+			return nil, fmt.Errorf("message of %d bytes too short for layout %q requiring %d bytes", len(buf), l.MetricName, l.size)
+		}
+
+		m, err := p.createMetric(l, buf)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+
+	if len(metrics) == 0 && !p.AllowNoMatch {
+		return nil, errors.New("no layout matching the message")
+	}
+
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, errors.New("no metric in line")
+	}
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (l *layout) matches(buf []byte) bool {
+	if l.Filter == nil {
+		return true
+	}
+	if l.Filter.Length > 0 && len(buf) != l.Filter.Length {
+		return false
+	}
+	if len(l.filter) > 0 {
+		end := l.Filter.Offset + len(l.filter)
+		if end > len(buf) || !bytes.Equal(buf[l.Filter.Offset:end], l.filter) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Parser) createMetric(l *layout, buf []byte) (telegraf.Metric, error) {
+	name := l.MetricName
+	timestamp := time.Now()
+	tags := make(map[string]string, len(p.DefaultTags))
+	fields := make(map[string]interface{}, len(l.Entries))
+
+	for _, e := range l.Entries {
+		value := l.decode(e, buf)
+		switch e.Assignment {
+		case "measurement":
+			name = fmt.Sprintf("%v", value)
+		case "time":
+			ts, err := internal.ParseTimestamp(l.TimestampFormat, value, l.TimestampTimezone)
+			if err != nil {
+				return nil, fmt.Errorf("parsing timestamp failed: %w", err)
+			}
+			timestamp = ts
+		case "tag":
+			tags[e.Name] = fmt.Sprintf("%v", value)
+		default:
+			fields[e.Name] = value
+		}
+	}
+
+	for k, v := range p.DefaultTags {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
+
+	return metric.New(name, tags, fields, timestamp), nil
+}
+
+// decode extracts the value of the entry from the message. The message must
+// contain at least the number of bytes required by the layout.
+func (l *layout) decode(e binaryconfig.Entry, buf []byte) interface{} {
+	size := typeSizes[e.Type]
+	if e.Type == "string" {
+		size = e.Length
+	}
+	data := buf[e.Offset : e.Offset+size]
+
+	switch e.Type {
+	case "string":
+		return string(bytes.TrimRight(data, "\x00"))
+	case "float32":
+		return scale(float64(math.Float32frombits(l.order.Uint32(data))), e.Scale)
+	case "float64":
+		return scale(math.Float64frombits(l.order.Uint64(data)), e.Scale)
+	}
+
+	// Integer and boolean types
+	var raw uint64
+	switch size {
+	case 1:
+		raw = uint64(data[0])
+	case 2:
+		raw = uint64(l.order.Uint16(data))
+	case 4:
+		raw = uint64(l.order.Uint32(data))
+	case 8:
+		raw = l.order.Uint64(data)
+	}
+
+	width := 8 * size
+	if e.Bits > 0 {
+		raw = (raw >> e.BitOffset) & (1<<e.Bits - 1)
+		width = e.Bits
+	}
+
+	switch e.Type {
+	case "bool":
+		return raw != 0
+	case "int8", "int16", "int32", "int64":
+		// Extend the sign of the (possibly truncated) value
+		shift := 64 - width
+		v := int64(raw<<shift) >> shift
+		if e.Scale != 0 {
+			return float64(v) * e.Scale
+		}
+		return v
+	}
+
+	if e.Scale != 0 {
+		return float64(raw) * e.Scale
+	}
+	return raw
+}
+
+func scale(v, factor float64) float64 {
+	if factor == 0 {
+		return v
+	}
+	return v * factor
+}
+
+// InitFromConfig is a compatibility function to construct the parser the old way
+func (p *Parser) InitFromConfig(config *parsers.Config) error {
+	p.DefaultMetricName = config.MetricName
+	p.DefaultTags = config.DefaultTags
+	p.Endianness = config.BinaryEndianness
+	p.AllowNoMatch = config.BinaryAllowNoMatch
+
+	// Convert the config formats which is a one-to-one copy
+	if len(config.BinaryConfig) > 0 {
+		p.Layouts = make([]binaryconfig.Config, 0, len(config.BinaryConfig))
+		p.Layouts = append(p.Layouts, config.BinaryConfig...)
+	}
+
+	return p.Init()
+}
+
+func init() {
+	parsers.Add("binary",
+		func(defaultMetricName string) telegraf.Parser {
+			return &Parser{DefaultMetricName: defaultMetricName}
+		},
+	)
+}
@@ -0,0 +1,276 @@
+package binary
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/inputs/file"
+	binaryconfig "github.com/influxdata/telegraf/plugins/parsers/temporary/binary"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// Layout of testdata/message.bin in big-endian byte order
+var sensorLayout = binaryconfig.Config{
+	MetricName: "sensor",
+	Filter:     &binaryconfig.Filter{Value: "0x01"},
+	Entries: []binaryconfig.Entry{
+		{Name: "device", Type: "uint16", Offset: 1, Assignment: "tag"},
+		{Name: "temperature", Type: "int16", Offset: 3, Scale: 0.01},
+		{Name: "alarm", Type: "bool", Offset: 5, Bits: 1},
+		{Name: "mode", Type: "uint8", Offset: 5, Bits: 3, BitOffset: 1},
+		{Type: "uint32", Offset: 6, Assignment: "time"},
+		{Name: "location", Type: "string", Offset: 10, Length: 6},
+	},
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		parser   *Parser
+		expected string
+	}{
+		{
+			name:     "no layout",
+			parser:   &Parser{},
+			expected: "no layout defined",
+		},
+		{
+			name: "invalid endianness",
+			parser: &Parser{
+				Endianness: "middle",
+				Layouts:    []binaryconfig.Config{sensorLayout},
+			},
+			expected: `invalid endianness "middle"`,
+		},
+		{
+			name: "no entries",
+			parser: &Parser{
+				Layouts: []binaryconfig.Config{{MetricName: "empty"}},
+			},
+			expected: "layout 1: no entries defined",
+		},
+		{
+			name: "invalid filter",
+			parser: &Parser{
+				Layouts: []binaryconfig.Config{{
+					Filter:  &binaryconfig.Filter{Value: "0xZZ"},
+					Entries: []binaryconfig.Entry{{Name: "a", Type: "uint8"}},
+				}},
+			},
+			expected: `decoding filter value "0xZZ" failed`,
+		},
+		{
+			name: "invalid type",
+			parser: &Parser{
+				Layouts: []binaryconfig.Config{{
+					Entries: []binaryconfig.Entry{{Name: "a", Type: "int128"}},
+				}},
+			},
+			expected: `entry 1 ("a"): invalid type "int128"`,
+		},
+		{
+			name: "string without length",
+			parser: &Parser{
+				Layouts: []binaryconfig.Config{{
+					Entries: []binaryconfig.Entry{{Name: "a", Type: "string"}},
+				}},
+			},
+			expected: "strings require a length",
+		},
+		{
+			name: "bits exceeding type",
+			parser: &Parser{
+				Layouts: []binaryconfig.Config{{
+					Entries: []binaryconfig.Entry{{Name: "a", Type: "uint8", Bits: 4, BitOffset: 6}},
+				}},
+			},
+			expected: "bits and bit offset exceed the 8 bits of the type",
+		},
+		{
+			name: "missing name",
+			parser: &Parser{
+				Layouts: []binaryconfig.Config{{
+					Entries: []binaryconfig.Entry{{Type: "uint8", Assignment: "tag"}},
+				}},
+			},
+			expected: "missing name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.parser.Init(), tt.expected)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	message, err := hex.DecodeString("010201fb2e0b62bad12073656e734100")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		parser   *Parser
+		input    []byte
+		expected []telegraf.Metric
+	}{
+		{
+			name:   "big-endian with bitfields",
+			parser: &Parser{Layouts: []binaryconfig.Config{sensorLayout}},
+			input:  message,
+			expected: []telegraf.Metric{
+				metric.New(
+					"sensor",
+					map[string]string{"device": "513"},
+					map[string]interface{}{
+						"temperature": -12.34,
+						"alarm":       true,
+						"mode":        uint64(5),
+						"location":    "sensA",
+					},
+					time.Unix(1656410400, 0),
+				),
+			},
+		},
+		{
+			name: "little-endian with measurement",
+			parser: &Parser{
+				Endianness: "le",
+				Layouts: []binaryconfig.Config{{
+					Entries: []binaryconfig.Entry{
+						{Type: "string", Length: 3, Assignment: "measurement"},
+						{Name: "value", Type: "float32", Offset: 3},
+						{Name: "counter", Type: "int32", Offset: 7},
+						{Name: "flags", Type: "int8", Offset: 11, Bits: 4},
+					},
+				}},
+			},
+			input: []byte{'m', 'e', 'm', 0x00, 0x00, 0x28, 0x42, 0xfe, 0xff, 0xff, 0xff, 0x0e},
+			expected: []telegraf.Metric{
+				metric.New(
+					"mem",
+					map[string]string{},
+					map[string]interface{}{
+						"value":   float64(42),
+						"counter": int64(-2),
+						"flags":   int64(-2),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "multiple matching layouts",
+			parser: &Parser{
+				Layouts: []binaryconfig.Config{
+					{
+						MetricName: "short",
+						Filter:     &binaryconfig.Filter{Length: 4},
+						Entries:    []binaryconfig.Entry{{Name: "value", Type: "uint16", Offset: 2}},
+					},
+					{
+						MetricName: "long",
+						Filter:     &binaryconfig.Filter{Length: 8},
+						Entries:    []binaryconfig.Entry{{Name: "value", Type: "uint16", Offset: 2}},
+					},
+					{
+						MetricName: "header",
+						Filter:     &binaryconfig.Filter{Offset: 1, Value: "abcd"},
+						Entries:    []binaryconfig.Entry{{Name: "value", Type: "uint8", Offset: 3, Scale: 0.5}},
+					},
+				},
+			},
+			input: []byte{0x00, 0xab, 0xcd, 0x03},
+			expected: []telegraf.Metric{
+				metric.New("short", map[string]string{}, map[string]interface{}{"value": uint64(0xcd03)}, time.Unix(0, 0)),
+				metric.New("header", map[string]string{}, map[string]interface{}{"value": 1.5}, time.Unix(0, 0)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.parser.DefaultMetricName = "binary"
+			require.NoError(t, tt.parser.Init())
+
+			actual, err := tt.parser.Parse(tt.input)
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestParseNoMatch(t *testing.T) {
+	parser := &Parser{Layouts: []binaryconfig.Config{sensorLayout}}
+	require.NoError(t, parser.Init())
+
+	_, err := parser.Parse([]byte{0x02, 0x00})
+	require.EqualError(t, err, "no layout matching the message")
+
+	_, err = parser.Parse([]byte{0x01, 0x00})
+	require.EqualError(t, err, `message of 2 bytes too short for layout "sensor" requiring 16 bytes`)
+
+	parser.AllowNoMatch = true
+	metrics, err := parser.Parse([]byte{0x02, 0x00})
+	require.NoError(t, err)
+	require.Empty(t, metrics)
+}
+
+func TestConfig(t *testing.T) {
+	conf := `
+[[inputs.file]]
+  files = ["testdata/message.bin"]
+  data_format = "binary"
+  binary_endianness = "be"
+
+  [[inputs.file.binary]]
+    metric_name = "sensor"
+    [inputs.file.binary.filter]
+      offset = 0
+      value = "0x01"
+
+    [[inputs.file.binary.entries]]
+      name = "device"
+      type = "uint16"
+      offset = 1
+      assignment = "tag"
+
+    [[inputs.file.binary.entries]]
+      name = "temperature"
+      type = "int16"
+      offset = 3
+      scale = 0.01
+
+    [[inputs.file.binary.entries]]
+      type = "uint32"
+      offset = 6
+      assignment = "time"
+`
+	inputs.Add("file", func() telegraf.Input {
+		return &file.File{}
+	})
+	cfg := config.NewConfig()
+	require.NoError(t, cfg.LoadConfigData([]byte(conf)))
+	require.Len(t, cfg.Inputs, 1)
+
+	var acc testutil.Accumulator
+	require.NoError(t, cfg.Inputs[0].Init())
+	require.NoError(t, cfg.Inputs[0].Gather(&acc))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"sensor",
+			map[string]string{"device": "513"},
+			map[string]interface{}{"temperature": -12.34},
+			time.Unix(1656410400, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
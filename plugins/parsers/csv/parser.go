@@ -3,7 +3,9 @@ package csv
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
@@ -57,6 +59,11 @@ type Parser struct {
 	remainingSkipRows     int
 	remainingHeaderRows   int
 	remainingMetadataRows int
+
+	// Preamble rows expected by the last call to Parse
+	parsedSkipRows     int
+	parsedHeaderRows   int
+	parsedMetadataRows int
 }
 
 type metadataPattern []string
@@ -195,11 +202,84 @@ func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	if p.ResetMode == "always" {
 		p.Reset()
 	}
+	p.parsedSkipRows = p.remainingSkipRows
+	p.parsedHeaderRows = p.remainingHeaderRows
+	p.parsedMetadataRows = p.remainingMetadataRows
 
 	r := bytes.NewReader(buf)
 	return parseCSV(p, r)
 }
 
+// SplitRecords splits the data of the last call to Parse into its rows. In
+// "always" reset mode, each row is preceded by the skipped, metadata and
+// header rows of the data, as the parser expects those for every call.
+// Otherwise, the preamble was already consumed and the rows are returned on
+// their own.
+func (p *Parser) SplitRecords(buf []byte) [][]byte {
+	// Skipped and metadata rows are read line by line
+	var offset int
+	for i := 0; i < p.parsedSkipRows+p.parsedMetadataRows && offset < len(buf); i++ {
+		end := bytes.IndexByte(buf[offset:], '\n')
+		if end < 0 {
+			return nil
+		}
+		offset += end + 1
+	}
+
+	var rows [][]byte
+	headerRows := p.parsedHeaderRows
+	for _, row := range splitRows(buf[offset:]) {
+		if len(bytes.TrimSpace(row)) == 0 || (p.Comment != "" && bytes.HasPrefix(row, []byte(p.Comment))) {
+			if headerRows > 0 {
+				offset += len(row)
+			}
+			continue
+		}
+		if headerRows > 0 {
+			headerRows--
+			offset += len(row)
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if p.ResetMode != "always" {
+		return rows
+	}
+
+	preamble := buf[:offset]
+	records := make([][]byte, 0, len(rows))
+	for _, row := range rows {
+		record := make([]byte, 0, len(preamble)+len(row))
+		record = append(record, preamble...)
+		records = append(records, append(record, row...))
+	}
+	return records
+}
+
+// splitRows splits the data into rows including the line terminator. Line
+// breaks within quoted values do not end a row.
+func splitRows(buf []byte) [][]byte {
+	var rows [][]byte
+	var quoted bool
+	var start int
+	for i, c := range buf {
+		switch c {
+		case '"':
+			quoted = !quoted
+		case '\n':
+			if !quoted {
+				rows = append(rows, buf[start:i+1])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(buf) {
+		rows = append(rows, buf[start:])
+	}
+	return rows
+}
+
 func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	if len(line) == 0 {
 		if p.remainingSkipRows > 0 {
@@ -225,7 +305,79 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	return nil, nil
 }
 
+// ParseStream parses the CSV data of the given reader record by record without
+// loading the complete data into memory.
+func (p *Parser) ParseStream(ctx context.Context, r io.Reader, fn func(telegraf.Metric) error) error {
+	// Reset the parser according to the specified mode
+	if p.ResetMode == "always" {
+		p.Reset()
+	}
+
+	csvReader, err := p.readPreamble(r)
+	if err != nil {
+		// Data ending within the preamble does not contain any records
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		m, err := p.parseRecord(record)
+		if err != nil {
+			if p.SkipErrors {
+				p.Log.Debugf("Parsing error: %v", err)
+				continue
+			}
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+}
+
 func parseCSV(p *Parser, r io.Reader) ([]telegraf.Metric, error) {
+	csvReader, err := p.readPreamble(r)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, record := range table {
+		m, err := p.parseRecord(record)
+		if err != nil {
+			if p.SkipErrors {
+				p.Log.Debugf("Parsing error: %v", err)
+				continue
+			}
+			return metrics, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// readPreamble consumes the rows to skip as well as the metadata and header
+// rows and returns a reader for the remaining records.
+func (p *Parser) readPreamble(r io.Reader) (*csv.Reader, error) {
 	lineReader := bufio.NewReader(r)
 	// skip first rows
 	for p.remainingSkipRows > 0 {
@@ -282,24 +434,7 @@ func parseCSV(p *Parser, r io.Reader) ([]telegraf.Metric, error) {
 		p.gotColumnNames = true
 	}
 
-	table, err := csvReader.ReadAll()
-	if err != nil {
-		return nil, err
-	}
-
-	metrics := make([]telegraf.Metric, 0)
-	for _, record := range table {
-		m, err := p.parseRecord(record)
-		if err != nil {
-			if p.SkipErrors {
-				p.Log.Debugf("Parsing error: %v", err)
-				continue
-			}
-			return metrics, err
-		}
-		metrics = append(metrics, m)
-	}
-	return metrics, nil
+	return csvReader, nil
 }
 
 func (p *Parser) parseRecord(record []string) (telegraf.Metric, error) {
@@ -1,8 +1,11 @@
 package csv
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -1366,6 +1369,74 @@ timestamp,category,id,flag
 	testutil.RequireMetricsEqual(t, expected, metrics)
 }
 
+func TestSplitRecords(t *testing.T) {
+	input := `skipped row
+name,value
+a,1
+b,"x
+y"
+
+c,3
+`
+	tests := []struct {
+		resetMode string
+		expected  []string
+	}{
+		{
+			resetMode: "none",
+			expected:  []string{"a,1\n", "b,\"x\ny\"\n", "c,3\n"},
+		},
+		{
+			resetMode: "always",
+			expected: []string{
+				"skipped row\nname,value\na,1\n",
+				"skipped row\nname,value\nb,\"x\ny\"\n",
+				"skipped row\nname,value\nc,3\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resetMode, func(t *testing.T) {
+			p := &Parser{
+				MetricName:     "csv",
+				HeaderRowCount: 1,
+				SkipRows:       1,
+				ColumnTypes:    []string{"string", "int"},
+				ResetMode:      tt.resetMode,
+				TimeFunc:       DefaultTime,
+			}
+			require.NoError(t, p.Init())
+
+			_, err := p.Parse([]byte(input))
+			require.Error(t, err)
+
+			records := p.SplitRecords([]byte(input))
+			actual := make([]string, 0, len(records))
+			for _, record := range records {
+				actual = append(actual, string(record))
+			}
+			require.Equal(t, tt.expected, actual)
+
+			// All records but the invalid one can be parsed on their own
+			var metrics []telegraf.Metric
+			for i, record := range records {
+				m, err := p.Parse(record)
+				if i == 1 {
+					require.Error(t, err)
+					continue
+				}
+				require.NoError(t, err)
+				metrics = append(metrics, m...)
+			}
+			expected := []telegraf.Metric{
+				metric.New("csv", map[string]string{}, map[string]interface{}{"name": "a", "value": int64(1)}, DefaultTime()),
+				metric.New("csv", map[string]string{}, map[string]interface{}{"name": "c", "value": int64(3)}, DefaultTime()),
+			}
+			testutil.RequireMetricsEqual(t, expected, metrics)
+		})
+	}
+}
+
 func TestParseCSVLinewiseResetModeAlways(t *testing.T) {
 	testCSV := []string{
 		"garbage nonsense that needs be skipped",
@@ -1467,3 +1538,76 @@ func TestParseCSVLinewiseResetModeAlways(t *testing.T) {
 	_, err = p.ParseLine(testCSV[0])
 	require.Error(t, err, `parsing time "garbage nonsense that needs be skipped" as "2006-01-02T15:04:05Z07:00": cannot parse "garbage nonsense that needs be skipped" as "2006"`)
 }
+
+func TestParseStreamReader(t *testing.T) {
+	p := &Parser{
+		HeaderRowCount:     1,
+		SkipRows:           1,
+		MetadataRows:       1,
+		Comment:            "#",
+		TagColumns:         []string{"type"},
+		MetadataSeparators: []string{"="},
+		MetadataTrimSet:    " #",
+		TimestampColumn:    "timestamp",
+		TimestampFormat:    "2006-01-02T15:04:05Z07:00",
+		SkipErrors:         true,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, p.Init())
+	p.SetDefaultTags(map[string]string{"test": "tag"})
+
+	input := `garbage nonsense that needs be skipped
+# version= 1.0
+timestamp,type,name,status
+2020-11-23T08:19:27+10:00,Reader,R002,1
+#2020-11-04T13:23:04+10:00,Reader,R031,0
+invalid,Reader,R031,0
+2020-11-04T13:29:47+10:00,Coordinator,C001,0`
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"",
+			map[string]string{"test": "tag", "type": "Reader", "version": "1.0"},
+			map[string]interface{}{"name": "R002", "status": int64(1)},
+			time.Date(2020, 11, 22, 22, 19, 27, 0, time.UTC),
+		),
+		metric.New(
+			"",
+			map[string]string{"test": "tag", "type": "Coordinator", "version": "1.0"},
+			map[string]interface{}{"name": "C001", "status": int64(0)},
+			time.Date(2020, 11, 4, 3, 29, 47, 0, time.UTC),
+		),
+	}
+
+	var actual []telegraf.Metric
+	err := p.ParseStream(context.Background(), strings.NewReader(input), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestParseStreamReaderStop(t *testing.T) {
+	p := &Parser{
+		MetricName:     "csv",
+		HeaderRowCount: 1,
+		TimeFunc:       DefaultTime,
+	}
+	require.NoError(t, p.Init())
+
+	// Data ending in the header does not produce an error
+	err := p.ParseStream(context.Background(), strings.NewReader(""), func(telegraf.Metric) error {
+		return errors.New("unexpected metric")
+	})
+	require.NoError(t, err)
+
+	stop := errors.New("stop")
+	var count int
+	err = p.ParseStream(context.Background(), strings.NewReader("a,b\n1,2\n3,4\n5,6"), func(telegraf.Metric) error {
+		count++
+		return stop
+	})
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, 1, count)
+}
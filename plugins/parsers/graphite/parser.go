@@ -192,6 +192,11 @@ func (p *Parser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
 
+// SplitRecords splits the data into lines which can be parsed independently
+func (p *Parser) SplitRecords(buf []byte) [][]byte {
+	return parsers.SplitLines(buf)
+}
+
 func init() {
 	parsers.Add("graphite", func(_ string) telegraf.Parser { return &Parser{} })
 }
@@ -148,6 +148,11 @@ func (p *Parser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
 
+// SplitRecords splits the data into lines which can be parsed independently
+func (p *Parser) SplitRecords(buf []byte) [][]byte {
+	return parsers.SplitLines(buf)
+}
+
 func (p *Parser) SetTimePrecision(u time.Duration) {
 	switch u {
 	case time.Nanosecond:
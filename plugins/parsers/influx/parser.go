@@ -1,6 +1,7 @@
 package influx
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -129,10 +130,64 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	return metrics[0], nil
 }
 
+// ParseStream parses line protocol from the given reader without loading the
+// complete data into memory. Parsing stops at the first invalid line.
+func (p *Parser) ParseStream(ctx context.Context, r io.Reader, fn func(telegraf.Metric) error) error {
+	// The stream machine cannot parse series, so fall back to reading the
+	// whole data in this case.
+	if p.Type == "series" {
+		buf, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		metrics, err := p.Parse(buf)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	sp := NewStreamParser(r)
+	sp.SetTimeFunc(p.handler.timeFunc)
+	sp.SetTimePrecision(p.handler.timePrecision)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		m, err := sp.Next()
+		if err == EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			continue
+		}
+
+		p.applyDefaultTagsSingle(m)
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+}
+
 func (p *Parser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
 
+// SplitRecords splits the data into lines which can be parsed independently
+func (p *Parser) SplitRecords(buf []byte) [][]byte {
+	return parsers.SplitLines(buf)
+}
+
 func (p *Parser) applyDefaultTags(metrics []telegraf.Metric) {
 	if len(p.DefaultTags) == 0 {
 		return
@@ -2,6 +2,7 @@ package influx
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"strconv"
@@ -864,3 +865,67 @@ func TestStreamParserProducesAllAvailableMetrics(t *testing.T) {
 	_, err = parser.Next()
 	require.NoError(t, err)
 }
+
+func TestParseStream(t *testing.T) {
+	for _, tt := range ptests {
+		if tt.err != nil {
+			continue
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			parser := Parser{DefaultTags: map[string]string{"source": "stream"}}
+			require.NoError(t, parser.Init())
+			parser.SetTimeFunc(DefaultTime)
+			if tt.timeFunc != nil {
+				parser.SetTimeFunc(tt.timeFunc)
+			}
+
+			var actual []telegraf.Metric
+			err := parser.ParseStream(context.Background(), bytes.NewReader(tt.input), func(m telegraf.Metric) error {
+				actual = append(actual, m)
+				return nil
+			})
+			require.NoError(t, err)
+
+			expected := make([]telegraf.Metric, 0, len(tt.metrics))
+			for _, m := range tt.metrics {
+				e := m.Copy()
+				if !e.HasTag("source") {
+					e.AddTag("source", "stream")
+				}
+				expected = append(expected, e)
+			}
+			testutil.RequireMetricsEqual(t, expected, actual)
+		})
+	}
+}
+
+func TestParseStreamStop(t *testing.T) {
+	input := "cpu value=1 1\ncpu value=2 2\ncpu value=3 3\n"
+
+	parser := Parser{}
+	require.NoError(t, parser.Init())
+
+	// Errors of the callback must stop parsing and be returned
+	stop := errors.New("stop")
+	var count int
+	err := parser.ParseStream(context.Background(), strings.NewReader(input), func(telegraf.Metric) error {
+		count++
+		if count == 2 {
+			return stop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, 2, count)
+
+	// Cancelling the context must stop parsing
+	ctx, cancel := context.WithCancel(context.Background())
+	count = 0
+	err = parser.ParseStream(ctx, strings.NewReader(input), func(telegraf.Metric) error {
+		count++
+		cancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1, count)
+}
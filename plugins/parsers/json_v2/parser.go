@@ -1,7 +1,11 @@
 package json_v2
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
@@ -142,6 +146,60 @@ func (p *Parser) Parse(input []byte) ([]telegraf.Metric, error) {
 	return metrics, nil
 }
 
+// ParseStream parses a stream of JSON documents such as newline-delimited JSON.
+// Each document is parsed on its own so only a single document is held in
+// memory at a time.
+func (p *Parser) ParseStream(ctx context.Context, r io.Reader, fn func(telegraf.Metric) error) error {
+	decoder := json.NewDecoder(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var document json.RawMessage
+		if err := decoder.Decode(&document); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("invalid JSON provided, unable to parse: %w", err)
+		}
+
+		metrics, err := p.Parse(document)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// SplitRecords splits newline-delimited JSON into its documents. Data is only
+// considered to be newline-delimited JSON if all lines start with an object or
+// array without indentation and at least one line is a complete document, so a
+// single document spanning multiple lines, e.g. pretty-printed JSON, is kept as
+// one record.
+func (p *Parser) SplitRecords(buf []byte) [][]byte {
+	lines := parsers.SplitLines(buf)
+	if len(lines) < 2 {
+		return [][]byte{buf}
+	}
+
+	var complete bool
+	for _, line := range lines {
+		if line[0] != '{' && line[0] != '[' {
+			return [][]byte{buf}
+		}
+		complete = complete || gjson.ValidBytes(bytes.TrimSpace(line))
+	}
+	if !complete {
+		return [][]byte{buf}
+	}
+	return lines
+}
+
 // processMetric will iterate over all 'field' or 'tag' configs and create metrics for each
 // A field/tag can either be a single value or an array of values, each resulting in its own metric
 // For multiple configs, a set of metrics is created from the cartesian product of each separate config
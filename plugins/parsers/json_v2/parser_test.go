@@ -2,6 +2,7 @@ package json_v2_test
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -11,9 +12,12 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/file"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/plugins/parsers/json_v2"
+	json_v2config "github.com/influxdata/telegraf/plugins/parsers/temporary/json_v2"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -86,6 +90,76 @@ func TestMultipleConfigs(t *testing.T) {
 	}
 }
 
+func TestParseStream(t *testing.T) {
+	parser := &json_v2.Parser{
+		Configs: []json_v2config.Config{
+			{
+				MeasurementName: "sensor",
+				TimestampPath:   "time",
+				TimestampFormat: "unix",
+				Tags:            []json_v2config.DataSet{{Path: "name"}},
+				Fields:          []json_v2config.DataSet{{Path: "value", Type: "float"}},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, parser.Init())
+
+	input := `{"name": "a", "value": 1, "time": 1656410400}
+{"name": "b", "value": 2.5, "time": 1656410401}
+
+{"name": "c",
+ "value": 3, "time": 1656410402}`
+
+	expected := []telegraf.Metric{
+		metric.New("sensor", map[string]string{"name": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(1656410400, 0)),
+		metric.New("sensor", map[string]string{"name": "b"}, map[string]interface{}{"value": 2.5}, time.Unix(1656410401, 0)),
+		metric.New("sensor", map[string]string{"name": "c"}, map[string]interface{}{"value": 3.0}, time.Unix(1656410402, 0)),
+	}
+
+	var actual []telegraf.Metric
+	err := parser.ParseStream(context.Background(), strings.NewReader(input), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// Invalid documents must stop parsing after the preceding documents
+	actual = nil
+	err = parser.ParseStream(context.Background(), strings.NewReader(`{"name": "a", "value": 1, "time": 1656410400} {"name":`), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.ErrorContains(t, err, "invalid JSON provided")
+	require.Len(t, actual, 1)
+}
+
+func TestSplitRecords(t *testing.T) {
+	parser := &json_v2.Parser{}
+
+	// Newline-delimited JSON is split into its documents
+	input := `{"name": "a", "value": 1}
+{"name": "b", "value":
+
+{"name": "c", "value": 3}`
+	expected := [][]byte{
+		[]byte(`{"name": "a", "value": 1}`),
+		[]byte(`{"name": "b", "value":`),
+		[]byte(`{"name": "c", "value": 3}`),
+	}
+	require.Equal(t, expected, parser.SplitRecords([]byte(input)))
+
+	// Documents spanning multiple lines are kept as is
+	for _, input := range []string{
+		`{"name": "a", "value": 1}`,
+		"{\n  \"name\": \"a\",\n  \"value\": \n}",
+		"[\n  {\"name\": \"a\"},\n  {\"name\": \"b\"}\n",
+	} {
+		require.Equal(t, [][]byte{[]byte(input)}, parser.SplitRecords([]byte(input)))
+	}
+}
+
 func readMetricFile(t *testing.T, path string) ([]telegraf.Metric, error) {
 	var metrics []telegraf.Metric
 	expectedFile, err := os.Open(path)
@@ -90,6 +90,11 @@ func (p *Parser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
 
+// SplitRecords splits the data into lines which can be parsed independently
+func (p *Parser) SplitRecords(buf []byte) [][]byte {
+	return parsers.SplitLines(buf)
+}
+
 func (p *Parser) applyDefaultTags(metrics []telegraf.Metric) {
 	if len(p.DefaultTags) == 0 {
 		return
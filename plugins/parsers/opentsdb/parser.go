@@ -0,0 +1,248 @@
+package opentsdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Largest timestamp interpreted as seconds, OpenTSDB expects timestamps with
+// at most 10 digits for seconds and 13 digits for milliseconds.
+const maxSeconds = 9999999999
+
+// Parser handles data points in the OpenTSDB telnet "put" syntax as well as
+// the JSON body of the /api/put HTTP endpoint. Each data point results in a
+// metric named after the OpenTSDB metric with the "value" field.
+type Parser struct {
+	DefaultTags map[string]string `toml:"-"`
+	Log         telegraf.Logger   `toml:"-"`
+}
+
+// dataPoint is a single element of the /api/put JSON body
+type dataPoint struct {
+	Metric    string            `json:"metric"`
+	Timestamp json.Number       `json:"timestamp"`
+	Value     json.Number       `json:"value"`
+	Tags      map[string]string `json:"tags"`
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	if isJSON(buf) {
+		return p.parseJSON(bytes.TrimSpace(buf))
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		m, err := p.parseTelnet(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		metrics = append(metrics, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, errors.New("no metric in line")
+	}
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// SplitRecords splits telnet data into lines which can be parsed
+// independently, while JSON data is a single record
+func (p *Parser) SplitRecords(buf []byte) [][]byte {
+	if isJSON(buf) {
+		return [][]byte{buf}
+	}
+	return parsers.SplitLines(buf)
+}
+
+func isJSON(buf []byte) bool {
+	trimmed := bytes.TrimSpace(buf)
+	return len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{')
+}
+
+// parseTelnet parses a line of the form "put <metric> <timestamp> <value> <tags>"
+func (p *Parser) parseTelnet(line string) (telegraf.Metric, error) {
+	parts := strings.Fields(line)
+	if parts[0] != "put" {
+		return nil, fmt.Errorf("unsupported command %q", parts[0])
+	}
+	if len(parts) < 5 {
+		return nil, errors.New("expected metric, timestamp, value and at least one tag")
+	}
+
+	tags := make(map[string]string, len(parts)-4)
+	for _, tag := range parts[4:] {
+		k, v, found := strings.Cut(tag, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		if _, exists := tags[k]; exists {
+			return nil, fmt.Errorf("duplicate tag %q", k)
+		}
+		tags[k] = v
+	}
+
+	return p.createMetric(parts[1], parts[2], parts[3], tags)
+}
+
+func (p *Parser) parseJSON(buf []byte) ([]telegraf.Metric, error) {
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+
+	var points []dataPoint
+	if buf[0] == '{' {
+		var point dataPoint
+		if err := decoder.Decode(&point); err != nil {
+			return nil, fmt.Errorf("decoding data point failed: %w", err)
+		}
+		points = append(points, point)
+	} else if err := decoder.Decode(&points); err != nil {
+		return nil, fmt.Errorf("decoding data points failed: %w", err)
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(points))
+	for i, point := range points {
+		m, err := p.createMetric(point.Metric, point.Timestamp.String(), point.Value.String(), point.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("data point %d: %w", i+1, err)
+		}
+		metrics = append(metrics, m)
+	}
+
+	return metrics, nil
+}
+
+func (p *Parser) createMetric(name, timestamp, value string, tags map[string]string) (telegraf.Metric, error) {
+	if err := validate(name); err != nil {
+		return nil, fmt.Errorf("invalid metric name %q: %w", name, err)
+	}
+	if len(tags) == 0 {
+		return nil, errors.New("at least one tag is required")
+	}
+	for k, v := range tags {
+		if err := validate(k); err != nil {
+			return nil, fmt.Errorf("invalid tag key %q: %w", k, err)
+		}
+		if err := validate(v); err != nil {
+			return nil, fmt.Errorf("invalid value %q of tag %q: %w", v, k, err)
+		}
+	}
+
+	ts, err := parseTimestamp(timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := parseValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range p.DefaultTags {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
+
+	return metric.New(name, tags, map[string]interface{}{"value": v}, ts), nil
+}
+
+// parseTimestamp converts the Unix timestamp in seconds or milliseconds.
+// Milliseconds might also be given as decimal seconds, e.g. "1356998400.500".
+func parseTimestamp(timestamp string) (time.Time, error) {
+	if sec, ms, found := strings.Cut(timestamp, "."); found {
+		s, err := strconv.ParseInt(sec, 10, 64)
+		if err != nil || len(ms) == 0 || len(ms) > 3 || s < 0 || s > maxSeconds {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", timestamp)
+		}
+		frac, err := strconv.ParseInt(ms+strings.Repeat("0", 3-len(ms)), 10, 64)
+		if err != nil || frac < 0 {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", timestamp)
+		}
+		return time.Unix(s, frac*int64(time.Millisecond)), nil
+	}
+
+	t, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || t < 0 {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if t > maxSeconds {
+		return time.Unix(0, t*int64(time.Millisecond)), nil
+	}
+	return time.Unix(t, 0), nil
+}
+
+// parseValue returns integer values as int64 and all others as float64
+func parseValue(value string) (interface{}, error) {
+	if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return v, nil
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", value)
+	}
+	return v, nil
+}
+
+// validate checks the metric name, tag key or tag value to only contain the
+// characters allowed by OpenTSDB
+func validate(s string) error {
+	if s == "" {
+		return errors.New("empty")
+	}
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == '/':
+		case unicode.IsLetter(r):
+		default:
+			return fmt.Errorf("invalid character %q", r)
+		}
+	}
+	return nil
+}
+
+// InitFromConfig is a compatibility function to construct the parser the old way
+func (p *Parser) InitFromConfig(config *parsers.Config) error {
+	p.DefaultTags = config.DefaultTags
+	return nil
+}
+
+func init() {
+	parsers.Add("opentsdb",
+		func(_ string) telegraf.Parser {
+			return &Parser{}
+		},
+	)
+}
@@ -0,0 +1,173 @@
+package opentsdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []telegraf.Metric
+	}{
+		{
+			name:  "telnet put",
+			input: "put sys.cpu.user 1356998400 42.5 host=webserver01 cpu=0\n",
+			expected: []telegraf.Metric{
+				metric.New(
+					"sys.cpu.user",
+					map[string]string{"host": "webserver01", "cpu": "0"},
+					map[string]interface{}{"value": 42.5},
+					time.Unix(1356998400, 0),
+				),
+			},
+		},
+		{
+			name: "telnet multiple lines",
+			input: "put sys.if.bytes.out 1356998400000 1024 host=web01 interface=eth0\n" +
+				"\n" +
+				"put sys.if.bytes.out 1356998400.250 -2e3 host=web01 interface=eth1\n",
+			expected: []telegraf.Metric{
+				metric.New(
+					"sys.if.bytes.out",
+					map[string]string{"host": "web01", "interface": "eth0"},
+					map[string]interface{}{"value": int64(1024)},
+					time.Unix(1356998400, 0),
+				),
+				metric.New(
+					"sys.if.bytes.out",
+					map[string]string{"host": "web01", "interface": "eth1"},
+					map[string]interface{}{"value": float64(-2000)},
+					time.Unix(1356998400, 250000000),
+				),
+			},
+		},
+		{
+			name: "json array",
+			input: `[
+				{"metric": "sys.cpu.nice", "timestamp": 1346846400, "value": 18, "tags": {"host": "web01", "dc": "lga"}},
+				{"metric": "sys.cpu.nice", "timestamp": 1346846400123, "value": 9.5, "tags": {"host": "web02", "dc": "lga"}}
+			]`,
+			expected: []telegraf.Metric{
+				metric.New(
+					"sys.cpu.nice",
+					map[string]string{"host": "web01", "dc": "lga"},
+					map[string]interface{}{"value": int64(18)},
+					time.Unix(1346846400, 0),
+				),
+				metric.New(
+					"sys.cpu.nice",
+					map[string]string{"host": "web02", "dc": "lga"},
+					map[string]interface{}{"value": 9.5},
+					time.Unix(1346846400, 123000000),
+				),
+			},
+		},
+		{
+			name:  "json single data point",
+			input: `{"metric": "sys.cpu.nice", "timestamp": 1346846400, "value": "18", "tags": {"host": "web01"}}`,
+			expected: []telegraf.Metric{
+				metric.New(
+					"sys.cpu.nice",
+					map[string]string{"host": "web01"},
+					map[string]interface{}{"value": int64(18)},
+					time.Unix(1346846400, 0),
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{}
+			actual, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "unsupported command",
+			input:    "version",
+			expected: `line 1: unsupported command "version"`,
+		},
+		{
+			name:     "missing tags",
+			input:    "put sys.cpu.user 1356998400 42.5",
+			expected: "line 1: expected metric, timestamp, value and at least one tag",
+		},
+		{
+			name:     "invalid tag",
+			input:    "put sys.cpu.user 1356998400 42.5 host",
+			expected: `line 1: invalid tag "host"`,
+		},
+		{
+			name:     "duplicate tag",
+			input:    "put sys.cpu.user 1356998400 42.5 host=a host=b",
+			expected: `line 1: duplicate tag "host"`,
+		},
+		{
+			name:     "invalid tag character",
+			input:    "put sys.cpu.user 1356998400 42.5 host=web:01",
+			expected: `line 1: invalid value "web:01" of tag "host": invalid character ':'`,
+		},
+		{
+			name:     "invalid metric name",
+			input:    "put sys,cpu 1356998400 42.5 host=a",
+			expected: `line 1: invalid metric name "sys,cpu": invalid character ','`,
+		},
+		{
+			name:     "invalid timestamp",
+			input:    "put sys.cpu.user 1356998400.1234 42.5 host=a",
+			expected: `line 1: invalid timestamp "1356998400.1234"`,
+		},
+		{
+			name:     "invalid value",
+			input:    "put sys.cpu.user 1356998400 high host=a",
+			expected: `line 1: invalid value "high"`,
+		},
+		{
+			name:     "json without tags",
+			input:    `[{"metric": "sys.cpu.nice", "timestamp": 1346846400, "value": 18}]`,
+			expected: "data point 1: at least one tag is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{}
+			_, err := parser.Parse([]byte(tt.input))
+			require.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestDefaultTags(t *testing.T) {
+	parser := &Parser{}
+	parser.SetDefaultTags(map[string]string{"host": "default", "source": "opentsdb"})
+
+	m, err := parser.ParseLine("put sys.cpu.user 1356998400 42 host=web01")
+	require.NoError(t, err)
+
+	expected := metric.New(
+		"sys.cpu.user",
+		map[string]string{"host": "web01", "source": "opentsdb"},
+		map[string]interface{}{"value": int64(42)},
+		time.Unix(1356998400, 0),
+	)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, []telegraf.Metric{m})
+}
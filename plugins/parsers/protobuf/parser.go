@@ -0,0 +1,338 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	commonproto "github.com/influxdata/telegraf/plugins/common/protobuf"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+type Parser struct {
+	File              string   `toml:"protobuf_file"`
+	ImportPaths       []string `toml:"protobuf_import_paths"`
+	MessageType       string   `toml:"protobuf_message_type"`
+	Framing           string   `toml:"protobuf_framing"`
+	MeasurementField  string   `toml:"protobuf_measurement_field"`
+	Tags              []string `toml:"protobuf_tags"`
+	TimestampField    string   `toml:"protobuf_timestamp_field"`
+	TimestampFormat   string   `toml:"protobuf_timestamp_format"`
+	TimestampTimezone string   `toml:"protobuf_timestamp_timezone"`
+	FieldSeparator    string   `toml:"protobuf_field_separator"`
+
+	DefaultMetricName string            `toml:"-"`
+	DefaultTags       map[string]string `toml:"-"`
+	Log               telegraf.Logger   `toml:"-"`
+
+	descriptor protoreflect.MessageDescriptor
+}
+
+func (p *Parser) Init() error {
+	switch p.Framing {
+	case "":
+		p.Framing = "none"
+	case "none", "length_delimited":
+	default:
+		return fmt.Errorf("invalid framing %q", p.Framing)
+	}
+
+	descriptor, err := commonproto.LoadMessageDescriptor(p.File, p.ImportPaths, p.MessageType)
+	if err != nil {
+		return err
+	}
+	p.descriptor = descriptor
+
+	if p.TimestampFormat == "" {
+		p.TimestampFormat = "unix"
+	}
+	if p.FieldSeparator == "" {
+		p.FieldSeparator = "_"
+	}
+
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	messages := [][]byte{buf}
+	if p.Framing == "length_delimited" {
+		var err error
+		if messages, err = commonproto.SplitLengthDelimited(buf); err != nil {
+			return nil, err
+		}
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(messages))
+	for _, data := range messages {
+		msg := dynamicpb.NewMessage(p.descriptor)
+		if err := proto.Unmarshal(data, msg); err != nil {
+			return nil, fmt.Errorf("decoding message failed: %w", err)
+		}
+
+		for _, values := range flatten(msg, "") {
+			m, err := p.createMetric(values)
+			if err != nil {
+				return nil, err
+			}
+			metrics = append(metrics, m)
+		}
+	}
+
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, errors.New("no metric in line")
+	}
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) createMetric(values map[string]interface{}) (telegraf.Metric, error) {
+	name := p.DefaultMetricName
+	if p.MeasurementField != "" {
+		if v, found := values[p.MeasurementField]; found {
+			name = fmt.Sprintf("%v", v)
+			delete(values, p.MeasurementField)
+		} else {
+			p.Log.Debugf("Measurement field %q not found in message", p.MeasurementField)
+		}
+	}
+
+	timestamp := time.Now()
+	if p.TimestampField != "" {
+		raw, found := values[p.TimestampField]
+		if !found {
+			return nil, fmt.Errorf("timestamp field %q not found in message", p.TimestampField)
+		}
+		if ts, ok := raw.(time.Time); ok {
+			timestamp = ts
+		} else {
+			ts, err := internal.ParseTimestamp(p.TimestampFormat, raw, p.TimestampTimezone)
+			if err != nil {
+				return nil, fmt.Errorf("parsing timestamp failed: %w", err)
+			}
+			timestamp = ts
+		}
+		delete(values, p.TimestampField)
+	}
+
+	tags := make(map[string]string, len(p.Tags)+len(p.DefaultTags))
+	for _, path := range p.Tags {
+		for key, v := range values {
+			if !matchPath(key, path) {
+				continue
+			}
+			tags[p.outputName(key)] = fmt.Sprintf("%v", convert(v))
+			delete(values, key)
+		}
+	}
+	for k, v := range p.DefaultTags {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
+
+	fields := make(map[string]interface{}, len(values))
+	for key, v := range values {
+		fields[p.outputName(key)] = convert(v)
+	}
+
+	return metric.New(name, tags, fields, timestamp), nil
+}
+
+func (p *Parser) outputName(key string) string {
+	return strings.ReplaceAll(key, ".", p.FieldSeparator)
+}
+
+// matchPath checks if the key is the given path or an element nested below it
+func matchPath(key, path string) bool {
+	return key == path || strings.HasPrefix(key, path+".")
+}
+
+// flatten returns the values of the message keyed by their dotted field path.
+// Each element of a repeated message field results in a separate set of values
+// containing the values of the enclosing messages. Repeated scalar fields are
+// flattened using the element index.
+func flatten(msg protoreflect.Message, prefix string) []map[string]interface{} {
+	base := make(map[string]interface{})
+	var expanded [][]map[string]interface{}
+
+	// Add nested values either to the common values if they are unique or
+	// expand them to multiple sets otherwise
+	merge := func(rows []map[string]interface{}) {
+		if len(rows) == 1 {
+			for k, v := range rows[0] {
+				base[k] = v
+			}
+			return
+		}
+		expanded = append(expanded, rows)
+	}
+
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := join(prefix, string(fd.Name()))
+
+		switch {
+		case fd.IsList():
+			list := msg.Get(fd).List()
+			if fd.Kind() == protoreflect.MessageKind && !isTimestamp(fd) {
+				var rows []map[string]interface{}
+				for j := 0; j < list.Len(); j++ {
+					rows = append(rows, flatten(list.Get(j).Message(), path)...)
+				}
+				if len(rows) > 0 {
+					expanded = append(expanded, rows)
+				}
+				continue
+			}
+			for j := 0; j < list.Len(); j++ {
+				base[join(path, strconv.Itoa(j))] = value(fd, list.Get(j))
+			}
+		case fd.IsMap():
+			valueDesc := fd.MapValue()
+			msg.Get(fd).Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				key := join(path, k.String())
+				if valueDesc.Kind() == protoreflect.MessageKind && !isTimestamp(valueDesc) {
+					merge(flatten(v.Message(), key))
+				} else {
+					base[key] = value(valueDesc, v)
+				}
+				return true
+			})
+		case fd.Kind() == protoreflect.MessageKind:
+			if !msg.Has(fd) {
+				continue
+			}
+			if isTimestamp(fd) {
+				base[path] = value(fd, msg.Get(fd))
+				continue
+			}
+			merge(flatten(msg.Get(fd).Message(), path))
+		default:
+			// Scalars without explicit presence are always reported to not
+			// drop zero values
+			if fd.HasPresence() && !msg.Has(fd) {
+				continue
+			}
+			base[path] = value(fd, msg.Get(fd))
+		}
+	}
+
+	if len(expanded) == 0 {
+		return []map[string]interface{}{base}
+	}
+
+	var result []map[string]interface{}
+	for _, rows := range expanded {
+		for _, row := range rows {
+			values := make(map[string]interface{}, len(base)+len(row))
+			for k, v := range base {
+				values[k] = v
+			}
+			for k, v := range row {
+				values[k] = v
+			}
+			result = append(result, values)
+		}
+	}
+	return result
+}
+
+func isTimestamp(fd protoreflect.FieldDescriptor) bool {
+	return fd.Kind() == protoreflect.MessageKind && fd.Message().FullName() == commonproto.TimestampMessage
+}
+
+// value returns the Go representation of the non-message value or the
+// time of a timestamp message
+func value(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return v.Bool()
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int64(v.Enum())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return v.Int()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return v.Uint()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	case protoreflect.StringKind:
+		return v.String()
+	case protoreflect.BytesKind:
+		return string(v.Bytes())
+	case protoreflect.MessageKind:
+		msg := v.Message()
+		fields := msg.Descriptor().Fields()
+		seconds := msg.Get(fields.ByName("seconds")).Int()
+		nanos := msg.Get(fields.ByName("nanos")).Int()
+		return time.Unix(seconds, nanos)
+	}
+	return v.Interface()
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// convert maps values not supported by metrics
+func convert(value interface{}) interface{} {
+	if v, ok := value.(time.Time); ok {
+		return v.UnixNano()
+	}
+	return value
+}
+
+// InitFromConfig is a compatibility function to construct the parser the old way
+func (p *Parser) InitFromConfig(config *parsers.Config) error {
+	p.DefaultMetricName = config.MetricName
+	p.DefaultTags = config.DefaultTags
+	p.File = config.ProtobufFile
+	p.ImportPaths = config.ProtobufImportPaths
+	p.MessageType = config.ProtobufMessageType
+	p.Framing = config.ProtobufFraming
+	p.MeasurementField = config.ProtobufMeasurementField
+	p.Tags = config.ProtobufTags
+	p.TimestampField = config.ProtobufTimestampField
+	p.TimestampFormat = config.ProtobufTimestampFormat
+	p.TimestampTimezone = config.ProtobufTimestampTimezone
+	p.FieldSeparator = config.ProtobufFieldSeparator
+
+	return p.Init()
+}
+
+func init() {
+	parsers.Add("protobuf",
+		func(defaultMetricName string) telegraf.Parser {
+			return &Parser{DefaultMetricName: defaultMetricName}
+		},
+	)
+}
@@ -0,0 +1,187 @@
+package protobuf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	commonproto "github.com/influxdata/telegraf/plugins/common/protobuf"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// encode creates the binary representation of the JSON encoded report
+func encode(t *testing.T, report string) []byte {
+	descriptor, err := commonproto.LoadMessageDescriptor("testdata/sensors.proto", nil, "sensors.Report")
+	require.NoError(t, err)
+
+	msg := dynamicpb.NewMessage(descriptor)
+	require.NoError(t, protojson.Unmarshal([]byte(report), msg))
+	buf, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	return buf
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		parser   *Parser
+		expected string
+	}{
+		{
+			name:     "no definition",
+			parser:   &Parser{MessageType: "sensors.Report"},
+			expected: "no message definition file specified",
+		},
+		{
+			name:     "no message type",
+			parser:   &Parser{File: "testdata/sensors.proto"},
+			expected: "no message type specified",
+		},
+		{
+			name:     "unknown message type",
+			parser:   &Parser{File: "testdata/sensors.proto", MessageType: "sensors.Unknown"},
+			expected: `looking up message type "sensors.Unknown" failed`,
+		},
+		{
+			name:     "invalid framing",
+			parser:   &Parser{File: "testdata/sensors.proto", MessageType: "sensors.Report", Framing: "netstring"},
+			expected: `invalid framing "netstring"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.parser.Init(), tt.expected)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		parser   *Parser
+		input    string
+		expected []telegraf.Metric
+	}{
+		{
+			name: "nested messages and scalars",
+			parser: &Parser{
+				Tags:           []string{"header.device"},
+				TimestampField: "time",
+			},
+			input: `{
+				"kind": "environment",
+				"header": {"device": "dev01", "sequence": 7},
+				"time": "2022-06-28T10:00:00.5Z",
+				"timeMs": "1656410400500",
+				"samples": ["3", "-1"],
+				"labels": {"room": "lab"}
+			}`,
+			expected: []telegraf.Metric{
+				metric.New(
+					"protobuf",
+					map[string]string{"header_device": "dev01"},
+					map[string]interface{}{
+						"kind":            "environment",
+						"header_sequence": uint64(7),
+						"time_ms":         int64(1656410400500),
+						"samples_0":       int64(3),
+						"samples_1":       int64(-1),
+						"labels_room":     "lab",
+					},
+					time.Unix(1656410400, 500000000),
+				),
+			},
+		},
+		{
+			name: "repeated messages",
+			parser: &Parser{
+				MeasurementField: "kind",
+				Tags:             []string{"header", "readings.sensor"},
+				TimestampField:   "time_ms",
+				TimestampFormat:  "unix_ms",
+				FieldSeparator:   ".",
+			},
+			input: `{
+				"kind": "environment",
+				"header": {"device": "dev01"},
+				"timeMs": "1656410400500",
+				"readings": [
+					{"sensor": "temperature", "value": 21.5},
+					{"sensor": "humidity", "value": 48, "status": "FAILED"}
+				],
+				"battery": 0.5
+			}`,
+			expected: []telegraf.Metric{
+				metric.New(
+					"environment",
+					map[string]string{"header.device": "dev01", "header.sequence": "0", "readings.sensor": "temperature"},
+					map[string]interface{}{
+						"readings.value":  21.5,
+						"readings.status": "OK",
+						"battery":         0.5,
+					},
+					time.Unix(1656410400, 500000000),
+				),
+				metric.New(
+					"environment",
+					map[string]string{"header.device": "dev01", "header.sequence": "0", "readings.sensor": "humidity"},
+					map[string]interface{}{
+						"readings.value":  float64(48),
+						"readings.status": "FAILED",
+						"battery":         0.5,
+					},
+					time.Unix(1656410400, 500000000),
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.parser.File = "testdata/sensors.proto"
+			tt.parser.MessageType = "sensors.Report"
+			tt.parser.DefaultMetricName = "protobuf"
+			tt.parser.Log = testutil.Logger{}
+			require.NoError(t, tt.parser.Init())
+
+			actual, err := tt.parser.Parse(encode(t, tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.SortMetrics())
+		})
+	}
+}
+
+func TestParseLengthDelimited(t *testing.T) {
+	var buf []byte
+	buf = commonproto.AppendLengthDelimited(buf, encode(t, `{"kind": "a", "timeMs": "1000"}`))
+	buf = commonproto.AppendLengthDelimited(buf, encode(t, `{"kind": "b", "timeMs": "2000"}`))
+
+	parser := &Parser{
+		File:             "testdata/sensors.proto",
+		MessageType:      "sensors.Report",
+		Framing:          "length_delimited",
+		MeasurementField: "kind",
+		TimestampField:   "time_ms",
+		TimestampFormat:  "unix_ms",
+	}
+	require.NoError(t, parser.Init())
+
+	actual, err := parser.Parse(buf)
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		metric.New("a", map[string]string{}, map[string]interface{}{}, time.Unix(1, 0)),
+		metric.New("b", map[string]string{}, map[string]interface{}{}, time.Unix(2, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	_, err = parser.Parse(buf[:len(buf)-1])
+	require.ErrorContains(t, err, "exceeds the remaining")
+}
@@ -0,0 +1,16 @@
+package parsers
+
+import "bytes"
+
+// SplitLines splits the data into non-empty lines for parsers implementing
+// telegraf.RecordParser for line-based formats
+func SplitLines(buf []byte) [][]byte {
+	var records [][]byte
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		records = append(records, line)
+	}
+	return records
+}
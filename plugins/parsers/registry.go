@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers/temporary/binary"
 	"github.com/influxdata/telegraf/plugins/parsers/temporary/json_v2"
 	"github.com/influxdata/telegraf/plugins/parsers/temporary/xpath"
 )
@@ -185,6 +186,36 @@ type Config struct {
 
 	// LogFmt configuration
 	LogFmtTagKeys []string `toml:"logfmt_tag_keys"`
+
+	// Avro configuration
+	AvroSchemaRegistry    string   `toml:"avro_schema_registry"`
+	AvroSchemaFile        string   `toml:"avro_schema_file"`
+	AvroFraming           string   `toml:"avro_framing"`
+	AvroMeasurement       string   `toml:"avro_measurement"`
+	AvroMeasurementField  string   `toml:"avro_measurement_field"`
+	AvroTags              []string `toml:"avro_tags"`
+	AvroFields            []string `toml:"avro_fields"`
+	AvroTimestamp         string   `toml:"avro_timestamp"`
+	AvroTimestampFormat   string   `toml:"avro_timestamp_format"`
+	AvroTimestampTimezone string   `toml:"avro_timestamp_timezone"`
+	AvroFieldSeparator    string   `toml:"avro_field_separator"`
+
+	// Binary configuration
+	BinaryEndianness   string          `toml:"binary_endianness"`
+	BinaryAllowNoMatch bool            `toml:"binary_allow_no_match"`
+	BinaryConfig       []binary.Config `toml:"binary"`
+
+	// Protobuf configuration
+	ProtobufFile              string   `toml:"protobuf_file"`
+	ProtobufImportPaths       []string `toml:"protobuf_import_paths"`
+	ProtobufMessageType       string   `toml:"protobuf_message_type"`
+	ProtobufFraming           string   `toml:"protobuf_framing"`
+	ProtobufMeasurementField  string   `toml:"protobuf_measurement_field"`
+	ProtobufTags              []string `toml:"protobuf_tags"`
+	ProtobufTimestampField    string   `toml:"protobuf_timestamp_field"`
+	ProtobufTimestampFormat   string   `toml:"protobuf_timestamp_format"`
+	ProtobufTimestampTimezone string   `toml:"protobuf_timestamp_timezone"`
+	ProtobufFieldSeparator    string   `toml:"protobuf_field_separator"`
 }
 
 // NewParser returns a Parser interface based on the given config.
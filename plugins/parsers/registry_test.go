@@ -12,14 +12,21 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	_ "github.com/influxdata/telegraf/plugins/parsers/all"
+	"github.com/influxdata/telegraf/plugins/parsers/temporary/binary"
 )
 
 func TestRegistry_BackwardCompatibility(t *testing.T) {
 	cfg := &parsers.Config{
-		MetricName:        "parser_compatibility_test",
-		CSVHeaderRowCount: 42,
-		XPathProtobufFile: "xpath/testcases/protos/addressbook.proto",
-		XPathProtobufType: "addressbook.AddressBook",
+		MetricName:          "parser_compatibility_test",
+		CSVHeaderRowCount:   42,
+		XPathProtobufFile:   "xpath/testcases/protos/addressbook.proto",
+		XPathProtobufType:   "addressbook.AddressBook",
+		AvroSchemaFile:      "avro/testdata/measurement.avsc",
+		ProtobufFile:        "protobuf/testdata/sensors.proto",
+		ProtobufMessageType: "sensors.Report",
+		BinaryConfig: []binary.Config{
+			{Entries: []binary.Entry{{Name: "value", Type: "uint8"}}},
+		},
 	}
 
 	// Some parsers need certain settings to not error. Furthermore, we
@@ -34,6 +41,22 @@ func TestRegistry_BackwardCompatibility(t *testing.T) {
 			},
 			mask: []string{"TimeFunc"},
 		},
+		"avro": {
+			param: map[string]interface{}{
+				"SchemaFile": cfg.AvroSchemaFile,
+			},
+		},
+		"binary": {
+			param: map[string]interface{}{
+				"Layouts": cfg.BinaryConfig,
+			},
+		},
+		"protobuf": {
+			param: map[string]interface{}{
+				"File":        cfg.ProtobufFile,
+				"MessageType": cfg.ProtobufMessageType,
+			},
+		},
 		"xpath_protobuf": {
 			param: map[string]interface{}{
 				"ProtobufMessageDef":  cfg.XPathProtobufFile,
@@ -0,0 +1,33 @@
+package binary
+
+// Config definition for backward compatibility ONLY.
+// We need this here to avoid cyclic dependencies. However, we need
+// to move this to plugins/parsers/binary once we deprecate parser
+// construction via `NewParser()`.
+type Config struct {
+	MetricName        string  `toml:"metric_name"`
+	Endianness        string  `toml:"endianness"`
+	TimestampFormat   string  `toml:"timestamp_format"`
+	TimestampTimezone string  `toml:"timestamp_timezone"`
+	Filter            *Filter `toml:"filter"`
+	Entries           []Entry `toml:"entries"`
+}
+
+// Filter selects the messages a layout applies to
+type Filter struct {
+	Length int    `toml:"length"`
+	Offset int    `toml:"offset"`
+	Value  string `toml:"value"`
+}
+
+// Entry describes a single value in the message
+type Entry struct {
+	Name       string  `toml:"name"`
+	Type       string  `toml:"type"`
+	Offset     int     `toml:"offset"`
+	Length     int     `toml:"length"`
+	Bits       int     `toml:"bits"`
+	BitOffset  int     `toml:"bit_offset"`
+	Scale      float64 `toml:"scale"`
+	Assignment string  `toml:"assignment"`
+}
@@ -127,6 +127,11 @@ func (p *Parser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
 
+// SplitRecords splits the data into lines which can be parsed independently
+func (p *Parser) SplitRecords(buf []byte) [][]byte {
+	return parsers.SplitLines(buf)
+}
+
 func (p *PointParser) convertPointToTelegrafMetric(points []Point) ([]telegraf.Metric, error) {
 	metrics := make([]telegraf.Metric, 0)
 
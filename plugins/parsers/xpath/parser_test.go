@@ -2,6 +2,8 @@ package xpath
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -21,6 +23,7 @@ import (
 	"github.com/influxdata/toml"
 
 	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
 )
 
 const invalidXML = `
@@ -1486,3 +1489,98 @@ func loadTestConfiguration(filename string) (*xpath.Config, []string, error) {
 	err = toml.Unmarshal(buf, &cfg)
 	return &cfg, header, err
 }
+
+func TestParseStream(t *testing.T) {
+	var msgpackInput []byte
+	for i, name := range []string{"a", "b"} {
+		msgpackInput = msgp.AppendMapHeader(msgpackInput, 2)
+		msgpackInput = msgp.AppendString(msgpackInput, "name")
+		msgpackInput = msgp.AppendString(msgpackInput, name)
+		msgpackInput = msgp.AppendString(msgpackInput, "value")
+		msgpackInput = msgp.AppendInt(msgpackInput, i+1)
+	}
+
+	var tests = []struct {
+		name      string
+		format    string
+		selection string
+		input     []byte
+	}{
+		{
+			name:      "xml",
+			format:    "xml",
+			selection: "/Device",
+			input: []byte(`<?xml version="1.0"?>
+<Device><Name>a</Name><Value>1</Value></Device>
+<?xml version="1.0"?>
+<Device>
+	<Name>b</Name>
+	<Value>2</Value>
+	<Empty/>
+</Device>
+`),
+		},
+		{
+			name:      "json",
+			format:    "xpath_json",
+			selection: "/",
+			input:     []byte(`{"name": "a", "value": 1}` + "\n" + `{"name": "b", "value": 2}` + "\n"),
+		},
+		{
+			name:      "msgpack",
+			format:    "xpath_msgpack",
+			selection: "/",
+			input:     msgpackInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				Format:            tt.format,
+				DefaultMetricName: "test",
+				Configs: []xpath.Config{
+					{
+						Selection: tt.selection,
+						Tags:      map[string]string{"name": "string(Name|name)"},
+						FieldsInt: map[string]string{"value": "Value|value"},
+					},
+				},
+				Log: testutil.Logger{Name: "parsers.xpath"},
+			}
+			require.NoError(t, parser.Init())
+
+			expected := []telegraf.Metric{
+				testutil.MustMetric("test", map[string]string{"name": "a"}, map[string]interface{}{"value": int64(1)}, time.Unix(0, 0)),
+				testutil.MustMetric("test", map[string]string{"name": "b"}, map[string]interface{}{"value": int64(2)}, time.Unix(0, 0)),
+			}
+
+			var actual []telegraf.Metric
+			err := parser.ParseStream(context.Background(), bytes.NewReader(tt.input), func(m telegraf.Metric) error {
+				actual = append(actual, m)
+				return nil
+			})
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestParseStreamInvalidXML(t *testing.T) {
+	parser := &Parser{
+		DefaultMetricName: "test",
+		Configs:           []xpath.Config{{Fields: map[string]string{"value": "/Device/Value"}}},
+		Log:               testutil.Logger{Name: "parsers.xpath"},
+	}
+	require.NoError(t, parser.Init())
+
+	input := "<Device><Value>1</Value></Device>\n<Device><Value>2</Value>"
+
+	var count int
+	err := parser.ParseStream(context.Background(), strings.NewReader(input), func(m telegraf.Metric) error {
+		count++
+		return nil
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, count)
+}
@@ -0,0 +1,133 @@
+package xpath
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/tinylib/msgp/msgp"
+
+	"github.com/influxdata/telegraf"
+)
+
+// documentReader returns the next complete document of a stream or io.EOF if
+// the stream does not contain any more documents.
+type documentReader func() ([]byte, error)
+
+// ParseStream parses a stream of concatenated documents, e.g. multiple XML
+// documents or newline-delimited JSON. Each document is parsed on its own so
+// only a single document is held in memory at a time. Protocol-buffer messages
+// are not self-delimiting, so the stream is treated as a single message.
+func (p *Parser) ParseStream(ctx context.Context, r io.Reader, fn func(telegraf.Metric) error) error {
+	var next documentReader
+	switch p.Format {
+	case "", "xml":
+		next = newXMLDocumentReader(r)
+	case "xpath_json":
+		next = newJSONDocumentReader(r)
+	case "xpath_msgpack":
+		next = newMsgpackDocumentReader(r)
+	default:
+		next = newSingleDocumentReader(r)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		buf, err := next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		metrics, err := p.Parse(buf)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func newXMLDocumentReader(r io.Reader) documentReader {
+	// Keep a copy of the data consumed by the decoder to be able to cut out
+	// the raw document once its root element is closed.
+	var buf bytes.Buffer
+	decoder := xml.NewDecoder(io.TeeReader(r, &buf))
+	var consumed int64
+
+	return func() ([]byte, error) {
+		var depth int
+		for {
+			token, err := decoder.RawToken()
+			if err == io.EOF && depth > 0 {
+				return nil, fmt.Errorf("incomplete XML document: %w", io.ErrUnexpectedEOF)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			switch token.(type) {
+			case xml.StartElement:
+				depth++
+			case xml.EndElement:
+				depth--
+				if depth > 0 {
+					continue
+				}
+
+				length := decoder.InputOffset() - consumed
+				consumed += length
+				doc := make([]byte, length)
+				copy(doc, buf.Next(int(length)))
+				return doc, nil
+			}
+		}
+	}
+}
+
+func newJSONDocumentReader(r io.Reader) documentReader {
+	decoder := json.NewDecoder(r)
+
+	return func() ([]byte, error) {
+		var doc json.RawMessage
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	}
+}
+
+func newMsgpackDocumentReader(r io.Reader) documentReader {
+	reader := msgp.NewReader(r)
+
+	return func() ([]byte, error) {
+		var buf bytes.Buffer
+		if _, err := reader.CopyNext(&buf); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+func newSingleDocumentReader(r io.Reader) documentReader {
+	var done bool
+
+	return func() ([]byte, error) {
+		if done {
+			return nil, io.EOF
+		}
+		done = true
+		return io.ReadAll(r)
+	}
+}
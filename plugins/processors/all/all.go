@@ -26,6 +26,9 @@ import (
 	_ "github.com/influxdata/telegraf/plugins/processors/strings"
 	_ "github.com/influxdata/telegraf/plugins/processors/tag_limit"
 	_ "github.com/influxdata/telegraf/plugins/processors/template"
+	_ "github.com/influxdata/telegraf/plugins/processors/timestamp"
 	_ "github.com/influxdata/telegraf/plugins/processors/topk"
+	_ "github.com/influxdata/telegraf/plugins/processors/units"
 	_ "github.com/influxdata/telegraf/plugins/processors/unpivot"
+	_ "github.com/influxdata/telegraf/plugins/processors/wasm"
 )
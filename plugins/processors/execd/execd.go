@@ -17,6 +17,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/processors"
 	"github.com/influxdata/telegraf/plugins/serializers"
+	serializers_influx "github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
 // DO NOT REMOVE THE NEXT TWO LINES! This is required to embed the sampleConfig data.
@@ -29,12 +30,11 @@ type Execd struct {
 	RestartDelay config.Duration `toml:"restart_delay"`
 	Log          telegraf.Logger
 
-	parserConfig     *parsers.Config
-	parser           parsers.Parser
-	serializerConfig *serializers.Config
-	serializer       serializers.Serializer
-	acc              telegraf.Accumulator
-	process          *process.Process
+	parserConfig *parsers.Config
+	parser       parsers.Parser
+	serializer   serializers.Serializer
+	acc          telegraf.Accumulator
+	process      *process.Process
 }
 
 func New() *Execd {
@@ -43,9 +43,6 @@ func New() *Execd {
 		parserConfig: &parsers.Config{
 			DataFormat: "influx",
 		},
-		serializerConfig: &serializers.Config{
-			DataFormat: "influx",
-		},
 	}
 }
 
@@ -59,10 +56,7 @@ func (e *Execd) Start(acc telegraf.Accumulator) error {
 	if err != nil {
 		return fmt.Errorf("error creating parser: %w", err)
 	}
-	e.serializer, err = serializers.NewSerializer(e.serializerConfig)
-	if err != nil {
-		return fmt.Errorf("error creating serializer: %w", err)
-	}
+	e.serializer = serializers_influx.NewSerializer()
 	e.acc = acc
 
 	e.process, err = process.New(e.Command, e.Environment)
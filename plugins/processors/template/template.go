@@ -7,6 +7,7 @@ import (
 	"text/template"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/templating"
 	"github.com/influxdata/telegraf/plugins/processors"
 )
 
@@ -29,10 +30,10 @@ func (r *TemplateProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 	// for each metric in "in" array
 	for _, metric := range in {
 		var b strings.Builder
-		newM := TemplateMetric{metric}
+		newM := templating.NewMetric(metric)
 
-		// supply TemplateMetric and Template from configuration to Template.Execute
-		err := r.tmpl.Execute(&b, &newM)
+		// supply the metric and Template from configuration to Template.Execute
+		err := r.tmpl.Execute(&b, newM)
 		if err != nil {
 			r.Log.Errorf("failed to execute template: %v", err)
 			continue
@@ -45,7 +46,7 @@ func (r *TemplateProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 
 func (r *TemplateProcessor) Init() error {
 	// create template
-	t, err := template.New("configured_template").Parse(r.Template)
+	t, err := templating.New("configured_template", r.Template)
 
 	r.tmpl = t
 	return err
@@ -0,0 +1,157 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package timestamp
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+// DO NOT REMOVE THE NEXT TWO LINES! This is required to embed the sampleConfig data.
+//go:embed sample.conf
+var sampleConfig string
+
+type Timestamp struct {
+	Field      string          `toml:"field"`
+	Tag        string          `toml:"tag"`
+	Format     string          `toml:"format"`
+	Timezone   string          `toml:"timezone"`
+	KeepSource bool            `toml:"keep_source"`
+	Offset     config.Duration `toml:"offset"`
+	MaxFuture  config.Duration `toml:"max_future"`
+	MaxPast    config.Duration `toml:"max_past"`
+	OutOfRange string          `toml:"out_of_range"`
+	Log        telegraf.Logger `toml:"-"`
+
+	now func() time.Time
+}
+
+func (*Timestamp) SampleConfig() string {
+	return sampleConfig
+}
+
+func (t *Timestamp) Init() error {
+	if t.Field != "" && t.Tag != "" {
+		return errors.New("only one of field or tag can be specified")
+	} else if t.Field == "" && t.Tag == "" {
+		return errors.New("one of field or tag must be specified")
+	}
+
+	if t.Format == "" {
+		return errors.New("no format specified")
+	}
+	if strings.ToLower(t.Format) == "iso8601" {
+		t.Format = time.RFC3339Nano
+	}
+
+	if _, err := time.LoadLocation(t.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	if t.MaxFuture < 0 || t.MaxPast < 0 {
+		return errors.New("max_future and max_past cannot be negative")
+	}
+
+	switch t.OutOfRange {
+	case "":
+		t.OutOfRange = "clamp"
+	case "clamp", "keep", "drop":
+	default:
+		return fmt.Errorf("invalid out_of_range action %q", t.OutOfRange)
+	}
+
+	if t.now == nil {
+		t.now = time.Now
+	}
+
+	return nil
+}
+
+func (t *Timestamp) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := in[:0]
+	for _, m := range in {
+		if t.apply(m) {
+			out = append(out, m)
+		} else {
+			m.Drop()
+		}
+	}
+	return out
+}
+
+// apply sets the metric's timestamp and returns false if the metric should
+// be dropped
+func (t *Timestamp) apply(m telegraf.Metric) bool {
+	var raw interface{}
+	var found bool
+	if t.Field != "" {
+		raw, found = m.GetField(t.Field)
+	} else {
+		raw, found = m.GetTag(t.Tag)
+	}
+	if !found {
+		return true
+	}
+
+	ts, err := internal.ParseTimestamp(t.Format, raw, t.Timezone)
+	if err != nil {
+		t.Log.Errorf("Parsing timestamp %v of metric %q failed: %v", raw, m.Name(), err)
+		return true
+	}
+	ts = ts.Add(time.Duration(t.Offset))
+
+	now := t.now()
+	if t.MaxFuture > 0 {
+		if limit := now.Add(time.Duration(t.MaxFuture)); ts.After(limit) {
+			t.Log.Debugf("Timestamp %v of metric %q too far in the future", ts, m.Name())
+			switch t.OutOfRange {
+			case "keep":
+				ts = m.Time()
+			case "drop":
+				return false
+			default:
+				ts = limit
+			}
+		}
+	}
+	if t.MaxPast > 0 {
+		if limit := now.Add(-time.Duration(t.MaxPast)); ts.Before(limit) {
+			t.Log.Debugf("Timestamp %v of metric %q too far in the past", ts, m.Name())
+			switch t.OutOfRange {
+			case "keep":
+				ts = m.Time()
+			case "drop":
+				return false
+			default:
+				ts = limit
+			}
+		}
+	}
+
+	m.SetTime(ts)
+	if !t.KeepSource {
+		if t.Field != "" {
+			m.RemoveField(t.Field)
+		} else {
+			m.RemoveTag(t.Tag)
+		}
+	}
+
+	return true
+}
+
+func init() {
+	processors.Add("timestamp", func() telegraf.Processor {
+		return &Timestamp{
+			Timezone:   "UTC",
+			OutOfRange: "clamp",
+		}
+	})
+}
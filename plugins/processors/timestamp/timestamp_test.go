@@ -0,0 +1,295 @@
+package timestamp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *Timestamp
+		expected string
+	}{
+		{
+			name:     "no source",
+			plugin:   &Timestamp{Format: "unix"},
+			expected: "one of field or tag must be specified",
+		},
+		{
+			name:     "field and tag",
+			plugin:   &Timestamp{Field: "time", Tag: "time", Format: "unix"},
+			expected: "only one of field or tag can be specified",
+		},
+		{
+			name:     "no format",
+			plugin:   &Timestamp{Field: "time"},
+			expected: "no format specified",
+		},
+		{
+			name:     "invalid timezone",
+			plugin:   &Timestamp{Field: "time", Format: "unix", Timezone: "Mars/Olympus_Mons"},
+			expected: "invalid timezone",
+		},
+		{
+			name:     "invalid action",
+			plugin:   &Timestamp{Field: "time", Format: "unix", OutOfRange: "fix"},
+			expected: "invalid out_of_range action \"fix\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Date(2022, 6, 28, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		plugin   *Timestamp
+		input    telegraf.Metric
+		expected telegraf.Metric
+	}{
+		{
+			name:   "unix field",
+			plugin: &Timestamp{Field: "event_time", Format: "unix"},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"event_time": int64(1656410400), "value": 42},
+				now,
+			),
+			expected: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"value": 42},
+				time.Date(2022, 6, 28, 10, 0, 0, 0, time.UTC),
+			),
+		},
+		{
+			name:   "unix_ms field keeping source",
+			plugin: &Timestamp{Field: "event_time", Format: "unix_ms", KeepSource: true},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"event_time": int64(1656410400123), "value": 42},
+				now,
+			),
+			expected: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"event_time": int64(1656410400123), "value": 42},
+				time.Date(2022, 6, 28, 10, 0, 0, 123000000, time.UTC),
+			),
+		},
+		{
+			name:   "iso8601 tag",
+			plugin: &Timestamp{Tag: "ts", Format: "iso8601"},
+			input: metric.New(
+				"test",
+				map[string]string{"ts": "2022-06-28T13:30:00.5+02:00", "host": "a"},
+				map[string]interface{}{"value": 42},
+				now,
+			),
+			expected: metric.New(
+				"test",
+				map[string]string{"host": "a"},
+				map[string]interface{}{"value": 42},
+				time.Date(2022, 6, 28, 11, 30, 0, 500000000, time.UTC),
+			),
+		},
+		{
+			name:   "custom layout with timezone",
+			plugin: &Timestamp{Field: "time", Format: "02.01.2006 15:04:05", Timezone: "Europe/Berlin"},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"time": "28.06.2022 09:15:00", "value": 42},
+				now,
+			),
+			expected: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"value": 42},
+				time.Date(2022, 6, 28, 7, 15, 0, 0, time.UTC),
+			),
+		},
+		{
+			name:   "offset",
+			plugin: &Timestamp{Field: "time", Format: "unix", Offset: config.Duration(-time.Hour)},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"time": int64(1656410400), "value": 42},
+				now,
+			),
+			expected: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"value": 42},
+				time.Date(2022, 6, 28, 9, 0, 0, 0, time.UTC),
+			),
+		},
+		{
+			name:   "invalid timestamp",
+			plugin: &Timestamp{Field: "time", Format: "rfc3339"},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"time": "yesterday", "value": 42},
+				now,
+			),
+			expected: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"time": "yesterday", "value": 42},
+				now,
+			),
+		},
+		{
+			name:   "missing source",
+			plugin: &Timestamp{Field: "time", Format: "unix"},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"value": 42},
+				now,
+			),
+			expected: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"value": 42},
+				now,
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.plugin.Log = testutil.Logger{}
+			require.NoError(t, tt.plugin.Init())
+			actual := tt.plugin.Apply(tt.input)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, actual)
+		})
+	}
+}
+
+func TestOutOfRange(t *testing.T) {
+	now := time.Date(2022, 6, 28, 12, 0, 0, 0, time.UTC)
+	original := now.Add(-time.Minute)
+	future := now.Add(2 * time.Hour)
+	past := now.Add(-48 * time.Hour)
+
+	tests := []struct {
+		name     string
+		action   string
+		input    time.Time
+		expected []time.Time
+	}{
+		{
+			name:     "clamp future",
+			action:   "clamp",
+			input:    future,
+			expected: []time.Time{now.Add(time.Hour)},
+		},
+		{
+			name:     "clamp past",
+			action:   "clamp",
+			input:    past,
+			expected: []time.Time{now.Add(-24 * time.Hour)},
+		},
+		{
+			name:     "keep future",
+			action:   "keep",
+			input:    future,
+			expected: []time.Time{original},
+		},
+		{
+			name:     "keep past",
+			action:   "keep",
+			input:    past,
+			expected: []time.Time{original},
+		},
+		{
+			name:   "drop future",
+			action: "drop",
+			input:  future,
+		},
+		{
+			name:   "drop past",
+			action: "drop",
+			input:  past,
+		},
+		{
+			name:     "in range",
+			action:   "drop",
+			input:    now.Add(-time.Hour),
+			expected: []time.Time{now.Add(-time.Hour)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Timestamp{
+				Field:      "time",
+				Format:     "unix_ns",
+				MaxFuture:  config.Duration(time.Hour),
+				MaxPast:    config.Duration(24 * time.Hour),
+				OutOfRange: tt.action,
+				KeepSource: true,
+				Log:        testutil.Logger{},
+				now:        func() time.Time { return now },
+			}
+			require.NoError(t, plugin.Init())
+
+			input := metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"time": tt.input.UnixNano()},
+				original,
+			)
+			actual := plugin.Apply(input)
+			require.Len(t, actual, len(tt.expected))
+			for i, m := range actual {
+				require.True(t, tt.expected[i].Equal(m.Time()), "expected %v but got %v", tt.expected[i], m.Time())
+			}
+		})
+	}
+}
+
+func TestOutOfRangeRemoveSource(t *testing.T) {
+	now := time.Date(2022, 6, 28, 12, 0, 0, 0, time.UTC)
+	original := now.Add(-time.Minute)
+
+	plugin := &Timestamp{
+		Field:      "time",
+		Format:     "unix_ns",
+		MaxFuture:  config.Duration(time.Hour),
+		OutOfRange: "keep",
+		Log:        testutil.Logger{},
+		now:        func() time.Time { return now },
+	}
+	require.NoError(t, plugin.Init())
+
+	input := metric.New(
+		"test",
+		map[string]string{},
+		map[string]interface{}{"time": now.Add(2 * time.Hour).UnixNano(), "value": 42},
+		original,
+	)
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": 42}, original),
+	}
+	testutil.RequireMetricsEqual(t, expected, plugin.Apply(input))
+}
@@ -0,0 +1,144 @@
+package units
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unit describes a unit as linear transformation to the base unit of its
+// quantity, i.e. base = value * factor + offset.
+type unit struct {
+	quantity string
+	factor   float64
+	offset   float64
+}
+
+var registry = map[string]unit{
+	// Information with base unit byte
+	"bit":  {quantity: "information", factor: 1.0 / 8},
+	"kbit": {quantity: "information", factor: 1e3 / 8},
+	"Mbit": {quantity: "information", factor: 1e6 / 8},
+	"Gbit": {quantity: "information", factor: 1e9 / 8},
+	"Tbit": {quantity: "information", factor: 1e12 / 8},
+	"B":    {quantity: "information", factor: 1},
+	"kB":   {quantity: "information", factor: 1e3},
+	"MB":   {quantity: "information", factor: 1e6},
+	"GB":   {quantity: "information", factor: 1e9},
+	"TB":   {quantity: "information", factor: 1e12},
+	"PB":   {quantity: "information", factor: 1e15},
+	"KiB":  {quantity: "information", factor: 1 << 10},
+	"MiB":  {quantity: "information", factor: 1 << 20},
+	"GiB":  {quantity: "information", factor: 1 << 30},
+	"TiB":  {quantity: "information", factor: 1 << 40},
+	"PiB":  {quantity: "information", factor: 1 << 50},
+
+	// Data rate with base unit byte per second
+	"bit/s":  {quantity: "data rate", factor: 1.0 / 8},
+	"kbit/s": {quantity: "data rate", factor: 1e3 / 8},
+	"Mbit/s": {quantity: "data rate", factor: 1e6 / 8},
+	"Gbit/s": {quantity: "data rate", factor: 1e9 / 8},
+	"B/s":    {quantity: "data rate", factor: 1},
+	"kB/s":   {quantity: "data rate", factor: 1e3},
+	"MB/s":   {quantity: "data rate", factor: 1e6},
+	"GB/s":   {quantity: "data rate", factor: 1e9},
+	"KiB/s":  {quantity: "data rate", factor: 1 << 10},
+	"MiB/s":  {quantity: "data rate", factor: 1 << 20},
+	"GiB/s":  {quantity: "data rate", factor: 1 << 30},
+
+	// Time with base unit second
+	"ns":  {quantity: "time", factor: 1e-9},
+	"us":  {quantity: "time", factor: 1e-6},
+	"ms":  {quantity: "time", factor: 1e-3},
+	"s":   {quantity: "time", factor: 1},
+	"min": {quantity: "time", factor: 60},
+	"h":   {quantity: "time", factor: 3600},
+	"d":   {quantity: "time", factor: 86400},
+
+	// Frequency with base unit hertz
+	"Hz":  {quantity: "frequency", factor: 1},
+	"kHz": {quantity: "frequency", factor: 1e3},
+	"MHz": {quantity: "frequency", factor: 1e6},
+	"GHz": {quantity: "frequency", factor: 1e9},
+
+	// Temperature with base unit kelvin
+	"K": {quantity: "temperature", factor: 1},
+	"C": {quantity: "temperature", factor: 1, offset: 273.15},
+	"F": {quantity: "temperature", factor: 5.0 / 9, offset: 273.15 - 32*5.0/9},
+
+	// Power with base unit watt
+	"mW": {quantity: "power", factor: 1e-3},
+	"W":  {quantity: "power", factor: 1},
+	"kW": {quantity: "power", factor: 1e3},
+	"MW": {quantity: "power", factor: 1e6},
+
+	// Energy with base unit joule
+	"J":   {quantity: "energy", factor: 1},
+	"kJ":  {quantity: "energy", factor: 1e3},
+	"MJ":  {quantity: "energy", factor: 1e6},
+	"Wh":  {quantity: "energy", factor: 3600},
+	"kWh": {quantity: "energy", factor: 3.6e6},
+	"MWh": {quantity: "energy", factor: 3.6e9},
+
+	// Pressure with base unit pascal
+	"Pa":  {quantity: "pressure", factor: 1},
+	"hPa": {quantity: "pressure", factor: 1e2},
+	"kPa": {quantity: "pressure", factor: 1e3},
+	"bar": {quantity: "pressure", factor: 1e5},
+	"psi": {quantity: "pressure", factor: 6894.757293168},
+
+	// Ratio with base unit one
+	"ratio":   {quantity: "ratio", factor: 1},
+	"percent": {quantity: "ratio", factor: 1e-2},
+	"%":       {quantity: "ratio", factor: 1e-2},
+	"ppm":     {quantity: "ratio", factor: 1e-6},
+}
+
+// aliases map commonly used alternative spellings to the registry names
+var aliases = map[string]string{
+	"byte":    "B",
+	"bytes":   "B",
+	"KB":      "kB",
+	"µs":      "us",
+	"second":  "s",
+	"seconds": "s",
+	"sec":     "s",
+	"°C":      "C",
+	"°F":      "F",
+	"celsius": "C",
+	"kelvin":  "K",
+}
+
+func lookup(name string) (unit, error) {
+	if alias, found := aliases[strings.ToLower(name)]; found {
+		name = alias
+	} else if alias, found := aliases[name]; found {
+		name = alias
+	}
+	u, found := registry[name]
+	if !found {
+		return unit{}, fmt.Errorf("unknown unit %q", name)
+	}
+	return u, nil
+}
+
+// linear returns the scale and offset to convert values from one unit to
+// another unit of the same quantity
+func linear(from, to string) (scale, offset float64, err error) {
+	ufrom, err := lookup(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	uto, err := lookup(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if ufrom.quantity != uto.quantity {
+		return 0, 0, fmt.Errorf("cannot convert %s (%s) to %s (%s)", from, ufrom.quantity, to, uto.quantity)
+	}
+
+	// base = value * from.factor + from.offset
+	// result = (base - to.offset) / to.factor
+	scale = ufrom.factor / uto.factor
+	offset = (ufrom.offset - uto.offset) / uto.factor
+	return scale, offset, nil
+}
@@ -0,0 +1,141 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package units
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+// DO NOT REMOVE THE NEXT TWO LINES! This is required to embed the sampleConfig data.
+//go:embed sample.conf
+var sampleConfig string
+
+type Conversion struct {
+	Fields      []string `toml:"fields"`
+	From        string   `toml:"from"`
+	To          string   `toml:"to"`
+	Scale       *float64 `toml:"scale"`
+	Offset      *float64 `toml:"offset"`
+	FieldSuffix bool     `toml:"field_suffix"`
+	UnitTag     string   `toml:"unit_tag"`
+
+	filter filter.Filter
+	scale  float64
+	offset float64
+}
+
+type Units struct {
+	Conversions []*Conversion   `toml:"conversion"`
+	Log         telegraf.Logger `toml:"-"`
+}
+
+func (*Units) SampleConfig() string {
+	return sampleConfig
+}
+
+func (u *Units) Init() error {
+	if len(u.Conversions) == 0 {
+		return errors.New("no conversions configured")
+	}
+
+	for i, c := range u.Conversions {
+		if len(c.Fields) == 0 {
+			return fmt.Errorf("no fields configured for conversion %d", i+1)
+		}
+
+		var err error
+		c.filter, err = filter.Compile(c.Fields)
+		if err != nil {
+			return fmt.Errorf("creating field filter for conversion %d failed: %w", i+1, err)
+		}
+
+		if (c.FieldSuffix || c.UnitTag != "") && c.To == "" {
+			return fmt.Errorf("conversion %d requires a \"to\" unit for naming", i+1)
+		}
+
+		if c.Scale != nil || c.Offset != nil {
+			c.scale, c.offset = 1.0, 0.0
+			if c.Scale != nil {
+				c.scale = *c.Scale
+			}
+			if c.Offset != nil {
+				c.offset = *c.Offset
+			}
+			continue
+		}
+
+		if c.From == "" || c.To == "" {
+			return fmt.Errorf("conversion %d requires \"from\" and \"to\" units or \"scale\" and \"offset\"", i+1)
+		}
+		c.scale, c.offset, err = linear(c.From, c.To)
+		if err != nil {
+			return fmt.Errorf("conversion %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (u *Units) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		for _, c := range u.Conversions {
+			u.convert(m, c)
+		}
+	}
+	return in
+}
+
+func (u *Units) convert(m telegraf.Metric, c *Conversion) {
+	// Collect the fields first as renaming modifies the field list
+	var keys []string
+	for _, field := range m.FieldList() {
+		if c.filter.Match(field.Key) {
+			keys = append(keys, field.Key)
+		}
+	}
+
+	var converted bool
+	for _, key := range keys {
+		raw, _ := m.GetField(key)
+		value, ok := toFloat(raw)
+		if !ok {
+			u.Log.Debugf("Field %q of metric %q is not numeric, skipping", key, m.Name())
+			continue
+		}
+		value = value*c.scale + c.offset
+
+		if c.FieldSuffix {
+			m.RemoveField(key)
+			key = key + "_" + c.To
+		}
+		m.AddField(key, value)
+		converted = true
+	}
+
+	if converted && c.UnitTag != "" {
+		m.AddTag(c.UnitTag, c.To)
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func init() {
+	processors.Add("units", func() telegraf.Processor {
+		return &Units{}
+	})
+}
@@ -0,0 +1,204 @@
+package units
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name        string
+		conversions []*Conversion
+		expected    string
+	}{
+		{
+			name:     "no conversions",
+			expected: "no conversions configured",
+		},
+		{
+			name:        "no fields",
+			conversions: []*Conversion{{From: "B", To: "MiB"}},
+			expected:    "no fields configured for conversion 1",
+		},
+		{
+			name:        "missing units",
+			conversions: []*Conversion{{Fields: []string{"value"}, From: "B"}},
+			expected:    "requires \"from\" and \"to\" units",
+		},
+		{
+			name:        "unknown unit",
+			conversions: []*Conversion{{Fields: []string{"value"}, From: "B", To: "furlong"}},
+			expected:    "unknown unit \"furlong\"",
+		},
+		{
+			name:        "incompatible units",
+			conversions: []*Conversion{{Fields: []string{"value"}, From: "B", To: "ms"}},
+			expected:    "cannot convert B (information) to ms (time)",
+		},
+		{
+			name:        "suffix without target unit",
+			conversions: []*Conversion{{Fields: []string{"value"}, Scale: floatPtr(2), FieldSuffix: true}},
+			expected:    "requires a \"to\" unit for naming",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Units{Conversions: tt.conversions}
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestLinear(t *testing.T) {
+	tests := []struct {
+		from     string
+		to       string
+		input    float64
+		expected float64
+	}{
+		{from: "B", to: "kB", input: 1500, expected: 1.5},
+		{from: "MiB", to: "B", input: 2, expected: 2097152},
+		{from: "KiB", to: "MiB", input: 512, expected: 0.5},
+		{from: "Mbit/s", to: "MB/s", input: 80, expected: 10},
+		{from: "ms", to: "s", input: 250, expected: 0.25},
+		{from: "h", to: "min", input: 1.5, expected: 90},
+		{from: "C", to: "F", input: 100, expected: 212},
+		{from: "F", to: "C", input: -40, expected: -40},
+		{from: "K", to: "C", input: 0, expected: -273.15},
+		{from: "°C", to: "K", input: 25, expected: 298.15},
+		{from: "percent", to: "ratio", input: 42, expected: 0.42},
+		{from: "kWh", to: "J", input: 1, expected: 3.6e6},
+		{from: "bar", to: "hPa", input: 1, expected: 1000},
+		{from: "bytes", to: "KB", input: 1000, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			scale, offset, err := linear(tt.from, tt.to)
+			require.NoError(t, err)
+			require.InDelta(t, tt.expected, tt.input*scale+offset, 1e-9)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		conversions []*Conversion
+		input       telegraf.Metric
+		expected    telegraf.Metric
+	}{
+		{
+			name:        "bytes to mebibytes",
+			conversions: []*Conversion{{Fields: []string{"used", "free"}, From: "B", To: "MiB"}},
+			input: metric.New(
+				"mem",
+				map[string]string{"host": "localhost"},
+				map[string]interface{}{"used": uint64(1048576), "free": int64(3145728), "used_percent": 25.0},
+				now,
+			),
+			expected: metric.New(
+				"mem",
+				map[string]string{"host": "localhost"},
+				map[string]interface{}{"used": 1.0, "free": 3.0, "used_percent": 25.0},
+				now,
+			),
+		},
+		{
+			name: "glob with suffix and tag",
+			conversions: []*Conversion{
+				{Fields: []string{"temp_*"}, From: "F", To: "C", FieldSuffix: true, UnitTag: "unit"},
+			},
+			input: metric.New(
+				"sensor",
+				map[string]string{},
+				map[string]interface{}{"temp_in": 32.0, "temp_out": 212.0, "humidity": 40.0},
+				now,
+			),
+			expected: metric.New(
+				"sensor",
+				map[string]string{"unit": "C"},
+				map[string]interface{}{"temp_in_C": 0.0, "temp_out_C": 100.0, "humidity": 40.0},
+				now,
+			),
+		},
+		{
+			name:        "scale and offset",
+			conversions: []*Conversion{{Fields: []string{"value"}, Scale: floatPtr(0.5), Offset: floatPtr(10)}},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"value": int64(20)},
+				now,
+			),
+			expected: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"value": 20.0},
+				now,
+			),
+		},
+		{
+			name:        "non-numeric fields are skipped",
+			conversions: []*Conversion{{Fields: []string{"*"}, From: "ms", To: "s", UnitTag: "unit"}},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"status": "ok", "up": true},
+				now,
+			),
+			expected: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"status": "ok", "up": true},
+				now,
+			),
+		},
+		{
+			name: "chained conversions",
+			conversions: []*Conversion{
+				{Fields: []string{"latency"}, From: "us", To: "ms"},
+				{Fields: []string{"latency"}, From: "ms", To: "s", FieldSuffix: true},
+			},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"latency": int64(1500000)},
+				now,
+			),
+			expected: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"latency_s": 1.5},
+				now,
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Units{
+				Conversions: tt.conversions,
+				Log:         testutil.Logger{},
+			}
+			require.NoError(t, plugin.Init())
+
+			actual := plugin.Apply(tt.input)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, actual, cmpopts.EquateApprox(0, 1e-9))
+		})
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
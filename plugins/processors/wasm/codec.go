@@ -0,0 +1,65 @@
+package wasm
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	serializers_influx "github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/plugins/serializers/msgpack"
+)
+
+// codec defines the format metrics are exchanged with the module
+type codec interface {
+	Serialize(m telegraf.Metric) ([]byte, error)
+	Parse(buf []byte) ([]telegraf.Metric, error)
+}
+
+type influxCodec struct {
+	serializer *serializers_influx.Serializer
+	parser     *influx.Parser
+}
+
+func newInfluxCodec() *influxCodec {
+	serializer := serializers_influx.NewSerializer()
+	serializer.SetFieldTypeSupport(serializers_influx.UintSupport)
+	parser := &influx.Parser{}
+	_ = parser.Init() // Cannot fail without configuration
+
+	return &influxCodec{
+		serializer: serializer,
+		parser:     parser,
+	}
+}
+
+func (c *influxCodec) Serialize(m telegraf.Metric) ([]byte, error) {
+	return c.serializer.Serialize(m)
+}
+
+func (c *influxCodec) Parse(buf []byte) ([]telegraf.Metric, error) {
+	if len(buf) == 0 {
+		return nil, nil
+	}
+	return c.parser.Parse(buf)
+}
+
+type msgpackCodec struct {
+	serializer msgpack.Serializer
+}
+
+func (c *msgpackCodec) Serialize(m telegraf.Metric) ([]byte, error) {
+	return c.serializer.Serialize(m)
+}
+
+func (c *msgpackCodec) Parse(buf []byte) ([]telegraf.Metric, error) {
+	var metrics []telegraf.Metric
+	for len(buf) > 0 {
+		var m msgpack.Metric
+		var err error
+		buf, err = m.UnmarshalMsg(buf)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric.New(m.Name, m.Tags, m.Fields, m.Time.Time()))
+	}
+	return metrics, nil
+}
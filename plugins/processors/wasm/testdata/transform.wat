@@ -0,0 +1,85 @@
+;; Test module for the wasm processor, compile using
+;;   wat2wasm transform.wat -o transform.wasm
+(module
+  (memory (export "memory") 1)
+
+  ;; Start of the heap, everything below is reserved
+  (global $heap (mut i32) (i32.const 1024))
+
+  ;; Simple bump allocator, the heap is reset on every call as the host
+  ;; copies the output before passing the next metric.
+  (func $alloc (param $size i32) (result i32)
+    (local $ptr i32)
+    (local.set $ptr (global.get $heap))
+    (global.set $heap (i32.add (local.get $ptr) (local.get $size)))
+    (if (i32.gt_u (global.get $heap) (i32.mul (memory.size) (i32.const 65536)))
+      (then
+        (if (i32.eq (memory.grow (i32.add (i32.div_u (local.get $size) (i32.const 65536)) (i32.const 1))) (i32.const -1))
+          (then unreachable))))
+    (local.get $ptr))
+
+  (func (export "allocate") (param $size i32) (result i32)
+    (global.set $heap (i32.const 1024))
+    (call $alloc (local.get $size)))
+
+  ;; Pack pointer and length into the result
+  (func $result (param $ptr i32) (param $len i32) (result i64)
+    (i64.or
+      (i64.shl (i64.extend_i32_u (local.get $ptr)) (i64.const 32))
+      (i64.extend_i32_u (local.get $len))))
+
+  ;; Return the metric unchanged
+  (func (export "passthrough") (param $ptr i32) (param $len i32) (result i64)
+    (call $result (local.get $ptr) (local.get $len)))
+
+  ;; Drop the metric
+  (func (export "drop") (param $ptr i32) (param $len i32) (result i64)
+    (i64.const 0))
+
+  ;; Return the metric twice
+  (func (export "duplicate") (param $ptr i32) (param $len i32) (result i64)
+    (local $out i32)
+    (local.set $out (call $alloc (i32.mul (local.get $len) (i32.const 2))))
+    (memory.copy (local.get $out) (local.get $ptr) (local.get $len))
+    (memory.copy (i32.add (local.get $out) (local.get $len)) (local.get $ptr) (local.get $len))
+    (call $result (local.get $out) (i32.mul (local.get $len) (i32.const 2))))
+
+  ;; Add the "source=wasm" tag after the measurement name of line protocol
+  (data (i32.const 0) ",source=wasm")
+  (func (export "tag") (param $ptr i32) (param $len i32) (result i64)
+    (local $out i32)
+    (local $pos i32)
+    (local $c i32)
+    ;; Find the end of the measurement name
+    (block $done
+      (loop $scan
+        (br_if $done (i32.ge_u (local.get $pos) (local.get $len)))
+        (local.set $c (i32.load8_u (i32.add (local.get $ptr) (local.get $pos))))
+        (br_if $done (i32.eq (local.get $c) (i32.const 44)))
+        (br_if $done (i32.eq (local.get $c) (i32.const 32)))
+        (local.set $pos (i32.add (local.get $pos) (i32.const 1)))
+        (br $scan)))
+    (local.set $out (call $alloc (i32.add (local.get $len) (i32.const 12))))
+    (memory.copy (local.get $out) (local.get $ptr) (local.get $pos))
+    (memory.copy (i32.add (local.get $out) (local.get $pos)) (i32.const 0) (i32.const 12))
+    (memory.copy
+      (i32.add (i32.add (local.get $out) (local.get $pos)) (i32.const 12))
+      (i32.add (local.get $ptr) (local.get $pos))
+      (i32.sub (local.get $len) (local.get $pos)))
+    (call $result (local.get $out) (i32.add (local.get $len) (i32.const 12))))
+
+  ;; Never return
+  (func (export "loop") (param $ptr i32) (param $len i32) (result i64)
+    (loop $forever
+      (br $forever))
+    (i64.const 0))
+
+  ;; Request 32MiB of memory
+  (func (export "grow") (param $ptr i32) (param $len i32) (result i64)
+    (drop (call $alloc (i32.const 33554432)))
+    (i64.const 0))
+
+  ;; Fail execution
+  (func (export "trap") (param $ptr i32) (param $len i32) (result i64)
+    unreachable)
+)
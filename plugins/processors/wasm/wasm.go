@@ -0,0 +1,324 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package wasm
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+// DO NOT REMOVE THE NEXT TWO LINES! This is required to embed the sampleConfig data.
+//go:embed sample.conf
+var sampleConfig string
+
+// Size of a WebAssembly memory page
+const pageSize = 64 * 1024
+
+type WASM struct {
+	Module      string          `toml:"module"`
+	Function    string          `toml:"function"`
+	DataFormat  string          `toml:"data_format"`
+	MemoryLimit config.Size     `toml:"memory_limit"`
+	Timeout     config.Duration `toml:"timeout"`
+	Log         telegraf.Logger `toml:"-"`
+
+	codec    codec
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	instance *instance
+}
+
+// instance holds an instantiated module and its exported ABI functions
+type instance struct {
+	module     api.Module
+	allocate   api.Function
+	deallocate api.Function
+	apply      api.Function
+}
+
+func (*WASM) SampleConfig() string {
+	return sampleConfig
+}
+
+func (w *WASM) Init() error {
+	if w.Module == "" {
+		return errors.New("no module specified")
+	}
+	if w.Function == "" {
+		w.Function = "apply"
+	}
+
+	switch w.DataFormat {
+	case "", "influx":
+		w.codec = newInfluxCodec()
+	case "msgpack":
+		w.codec = &msgpackCodec{}
+	default:
+		return fmt.Errorf("invalid data format %q", w.DataFormat)
+	}
+
+	if w.MemoryLimit < 0 || w.MemoryLimit > 4*1024*1024*1024 {
+		return fmt.Errorf("memory limit %d out of range", w.MemoryLimit)
+	}
+
+	return nil
+}
+
+func (w *WASM) Start(_ telegraf.Accumulator) error {
+	code, err := os.ReadFile(w.Module)
+	if err != nil {
+		return fmt.Errorf("reading module failed: %w", err)
+	}
+
+	ctx := context.Background()
+	cfg := wazero.NewRuntimeConfig().WithCloseOnContextDone(w.Timeout > 0)
+	if w.MemoryLimit > 0 {
+		cfg = cfg.WithMemoryLimitPages(uint32((w.MemoryLimit + pageSize - 1) / pageSize))
+	}
+	w.runtime = wazero.NewRuntimeWithConfig(ctx, cfg)
+
+	// Provide WASI to allow modules compiled with standard toolchains such
+	// as TinyGo, Rust or AssemblyScript to run.
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, w.runtime); err != nil {
+		w.runtime.Close(ctx)
+		return fmt.Errorf("instantiating WASI failed: %w", err)
+	}
+
+	w.compiled, err = w.runtime.CompileModule(ctx, code)
+	if err != nil {
+		w.runtime.Close(ctx)
+		return fmt.Errorf("compiling module failed: %w", err)
+	}
+
+	if err := w.instantiate(ctx); err != nil {
+		w.runtime.Close(ctx)
+		return err
+	}
+
+	return nil
+}
+
+func (w *WASM) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	in, err := w.codec.Serialize(m)
+	if err != nil {
+		m.Reject()
+		return fmt.Errorf("serializing metric failed: %w", err)
+	}
+
+	out, err := w.call(in)
+	if err != nil {
+		m.Reject()
+		return err
+	}
+
+	metrics, err := w.codec.Parse(out)
+	if err != nil {
+		m.Reject()
+		return fmt.Errorf("parsing result failed: %w", err)
+	}
+
+	if len(metrics) == 0 {
+		m.Drop()
+		return nil
+	}
+
+	// Update the original metric with the first result to keep tracking
+	// information intact.
+	replace(m, metrics[0])
+	acc.AddMetric(m)
+	for _, result := range metrics[1:] {
+		acc.AddMetric(result)
+	}
+
+	return nil
+}
+
+func (w *WASM) Stop() error {
+	if w.runtime != nil {
+		return w.runtime.Close(context.Background())
+	}
+	return nil
+}
+
+func (w *WASM) instantiate(ctx context.Context) error {
+	cfg := wazero.NewModuleConfig().
+		WithStartFunctions("_initialize").
+		WithStdout(&logWriter{log: w.Log.Debug}).
+		WithStderr(&logWriter{log: w.Log.Error}).
+		WithSysWalltime().
+		WithSysNanotime()
+
+	module, err := w.runtime.InstantiateModule(ctx, w.compiled, cfg)
+	if err != nil {
+		return fmt.Errorf("instantiating module failed: %w", err)
+	}
+
+	inst := &instance{
+		module:     module,
+		allocate:   module.ExportedFunction("allocate"),
+		deallocate: module.ExportedFunction("deallocate"),
+		apply:      module.ExportedFunction(w.Function),
+	}
+	if module.Memory() == nil {
+		module.Close(ctx)
+		return errors.New("module does not export a memory")
+	}
+	if inst.allocate == nil {
+		module.Close(ctx)
+		return errors.New("module does not export an \"allocate\" function")
+	}
+	if inst.apply == nil {
+		module.Close(ctx)
+		return fmt.Errorf("module does not export an %q function", w.Function)
+	}
+	w.instance = inst
+
+	return nil
+}
+
+// call passes the given buffer to the module's apply function and returns
+// the buffer produced by the module. The module is re-instantiated after
+// any failure as its state cannot be trusted anymore.
+func (w *WASM) call(in []byte) ([]byte, error) {
+	ctx := context.Background()
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(w.Timeout))
+		defer cancel()
+	}
+
+	if w.instance == nil {
+		if err := w.instantiate(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := w.instance.call(ctx, in)
+	if err != nil {
+		w.instance.module.Close(context.Background())
+		w.instance = nil
+		if ierr := w.instantiate(context.Background()); ierr != nil {
+			w.Log.Errorf("Re-instantiating module failed: %v", ierr)
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("calling %q timed out", w.Function)
+		}
+		return nil, fmt.Errorf("calling %q failed: %w", w.Function, err)
+	}
+
+	return out, nil
+}
+
+func (inst *instance) call(ctx context.Context, in []byte) ([]byte, error) {
+	memory := inst.module.Memory()
+
+	results, err := inst.allocate.Call(ctx, uint64(len(in)))
+	if err != nil {
+		return nil, fmt.Errorf("allocating input buffer: %w", err)
+	}
+	ptr := uint32(results[0])
+	if !memory.Write(ptr, in) {
+		return nil, fmt.Errorf("writing input buffer at %d with length %d out of range", ptr, len(in))
+	}
+
+	results, err = inst.apply.Call(ctx, uint64(ptr), uint64(len(in)))
+	if err != nil {
+		return nil, err
+	}
+	if err := inst.free(ctx, ptr, uint32(len(in))); err != nil {
+		return nil, fmt.Errorf("freeing input buffer: %w", err)
+	}
+
+	// The result is the location of the output buffer with the pointer in
+	// the upper and the length in the lower 32 bits.
+	outPtr, outLen := uint32(results[0]>>32), uint32(results[0])
+	if outLen == 0 {
+		return nil, nil
+	}
+	buf, ok := memory.Read(outPtr, outLen)
+	if !ok {
+		return nil, fmt.Errorf("reading output buffer at %d with length %d out of range", outPtr, outLen)
+	}
+	out := make([]byte, len(buf))
+	copy(out, buf)
+	if err := inst.free(ctx, outPtr, outLen); err != nil {
+		return nil, fmt.Errorf("freeing output buffer: %w", err)
+	}
+
+	return out, nil
+}
+
+func (inst *instance) free(ctx context.Context, ptr, size uint32) error {
+	if inst.deallocate == nil {
+		return nil
+	}
+	_, err := inst.deallocate.Call(ctx, uint64(ptr), uint64(size))
+	return err
+}
+
+// replace sets name, tags, fields and time of the metric to the ones of the
+// source metric
+func replace(m, source telegraf.Metric) {
+	m.SetName(source.Name())
+
+	var remove []string
+	for _, tag := range m.TagList() {
+		if _, found := source.GetTag(tag.Key); !found {
+			remove = append(remove, tag.Key)
+		}
+	}
+	for _, key := range remove {
+		m.RemoveTag(key)
+	}
+	for _, tag := range source.TagList() {
+		m.AddTag(tag.Key, tag.Value)
+	}
+
+	remove = remove[:0]
+	for _, field := range m.FieldList() {
+		if _, found := source.GetField(field.Key); !found {
+			remove = append(remove, field.Key)
+		}
+	}
+	for _, key := range remove {
+		m.RemoveField(key)
+	}
+	for _, field := range source.FieldList() {
+		m.AddField(field.Key, field.Value)
+	}
+
+	m.SetTime(source.Time())
+}
+
+// logWriter forwards output of the module to the Telegraf log
+type logWriter struct {
+	log func(args ...interface{})
+}
+
+func (lw *logWriter) Write(p []byte) (int, error) {
+	lw.log(strings.TrimRight(string(p), "\r\n"))
+	return len(p), nil
+}
+
+func init() {
+	processors.AddStreaming("wasm", func() telegraf.StreamingProcessor {
+		return &WASM{
+			Function:    "apply",
+			DataFormat:  "influx",
+			MemoryLimit: config.Size(16 * 1024 * 1024),
+			Timeout:     config.Duration(time.Second),
+		}
+	})
+}
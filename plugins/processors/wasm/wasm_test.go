@@ -0,0 +1,220 @@
+package wasm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newWASM(function string) *WASM {
+	return &WASM{
+		Module:      "testdata/transform.wasm",
+		Function:    function,
+		DataFormat:  "influx",
+		MemoryLimit: config.Size(16 * 1024 * 1024),
+		Timeout:     config.Duration(time.Second),
+		Log:         testutil.Logger{},
+	}
+}
+
+func TestInitError(t *testing.T) {
+	plugin := &WASM{}
+	require.ErrorContains(t, plugin.Init(), "no module specified")
+
+	plugin = newWASM("apply")
+	plugin.DataFormat = "json"
+	require.ErrorContains(t, plugin.Init(), "invalid data format")
+}
+
+func TestStartError(t *testing.T) {
+	tests := []struct {
+		name     string
+		module   string
+		function string
+		expected string
+	}{
+		{
+			name:     "missing module",
+			module:   "testdata/nonexistent.wasm",
+			function: "passthrough",
+			expected: "reading module failed",
+		},
+		{
+			name:     "invalid module",
+			module:   "testdata/transform.wat",
+			function: "passthrough",
+			expected: "compiling module failed",
+		},
+		{
+			name:     "missing function",
+			module:   "testdata/transform.wasm",
+			function: "nonexistent",
+			expected: "module does not export an \"nonexistent\" function",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newWASM(tt.function)
+			plugin.Module = tt.module
+			require.NoError(t, plugin.Init())
+			require.ErrorContains(t, plugin.Start(&testutil.Accumulator{}), tt.expected)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Unix(1656426000, 0)
+	input := metric.New(
+		"cpu",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"value": 42.0, "count": int64(3), "status": "ok"},
+		now,
+	)
+
+	tests := []struct {
+		name       string
+		function   string
+		dataFormat string
+		expected   []telegraf.Metric
+	}{
+		{
+			name:     "passthrough",
+			function: "passthrough",
+			expected: []telegraf.Metric{input},
+		},
+		{
+			name:       "passthrough msgpack",
+			function:   "passthrough",
+			dataFormat: "msgpack",
+			expected:   []telegraf.Metric{input},
+		},
+		{
+			name:     "drop",
+			function: "drop",
+		},
+		{
+			name:     "duplicate",
+			function: "duplicate",
+			expected: []telegraf.Metric{input, input},
+		},
+		{
+			name:       "duplicate msgpack",
+			function:   "duplicate",
+			dataFormat: "msgpack",
+			expected:   []telegraf.Metric{input, input},
+		},
+		{
+			name:     "add tag",
+			function: "tag",
+			expected: []telegraf.Metric{
+				metric.New(
+					"cpu",
+					map[string]string{"host": "localhost", "source": "wasm"},
+					map[string]interface{}{"value": 42.0, "count": int64(3), "status": "ok"},
+					now,
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newWASM(tt.function)
+			if tt.dataFormat != "" {
+				plugin.DataFormat = tt.dataFormat
+			}
+			require.NoError(t, plugin.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Start(&acc))
+			defer plugin.Stop()
+
+			for i := 0; i < 3; i++ {
+				require.NoError(t, plugin.Add(input.Copy(), &acc))
+			}
+
+			var expected []telegraf.Metric
+			for i := 0; i < 3; i++ {
+				expected = append(expected, tt.expected...)
+			}
+			testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		function string
+		expected string
+	}{
+		{
+			name:     "timeout",
+			function: "loop",
+			expected: "calling \"loop\" timed out",
+		},
+		{
+			name:     "memory",
+			function: "grow",
+			expected: "calling \"grow\" failed",
+		},
+		{
+			name:     "trap",
+			function: "trap",
+			expected: "calling \"trap\" failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newWASM(tt.function)
+			plugin.Timeout = config.Duration(100 * time.Millisecond)
+			require.NoError(t, plugin.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Start(&acc))
+			defer plugin.Stop()
+
+			// The module must be usable again after a failure
+			for i := 0; i < 2; i++ {
+				m := metric.New("test", map[string]string{}, map[string]interface{}{"value": 1}, time.Now())
+				require.ErrorContains(t, plugin.Add(m, &acc), tt.expected)
+			}
+			require.Empty(t, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestTracking(t *testing.T) {
+	plugin := newWASM("tag")
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	var delivered bool
+	notify := func(di telegraf.DeliveryInfo) {
+		delivered = di.Delivered()
+	}
+	m := metric.New("test", map[string]string{}, map[string]interface{}{"value": 1}, time.Now())
+	tm, _ := metric.WithTracking(m, notify)
+	require.NoError(t, plugin.Add(tm, &acc))
+
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+
+	// The original metric must be modified in-place
+	value, found := tm.GetTag("source")
+	require.True(t, found)
+	require.Equal(t, "wasm", value)
+
+	tm.Accept()
+	require.True(t, delivered)
+}
@@ -0,0 +1,22 @@
+package all
+
+import (
+	//Blank imports for plugins to register themselves
+	_ "github.com/influxdata/telegraf/plugins/serializers/arrow"
+	_ "github.com/influxdata/telegraf/plugins/serializers/avro"
+	_ "github.com/influxdata/telegraf/plugins/serializers/carbon2"
+	_ "github.com/influxdata/telegraf/plugins/serializers/cloudevents"
+	_ "github.com/influxdata/telegraf/plugins/serializers/csv"
+	_ "github.com/influxdata/telegraf/plugins/serializers/graphite"
+	_ "github.com/influxdata/telegraf/plugins/serializers/influx"
+	_ "github.com/influxdata/telegraf/plugins/serializers/json"
+	_ "github.com/influxdata/telegraf/plugins/serializers/msgpack"
+	_ "github.com/influxdata/telegraf/plugins/serializers/nowmetric"
+	_ "github.com/influxdata/telegraf/plugins/serializers/parquet"
+	_ "github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	_ "github.com/influxdata/telegraf/plugins/serializers/prometheusremotewrite"
+	_ "github.com/influxdata/telegraf/plugins/serializers/protobuf"
+	_ "github.com/influxdata/telegraf/plugins/serializers/splunkmetric"
+	_ "github.com/influxdata/telegraf/plugins/serializers/template"
+	_ "github.com/influxdata/telegraf/plugins/serializers/wavefront"
+)
@@ -0,0 +1,169 @@
+package arrow
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/columnar"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
+)
+
+// Serializer writes each batch of metrics as Arrow IPC file or stream with
+// one record batch per measurement.
+type Serializer struct {
+	Format      string `toml:"arrow_format"`
+	Compression string `toml:"arrow_compression"`
+
+	options []ipc.Option
+}
+
+func (s *Serializer) Init() error {
+	switch s.Format {
+	case "":
+		s.Format = "file"
+	case "file", "stream":
+	default:
+		return fmt.Errorf("invalid format %q", s.Format)
+	}
+
+	switch s.Compression {
+	case "", "none":
+	case "lz4":
+		s.options = append(s.options, ipc.WithLZ4())
+	case "zstd":
+		s.options = append(s.options, ipc.WithZstd())
+	default:
+		return fmt.Errorf("invalid compression %q", s.Compression)
+	}
+
+	return nil
+}
+
+// WholeFile returns true as every batch is written as a complete Arrow file or
+// stream, both of which are terminated and cannot be appended to
+func (s *Serializer) WholeFile() bool {
+	return true
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	table := columnar.NewTable(metrics)
+	schema := newSchema(table)
+
+	mem := memory.NewGoAllocator()
+	builder := array.NewRecordBuilder(mem, schema)
+	defer builder.Release()
+
+	var buf buffer
+	options := append([]ipc.Option{ipc.WithSchema(schema), ipc.WithAllocator(mem)}, s.options...)
+	var w interface {
+		Write(array.Record) error
+		Close() error
+	}
+	if s.Format == "stream" {
+		w = ipc.NewWriter(&buf, options...)
+	} else {
+		fw, err := ipc.NewFileWriter(&buf, options...)
+		if err != nil {
+			return nil, fmt.Errorf("creating writer failed: %w", err)
+		}
+		w = fw
+	}
+
+	for _, group := range table.Groups {
+		for _, m := range group.Metrics {
+			appendMetric(builder, table, m)
+		}
+
+		record := builder.NewRecord()
+		err := w.Write(record)
+		record.Release()
+		if err != nil {
+			return nil, fmt.Errorf("writing record batch for %q failed: %w", group.Name, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("finishing output failed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func newSchema(table *columnar.Table) *arrow.Schema {
+	fields := make([]arrow.Field, 0, len(table.Columns)+2)
+	fields = append(fields,
+		arrow.Field{Name: columnar.MeasurementColumn, Type: arrow.BinaryTypes.String},
+		arrow.Field{Name: columnar.TimeColumn, Type: arrow.FixedWidthTypes.Timestamp_ns},
+	)
+	for _, c := range table.Columns {
+		var typ arrow.DataType
+		switch c.Type {
+		case columnar.Bool:
+			typ = arrow.FixedWidthTypes.Boolean
+		case columnar.Int:
+			typ = arrow.PrimitiveTypes.Int64
+		case columnar.Uint:
+			typ = arrow.PrimitiveTypes.Uint64
+		case columnar.Float:
+			typ = arrow.PrimitiveTypes.Float64
+		case columnar.String:
+			typ = arrow.BinaryTypes.String
+		}
+		fields = append(fields, arrow.Field{Name: c.Name, Type: typ, Nullable: true})
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+func appendMetric(builder *array.RecordBuilder, table *columnar.Table, m telegraf.Metric) {
+	builder.Field(0).(*array.StringBuilder).Append(m.Name())
+	builder.Field(1).(*array.TimestampBuilder).Append(arrow.Timestamp(m.Time().UnixNano()))
+
+	for i := range table.Columns {
+		b := builder.Field(i + 2)
+		switch v := table.Columns[i].Value(m).(type) {
+		case nil:
+			b.AppendNull()
+		case bool:
+			b.(*array.BooleanBuilder).Append(v)
+		case int64:
+			b.(*array.Int64Builder).Append(v)
+		case uint64:
+			b.(*array.Uint64Builder).Append(v)
+		case float64:
+			b.(*array.Float64Builder).Append(v)
+		case string:
+			b.(*array.StringBuilder).Append(v)
+		}
+	}
+}
+
+// buffer is an in-memory io.WriteSeeker as required by the Arrow file writer,
+// seeking is only used to determine the current position.
+type buffer struct {
+	bytes.Buffer
+}
+
+func (b *buffer) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekCurrent {
+		return 0, fmt.Errorf("unsupported seek to %d from %d", offset, whence)
+	}
+	return int64(b.Len()), nil
+}
+
+func init() {
+	registry.Add("arrow",
+		func() registry.Serializer {
+			return &Serializer{}
+		},
+	)
+}
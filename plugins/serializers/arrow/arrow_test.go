@@ -0,0 +1,105 @@
+package arrow
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var metrics = []telegraf.Metric{
+	metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": uint64(1024)}, time.Unix(0, 2)),
+	metric.New("cpu", map[string]string{"host": "a", "cpu": "cpu0"}, map[string]interface{}{"usage": 42.5, "ok": true}, time.Unix(0, 1)),
+	metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": int64(23), "ok": "maybe"}, time.Unix(0, 3)),
+}
+
+var expectedSchema = []string{
+	"measurement: utf8",
+	"time: timestamp[ns, tz=UTC]",
+	"cpu: utf8",
+	"host: utf8",
+	"ok: utf8",
+	"usage: float64",
+	"used: uint64",
+}
+
+var expected = [][]string{
+	{`["cpu" "cpu"]`, `[1 3]`, `["cpu0" (null)]`, `["a" "b"]`, `["true" "maybe"]`, `[42.5 23]`, `[(null) (null)]`},
+	{`["mem"]`, `[2]`, `[(null)]`, `["a"]`, `[(null)]`, `[(null)]`, `[1024]`},
+}
+
+func TestSerializeStream(t *testing.T) {
+	for _, compression := range []string{"none", "lz4", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			serializer := &Serializer{Format: "stream", Compression: compression}
+			require.NoError(t, serializer.Init())
+			buf, err := serializer.SerializeBatch(metrics)
+			require.NoError(t, err)
+
+			r, err := ipc.NewReader(bytes.NewReader(buf))
+			require.NoError(t, err)
+			defer r.Release()
+
+			require.Equal(t, expectedSchema, schema(r.Schema()))
+			actual := make([][]string, 0, len(expected))
+			for r.Next() {
+				actual = append(actual, columns(r.Record()))
+			}
+			require.NoError(t, r.Err())
+			require.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestSerializeFile(t *testing.T) {
+	serializer := &Serializer{}
+	require.NoError(t, serializer.Init())
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	r, err := ipc.NewFileReader(bytes.NewReader(buf))
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.Equal(t, expectedSchema, schema(r.Schema()))
+	require.Equal(t, len(expected), r.NumRecords())
+	actual := make([][]string, 0, len(expected))
+	for i := 0; i < r.NumRecords(); i++ {
+		record, err := r.Record(i)
+		require.NoError(t, err)
+		actual = append(actual, columns(record))
+	}
+	require.Equal(t, expected, actual)
+}
+
+func TestInitError(t *testing.T) {
+	serializer := &Serializer{Format: "feather"}
+	require.EqualError(t, serializer.Init(), `invalid format "feather"`)
+
+	serializer = &Serializer{Compression: "gzip"}
+	require.EqualError(t, serializer.Init(), `invalid compression "gzip"`)
+}
+
+func schema(s *arrow.Schema) []string {
+	fields := make([]string, 0, len(s.Fields()))
+	for _, f := range s.Fields() {
+		fields = append(fields, fmt.Sprintf("%s: %v", f.Name, f.Type))
+	}
+	return fields
+}
+
+func columns(record array.Record) []string {
+	cols := make([]string, 0, record.NumCols())
+	for _, col := range record.Columns() {
+		cols = append(cols, fmt.Sprintf("%v", col))
+	}
+	return cols
+}
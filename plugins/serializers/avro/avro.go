@@ -0,0 +1,334 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/influxdata/telegraf"
+	commonavro "github.com/influxdata/telegraf/plugins/common/avro"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
+)
+
+// Serializer encodes metrics as Avro records. Record fields are filled with
+// the metric's fields or tags of the same name.
+type Serializer struct {
+	SchemaRegistry   string `toml:"avro_schema_registry"`
+	SchemaSubject    string `toml:"avro_schema_subject"`
+	SchemaFile       string `toml:"avro_schema_file"`
+	SchemaID         int    `toml:"avro_schema_id"`
+	Framing          string `toml:"avro_framing"`
+	MeasurementField string `toml:"avro_measurement_field"`
+	TimestampField   string `toml:"avro_timestamp_field"`
+	TimestampFormat  string `toml:"avro_timestamp_format"`
+
+	registry *commonavro.SchemaRegistry
+	schema   *commonavro.Schema
+}
+
+func (s *Serializer) Init() error {
+	switch s.Framing {
+	case "":
+		s.Framing = "confluent"
+	case "confluent", "none":
+	default:
+		return fmt.Errorf("invalid framing %q", s.Framing)
+	}
+
+	switch {
+	case s.SchemaRegistry != "" && s.SchemaFile != "":
+		return errors.New("only one of avro_schema_registry and avro_schema_file can be specified")
+	case s.SchemaRegistry != "":
+		if s.SchemaSubject == "" {
+			return errors.New("using a schema registry requires a subject")
+		}
+		if s.Framing != "confluent" {
+			return errors.New("using a schema registry requires the confluent framing")
+		}
+		s.registry = commonavro.NewSchemaRegistry(s.SchemaRegistry)
+	case s.SchemaFile != "":
+		if s.Framing == "confluent" && s.SchemaID <= 0 {
+			return errors.New("the confluent framing requires a schema ID")
+		}
+		schema, err := commonavro.NewSchemaFromFile(s.SchemaID, s.SchemaFile)
+		if err != nil {
+			return fmt.Errorf("loading schema %q failed: %w", s.SchemaFile, err)
+		}
+		s.schema = schema
+	default:
+		return errors.New("either avro_schema_registry or avro_schema_file must be specified")
+	}
+
+	switch s.TimestampFormat {
+	case "":
+		s.TimestampFormat = "unix"
+	case "unix", "unix_ms", "unix_us", "unix_ns":
+	default:
+		return fmt.Errorf("invalid timestamp format %q", s.TimestampFormat)
+	}
+
+	return nil
+}
+
+func (s *Serializer) Serialize(m telegraf.Metric) ([]byte, error) {
+	return s.serialize(nil, m)
+}
+
+// SerializeBatch concatenates the serialized metrics. Each record is prefixed
+// with a header when using the confluent framing.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf []byte
+	for _, m := range metrics {
+		var err error
+		if buf, err = s.serialize(buf, m); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (s *Serializer) serialize(buf []byte, m telegraf.Metric) ([]byte, error) {
+	// The latest schema is fetched once and used for the lifetime of the
+	// serializer.
+	if s.schema == nil {
+		schema, err := s.registry.LatestSchema(s.SchemaSubject)
+		if err != nil {
+			return nil, err
+		}
+		s.schema = schema
+	}
+
+	record, err := s.record(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Framing == "confluent" {
+		buf = commonavro.AppendHeader(buf, s.schema.ID)
+	}
+	buf, err = s.schema.Codec.BinaryFromNative(buf, record)
+	if err != nil {
+		return nil, fmt.Errorf("encoding metric %q failed: %w", m.Name(), err)
+	}
+	return buf, nil
+}
+
+func (s *Serializer) record(m telegraf.Metric) (map[string]interface{}, error) {
+	record := make(map[string]interface{}, len(s.schema.Fields))
+	for _, f := range s.schema.Fields {
+		var value interface{}
+		var found bool
+		switch f.Name {
+		case s.MeasurementField:
+			value, found = m.Name(), true
+		case s.TimestampField:
+			value, found = m.Time(), true
+		default:
+			if value, found = m.GetField(f.Name); !found {
+				value, found = m.GetTag(f.Name)
+			}
+		}
+
+		if !found {
+			// Leave the field to its default or null if possible
+			if f.HasDefault {
+				continue
+			}
+			if contains(f.Types, "null") {
+				record[f.Name] = nil
+				continue
+			}
+			return nil, fmt.Errorf("no value for record field %q in metric %q", f.Name, m.Name())
+		}
+
+		v, typ, err := s.convert(value, f.Types)
+		if err != nil {
+			return nil, fmt.Errorf("record field %q of metric %q: %w", f.Name, m.Name(), err)
+		}
+		if f.IsUnion() {
+			v = goavro.Union(typ, v)
+		}
+		record[f.Name] = v
+	}
+	return record, nil
+}
+
+// convert returns the value converted to the first of the given types
+// matching the value's type. If there is none, the first type the value can
+// be converted to is used.
+func (s *Serializer) convert(value interface{}, types []string) (interface{}, string, error) {
+	for _, typ := range types {
+		if v, ok := s.convertStrict(value, typ); ok {
+			return v, typ, nil
+		}
+	}
+	for _, typ := range types {
+		if v, ok := convertLenient(value, typ); ok {
+			return v, typ, nil
+		}
+	}
+	return nil, "", fmt.Errorf("cannot convert %T to any of %v", value, types)
+}
+
+func (s *Serializer) convertStrict(value interface{}, typ string) (interface{}, bool) {
+	switch v := value.(type) {
+	case float64:
+		switch typ {
+		case "double":
+			return v, true
+		case "float":
+			return float32(v), true
+		}
+	case int64:
+		switch typ {
+		case "long":
+			return v, true
+		case "int":
+			if v >= math.MinInt32 && v <= math.MaxInt32 {
+				return int32(v), true
+			}
+		}
+	case uint64:
+		switch typ {
+		case "long":
+			if v <= math.MaxInt64 {
+				return int64(v), true
+			}
+		case "int":
+			if v <= math.MaxInt32 {
+				return int32(v), true
+			}
+		}
+	case bool:
+		if typ == "boolean" {
+			return v, true
+		}
+	case string:
+		if typ == "string" {
+			return v, true
+		}
+	case time.Time:
+		switch typ {
+		case "long.timestamp-millis", "long.timestamp-micros":
+			return v, true
+		case "long":
+			return s.timestamp(v), true
+		}
+	}
+	return nil, false
+}
+
+func convertLenient(value interface{}, typ string) (interface{}, bool) {
+	switch typ {
+	case "null":
+		return nil, false
+	case "double", "float":
+		var f float64
+		switch v := value.(type) {
+		case int64:
+			f = float64(v)
+		case uint64:
+			f = float64(v)
+		case string:
+			var err error
+			if f, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+		if typ == "float" {
+			return float32(f), true
+		}
+		return f, true
+	case "long", "int":
+		var i int64
+		switch v := value.(type) {
+		case string:
+			var err error
+			if i, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return nil, false
+			}
+		case bool:
+			if v {
+				i = 1
+			}
+		default:
+			return nil, false
+		}
+		if typ == "int" {
+			if i < math.MinInt32 || i > math.MaxInt32 {
+				return nil, false
+			}
+			return int32(i), true
+		}
+		return i, true
+	case "boolean":
+		if v, ok := value.(string); ok {
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, true
+			}
+		}
+		return nil, false
+	case "string":
+		if v, ok := value.(time.Time); ok {
+			return v.Format(time.RFC3339Nano), true
+		}
+		return fmt.Sprintf("%v", value), true
+	case "bytes":
+		if v, ok := value.(string); ok {
+			return []byte(v), true
+		}
+		return nil, false
+	}
+
+	// Leave the validation of other types, e.g. enums, to the codec
+	return value, true
+}
+
+func (s *Serializer) timestamp(t time.Time) int64 {
+	switch s.TimestampFormat {
+	case "unix_ms":
+		return t.UnixNano() / int64(time.Millisecond)
+	case "unix_us":
+		return t.UnixNano() / int64(time.Microsecond)
+	case "unix_ns":
+		return t.UnixNano()
+	}
+	return t.Unix()
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	registry.Add("avro",
+		func() registry.Serializer {
+			return &Serializer{}
+		},
+	)
+}
+
+// InitFromConfig is a compatibility function to construct the serializer the old way
+func (s *Serializer) InitFromConfig(cfg *registry.Config) error {
+	s.SchemaRegistry = cfg.AvroSchemaRegistry
+	s.SchemaSubject = cfg.AvroSchemaSubject
+	s.SchemaFile = cfg.AvroSchemaFile
+	s.SchemaID = cfg.AvroSchemaID
+	s.Framing = cfg.AvroFraming
+	s.MeasurementField = cfg.AvroMeasurementField
+	s.TimestampField = cfg.AvroTimestampField
+	s.TimestampFormat = cfg.AvroTimestampFormat
+
+	return s.Init()
+}
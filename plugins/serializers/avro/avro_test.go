@@ -0,0 +1,195 @@
+package avro
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	commonavro "github.com/influxdata/telegraf/plugins/common/avro"
+)
+
+func decode(t *testing.T, buf []byte) (int, map[string]interface{}) {
+	id, payload, err := commonavro.SplitMessage(buf)
+	require.NoError(t, err)
+
+	schema, err := commonavro.NewSchemaFromFile(id, "testdata/cpu.avsc")
+	require.NoError(t, err)
+	native, remaining, err := schema.Codec.NativeFromBinary(payload)
+	require.NoError(t, err)
+	require.Empty(t, remaining)
+
+	return id, native.(map[string]interface{})
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name       string
+		serializer *Serializer
+		expected   string
+	}{
+		{
+			name:       "no schema",
+			serializer: &Serializer{},
+			expected:   "either avro_schema_registry or avro_schema_file must be specified",
+		},
+		{
+			name:       "registry without subject",
+			serializer: &Serializer{SchemaRegistry: "http://localhost:8081"},
+			expected:   "using a schema registry requires a subject",
+		},
+		{
+			name:       "file without schema ID",
+			serializer: &Serializer{SchemaFile: "testdata/cpu.avsc"},
+			expected:   "the confluent framing requires a schema ID",
+		},
+		{
+			name:       "invalid timestamp format",
+			serializer: &Serializer{SchemaFile: "testdata/cpu.avsc", Framing: "none", TimestampFormat: "RFC3339"},
+			expected:   "invalid timestamp format \"RFC3339\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.serializer.Init(), tt.expected)
+		})
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	s := &Serializer{
+		SchemaFile:       "testdata/cpu.avsc",
+		SchemaID:         3,
+		MeasurementField: "name",
+		TimestampField:   "time",
+	}
+	require.NoError(t, s.Init())
+
+	now := time.UnixMilli(1656410400123)
+	tests := []struct {
+		name     string
+		input    telegraf.Metric
+		expected map[string]interface{}
+	}{
+		{
+			name: "all fields",
+			input: metric.New(
+				"cpu",
+				map[string]string{"host": "server01", "cpu": "2", "state": "FAILED"},
+				map[string]interface{}{"usage": 42.5, "cores": uint64(8), "unused": "dropped"},
+				now,
+			),
+			expected: map[string]interface{}{
+				"name":  "cpu",
+				"time":  now.UTC(),
+				"host":  "server01",
+				"cpu":   map[string]interface{}{"int": int32(2)},
+				"usage": map[string]interface{}{"double": 42.5},
+				"state": "FAILED",
+				"cores": int64(8),
+			},
+		},
+		{
+			name: "defaults and nulls",
+			input: metric.New(
+				"cpu",
+				map[string]string{"host": "server01"},
+				map[string]interface{}{"usage": int64(42), "cores": int64(8)},
+				now,
+			),
+			expected: map[string]interface{}{
+				"name":  "cpu",
+				"time":  now.UTC(),
+				"host":  "server01",
+				"cpu":   nil,
+				"usage": map[string]interface{}{"long": int64(42)},
+				"state": "OK",
+				"cores": int64(8),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := s.Serialize(tt.input)
+			require.NoError(t, err)
+
+			id, actual := decode(t, buf)
+			require.Equal(t, 3, id)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestSerializeMissingValue(t *testing.T) {
+	s := &Serializer{
+		SchemaFile:     "testdata/cpu.avsc",
+		Framing:        "none",
+		TimestampField: "time",
+	}
+	require.NoError(t, s.Init())
+
+	m := metric.New("cpu", map[string]string{"host": "server01"}, map[string]interface{}{"cores": 8}, time.Now())
+	_, err := s.Serialize(m)
+	require.ErrorContains(t, err, "no value for record field \"name\" in metric \"cpu\"")
+}
+
+func TestSerializeBatchSchemaRegistry(t *testing.T) {
+	schema, err := os.ReadFile("testdata/cpu.avsc")
+	require.NoError(t, err)
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/subjects/cpu-value/versions/latest" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		response := map[string]interface{}{
+			"subject": "cpu-value",
+			"version": 1,
+			"id":      21,
+			"schema":  string(schema),
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+	defer server.Close()
+
+	s := &Serializer{
+		SchemaRegistry:   server.URL,
+		SchemaSubject:    "cpu-value",
+		MeasurementField: "name",
+		TimestampField:   "time",
+	}
+	require.NoError(t, s.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.0, "cores": 1}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": 2.0, "cores": 2}, time.Unix(0, 0)),
+	}
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, 1, requests)
+
+	codec, err := goavro.NewCodec(string(schema))
+	require.NoError(t, err)
+	for _, expected := range []string{"a", "b"} {
+		id, payload, err := commonavro.SplitMessage(buf)
+		require.NoError(t, err)
+		require.Equal(t, 21, id)
+
+		native, remaining, err := codec.NativeFromBinary(payload)
+		require.NoError(t, err)
+		require.Equal(t, expected, native.(map[string]interface{})["host"])
+		buf = remaining
+	}
+	require.Empty(t, buf)
+}
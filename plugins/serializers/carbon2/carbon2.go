@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
 )
 
 type format string
@@ -30,21 +31,35 @@ const (
 )
 
 type Serializer struct {
+	Format              string `toml:"carbon2_format"`
+	SanitizeReplaceChar string `toml:"carbon2_sanitize_replace_char"`
+
 	metricsFormat    format
 	sanitizeReplacer *strings.Replacer
 }
 
 func NewSerializer(metricsFormat string, sanitizeReplaceChar string) (*Serializer, error) {
-	if sanitizeReplaceChar == "" {
-		sanitizeReplaceChar = DefaultSanitizeReplaceChar
-	} else if len(sanitizeReplaceChar) > 1 {
-		return nil, errors.New("sanitize replace char has to be a singular character")
+	s := &Serializer{
+		Format:              metricsFormat,
+		SanitizeReplaceChar: sanitizeReplaceChar,
+	}
+	if err := s.Init(); err != nil {
+		return nil, err
 	}
+	return s, nil
+}
 
-	var f = format(metricsFormat)
+func (s *Serializer) Init() error {
+	if s.SanitizeReplaceChar == "" {
+		s.SanitizeReplaceChar = DefaultSanitizeReplaceChar
+	} else if len(s.SanitizeReplaceChar) > 1 {
+		return errors.New("sanitize replace char has to be a singular character")
+	}
+
+	var f = format(s.Format)
 
 	if _, ok := formats[f]; !ok {
-		return nil, fmt.Errorf("unknown carbon2 format: %s", f)
+		return fmt.Errorf("unknown carbon2 format: %s", f)
 	}
 
 	// When unset, default to field separate.
@@ -52,10 +67,10 @@ func NewSerializer(metricsFormat string, sanitizeReplaceChar string) (*Serialize
 		f = Carbon2FormatFieldSeparate
 	}
 
-	return &Serializer{
-		metricsFormat:    f,
-		sanitizeReplacer: createSanitizeReplacer(sanitizedChars, rune(sanitizeReplaceChar[0])),
-	}, nil
+	s.metricsFormat = f
+	s.sanitizeReplacer = createSanitizeReplacer(sanitizedChars, rune(s.SanitizeReplaceChar[0]))
+
+	return nil
 }
 
 func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
@@ -174,3 +189,19 @@ func createSanitizeReplacer(sanitizedChars string, replaceChar rune) *strings.Re
 	}
 	return strings.NewReplacer(sanitizeCharPairs...)
 }
+
+func init() {
+	registry.Add("carbon2",
+		func() registry.Serializer {
+			return &Serializer{}
+		},
+	)
+}
+
+// InitFromConfig is a compatibility function to construct the serializer the old way
+func (s *Serializer) InitFromConfig(cfg *registry.Config) error {
+	s.Format = cfg.Carbon2Format
+	s.SanitizeReplaceChar = cfg.Carbon2SanitizeReplaceChar
+
+	return s.Init()
+}
@@ -0,0 +1,190 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
+)
+
+// Attributes defined by the CloudEvents specification that cannot be used
+// for extensions
+var reserved = map[string]bool{
+	"data":            true,
+	"data_base64":     true,
+	"datacontenttype": true,
+	"dataschema":      true,
+	"id":              true,
+	"source":          true,
+	"specversion":     true,
+	"subject":         true,
+	"time":            true,
+	"type":            true,
+}
+
+// Serializer creates CloudEvents 1.0 in the JSON event format. Single metrics
+// are serialized in structured mode while batches of metrics result in a
+// JSON array of events according to the batch mode.
+type Serializer struct {
+	Source        string   `toml:"cloudevents_source"`
+	SourceTag     string   `toml:"cloudevents_source_tag"`
+	EventType     string   `toml:"cloudevents_type"`
+	IDGeneration  string   `toml:"cloudevents_id"`
+	ExtensionTags []string `toml:"cloudevents_extension_tags"`
+
+	extensions map[string]string
+}
+
+func (s *Serializer) Init() error {
+	if s.Source == "" {
+		s.Source = "telegraf"
+	}
+	if s.EventType == "" {
+		s.EventType = "com.influxdata.telegraf.metric"
+	}
+
+	switch s.IDGeneration {
+	case "":
+		s.IDGeneration = "uuid"
+	case "uuid", "hash":
+	default:
+		return fmt.Errorf("invalid id generation %q", s.IDGeneration)
+	}
+
+	s.extensions = make(map[string]string, len(s.ExtensionTags))
+	for _, tag := range s.ExtensionTags {
+		name := extensionName(tag)
+		if name == "" {
+			return fmt.Errorf("tag %q cannot be used as extension attribute", tag)
+		}
+		if reserved[name] {
+			return fmt.Errorf("extension attribute %q of tag %q conflicts with a context attribute", name, tag)
+		}
+		if other, found := s.extensions[name]; found {
+			return fmt.Errorf("tags %q and %q map to the same extension attribute %q", other, tag, name)
+		}
+		s.extensions[name] = tag
+	}
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	event, err := s.createEvent(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return append(serialized, '\n'), nil
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	events := make([]map[string]interface{}, 0, len(metrics))
+	for _, m := range metrics {
+		event, err := s.createEvent(m)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return json.Marshal(events)
+}
+
+func (s *Serializer) createEvent(metric telegraf.Metric) (map[string]interface{}, error) {
+	id, err := s.id(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	source := s.Source
+	if s.SourceTag != "" {
+		if v, found := metric.GetTag(s.SourceTag); found {
+			source = v
+		}
+	}
+
+	event := map[string]interface{}{
+		"specversion":     "1.0",
+		"id":              id,
+		"source":          source,
+		"type":            s.EventType,
+		"time":            metric.Time().UTC().Format(time.RFC3339Nano),
+		"datacontenttype": "application/json",
+		"data":            createData(metric),
+	}
+	for name, tag := range s.extensions {
+		if v, found := metric.GetTag(tag); found {
+			event[name] = v
+		}
+	}
+
+	return event, nil
+}
+
+func (s *Serializer) id(metric telegraf.Metric) (string, error) {
+	if s.IDGeneration == "hash" {
+		// Identical metrics, e.g. when resent on a retry, get the same ID
+		return fmt.Sprintf("%016x-%d", metric.HashID(), metric.Time().UnixNano()), nil
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", fmt.Errorf("generating event id failed: %w", err)
+	}
+	return id.String(), nil
+}
+
+func createData(metric telegraf.Metric) map[string]interface{} {
+	tags := make(map[string]string, len(metric.TagList()))
+	for _, tag := range metric.TagList() {
+		tags[tag.Key] = tag.Value
+	}
+
+	fields := make(map[string]interface{}, len(metric.FieldList()))
+	for _, field := range metric.FieldList() {
+		if fv, ok := field.Value.(float64); ok {
+			// JSON does not support these special values
+			if math.IsNaN(fv) || math.IsInf(fv, 0) {
+				continue
+			}
+		}
+		fields[field.Key] = field.Value
+	}
+
+	return map[string]interface{}{
+		"name":   metric.Name(),
+		"tags":   tags,
+		"fields": fields,
+	}
+}
+
+// extensionName converts the tag key to a valid extension attribute name
+// consisting of lower-case letters and digits only
+func extensionName(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(key) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func init() {
+	registry.Add("cloudevents",
+		func() registry.Serializer {
+			return &Serializer{}
+		},
+	)
+}
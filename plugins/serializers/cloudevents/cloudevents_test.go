@@ -0,0 +1,123 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name       string
+		serializer *Serializer
+		expected   string
+	}{
+		{
+			name:       "invalid id generation",
+			serializer: &Serializer{IDGeneration: "random"},
+			expected:   `invalid id generation "random"`,
+		},
+		{
+			name:       "reserved extension",
+			serializer: &Serializer{ExtensionTags: []string{"Source"}},
+			expected:   `extension attribute "source" of tag "Source" conflicts with a context attribute`,
+		},
+		{
+			name:       "empty extension",
+			serializer: &Serializer{ExtensionTags: []string{"_-_"}},
+			expected:   `tag "_-_" cannot be used as extension attribute`,
+		},
+		{
+			name:       "duplicate extension",
+			serializer: &Serializer{ExtensionTags: []string{"data_center", "datacenter"}},
+			expected:   `tags "data_center" and "datacenter" map to the same extension attribute "datacenter"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.EqualError(t, tt.serializer.Init(), tt.expected)
+		})
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	m := metric.New(
+		"cpu",
+		map[string]string{"host": "localhost", "data_center": "eu-1"},
+		map[string]interface{}{"usage_idle": 91.5},
+		time.Unix(1656410400, 123456789),
+	)
+
+	serializer := &Serializer{
+		SourceTag:     "host",
+		IDGeneration:  "hash",
+		ExtensionTags: []string{"data_center", "region"},
+	}
+	require.NoError(t, serializer.Init())
+
+	actual, err := serializer.Serialize(m)
+	require.NoError(t, err)
+
+	expected := `{
+		"specversion": "1.0",
+		"id": "` + idOf(m) + `",
+		"source": "localhost",
+		"type": "com.influxdata.telegraf.metric",
+		"time": "2022-06-28T10:00:00.123456789Z",
+		"datacontenttype": "application/json",
+		"datacenter": "eu-1",
+		"data": {
+			"name": "cpu",
+			"tags": {"host": "localhost", "data_center": "eu-1"},
+			"fields": {"usage_idle": 91.5}
+		}
+	}`
+	require.JSONEq(t, expected, string(actual))
+	require.Equal(t, byte('\n'), actual[len(actual)-1])
+
+	// The hash based ID must be stable across calls
+	again, err := serializer.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, actual, again)
+}
+
+func TestSerializeBatch(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"value": 23}, time.Unix(0, 0)),
+	}
+
+	serializer := &Serializer{Source: "/telegraf/test", EventType: "com.example.metric"}
+	require.NoError(t, serializer.Init())
+
+	actual, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	var events []map[string]interface{}
+	require.NoError(t, json.Unmarshal(actual, &events))
+	require.Len(t, events, 2)
+	for i, event := range events {
+		require.Equal(t, "/telegraf/test", event["source"])
+		require.Equal(t, "com.example.metric", event["type"])
+		require.Equal(t, metrics[i].Name(), event["data"].(map[string]interface{})["name"])
+
+		id, ok := event["id"].(string)
+		require.True(t, ok)
+		_, err := uuid.FromString(id)
+		require.NoError(t, err)
+	}
+	require.NotEqual(t, events[0]["id"], events[1]["id"])
+}
+
+func idOf(m telegraf.Metric) string {
+	s := &Serializer{IDGeneration: "hash"}
+	id, _ := s.id(m)
+	return id
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
 )
 
 const DefaultTemplate = "host.tags.measurement.field"
@@ -39,12 +40,36 @@ type GraphiteTemplate struct {
 }
 
 type GraphiteSerializer struct {
-	Prefix          string
-	Template        string
-	TagSupport      bool
-	TagSanitizeMode string
-	Separator       string
-	Templates       []*GraphiteTemplate
+	Prefix          string   `toml:"prefix"`
+	Template        string   `toml:"template"`
+	TagSupport      bool     `toml:"graphite_tag_support"`
+	TagSanitizeMode string   `toml:"graphite_tag_sanitize_mode"`
+	Separator       string   `toml:"graphite_separator"`
+	Templates       []string `toml:"templates"`
+
+	templates []*GraphiteTemplate
+}
+
+func (s *GraphiteSerializer) Init() error {
+	graphiteTemplates, defaultTemplate, err := InitGraphiteTemplates(s.Templates)
+	if err != nil {
+		return err
+	}
+	s.templates = graphiteTemplates
+
+	if defaultTemplate != "" {
+		s.Template = defaultTemplate
+	}
+
+	if s.TagSanitizeMode == "" {
+		s.TagSanitizeMode = "strict"
+	}
+
+	if s.Separator == "" {
+		s.Separator = "."
+	}
+
+	return nil
 }
 
 func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
@@ -72,7 +97,7 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 		}
 	default:
 		template := s.Template
-		for _, graphiteTemplate := range s.Templates {
+		for _, graphiteTemplate := range s.templates {
 			if graphiteTemplate.Filter.Match(metric.Name()) {
 				template = graphiteTemplate.Value
 				break
@@ -332,3 +357,23 @@ func compatibleSanitize(name string, value string) string {
 	value = compatibleLeadingTildeDrop.FindStringSubmatch(value)[1]
 	return name + "=" + value
 }
+
+func init() {
+	registry.Add("graphite",
+		func() registry.Serializer {
+			return &GraphiteSerializer{}
+		},
+	)
+}
+
+// InitFromConfig is a compatibility function to construct the serializer the old way
+func (s *GraphiteSerializer) InitFromConfig(cfg *registry.Config) error {
+	s.Prefix = cfg.Prefix
+	s.Template = cfg.Template
+	s.TagSupport = cfg.GraphiteTagSupport
+	s.TagSanitizeMode = cfg.GraphiteTagSanitizeMode
+	s.Separator = cfg.GraphiteSeparator
+	s.Templates = cfg.Templates
+
+	return s.Init()
+}
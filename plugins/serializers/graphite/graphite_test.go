@@ -160,7 +160,7 @@ func TestSerializeMetricHostWithMultipleTemplates(t *testing.T) {
 	require.Equal(t, defaultTemplate, "")
 
 	s := GraphiteSerializer{
-		Templates: templates,
+		templates: templates,
 	}
 
 	buf, _ := s.Serialize(m1)
@@ -204,7 +204,7 @@ func TestSerializeMetricHostWithMultipleTemplatesWithDefault(t *testing.T) {
 	require.Equal(t, defaultTemplate, "tags.host.measurement.field")
 
 	s := GraphiteSerializer{
-		Templates: templates,
+		templates: templates,
 		Template:  defaultTemplate,
 	}
 
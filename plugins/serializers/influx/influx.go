@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
 )
 
 const MaxInt64 = int64(^uint64(0) >> 1)
@@ -58,10 +59,11 @@ func (e FieldError) Error() string {
 
 // Serializer is a serializer for line protocol.
 type Serializer struct {
-	maxLineBytes     int
-	bytesWritten     int
-	fieldSortOrder   FieldSortOrder
-	fieldTypeSupport FieldTypeSupport
+	MaxLineBytes int  `toml:"influx_max_line_bytes"`
+	SortFields   bool `toml:"influx_sort_fields"`
+	UintSupport  bool `toml:"influx_uint_support"`
+
+	bytesWritten int
 
 	buf    bytes.Buffer
 	header []byte
@@ -70,26 +72,29 @@ type Serializer struct {
 }
 
 func NewSerializer() *Serializer {
-	serializer := &Serializer{
-		fieldSortOrder: NoSortFields,
-
-		header: make([]byte, 0, 50),
-		footer: make([]byte, 0, 21),
-		pair:   make([]byte, 0, 50),
-	}
+	serializer := &Serializer{}
+	_ = serializer.Init()
 	return serializer
 }
 
+func (s *Serializer) Init() error {
+	s.header = make([]byte, 0, 50)
+	s.footer = make([]byte, 0, 21)
+	s.pair = make([]byte, 0, 50)
+
+	return nil
+}
+
 func (s *Serializer) SetMaxLineBytes(maxLineBytes int) {
-	s.maxLineBytes = maxLineBytes
+	s.MaxLineBytes = maxLineBytes
 }
 
 func (s *Serializer) SetFieldSortOrder(order FieldSortOrder) {
-	s.fieldSortOrder = order
+	s.SortFields = order == SortFields
 }
 
 func (s *Serializer) SetFieldTypeSupport(typeSupport FieldTypeSupport) {
-	s.fieldTypeSupport = typeSupport
+	s.UintSupport = typeSupport&UintSupport != 0
 }
 
 // Serialize writes the telegraf.Metric to a byte slice.  May produce multiple
@@ -216,7 +221,7 @@ func (s *Serializer) writeMetric(w io.Writer, m telegraf.Metric) error {
 
 	s.buildFooter(m)
 
-	if s.fieldSortOrder == SortFields {
+	if s.SortFields {
 		sort.Slice(m.FieldList(), func(i, j int) bool {
 			return m.FieldList()[i].Key < m.FieldList()[j].Key
 		})
@@ -240,7 +245,7 @@ func (s *Serializer) writeMetric(w io.Writer, m telegraf.Metric) error {
 			bytesNeeded++
 		}
 
-		if s.maxLineBytes > 0 && bytesNeeded > s.maxLineBytes {
+		if s.MaxLineBytes > 0 && bytesNeeded > s.MaxLineBytes {
 			// Need at least one field per line, this metric cannot be fit
 			// into the max line bytes.
 			if firstField {
@@ -256,7 +261,7 @@ func (s *Serializer) writeMetric(w io.Writer, m telegraf.Metric) error {
 			firstField = true
 			bytesNeeded = len(s.header) + len(s.pair) + len(s.footer)
 
-			if bytesNeeded > s.maxLineBytes {
+			if bytesNeeded > s.MaxLineBytes {
 				return s.newMetricError(NeedMoreSpace)
 			}
 		}
@@ -300,7 +305,7 @@ func (s *Serializer) newMetricError(reason string) *MetricError {
 func (s *Serializer) appendFieldValue(buf []byte, value interface{}) ([]byte, error) {
 	switch v := value.(type) {
 	case uint64:
-		if s.fieldTypeSupport&UintSupport != 0 {
+		if s.UintSupport {
 			return appendUintField(buf, v), nil
 		}
 		if v <= uint64(MaxInt64) {
@@ -350,3 +355,20 @@ func appendStringField(buf []byte, value string) []byte {
 	buf = append(buf, '"')
 	return buf
 }
+
+func init() {
+	registry.Add("influx",
+		func() registry.Serializer {
+			return NewSerializer()
+		},
+	)
+}
+
+// InitFromConfig is a compatibility function to construct the serializer the old way
+func (s *Serializer) InitFromConfig(cfg *registry.Config) error {
+	s.MaxLineBytes = cfg.InfluxMaxLineBytes
+	s.SortFields = cfg.InfluxSortFields
+	s.UintSupport = cfg.InfluxUintSupport
+
+	return s.Init()
+}
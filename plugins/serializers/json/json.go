@@ -10,30 +10,42 @@ import (
 	jsonata "github.com/blues/jsonata-go"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
 )
 
 type Serializer struct {
-	TimestampUnits  time.Duration
-	TimestampFormat string
+	TimestampUnits  config.Duration `toml:"json_timestamp_units"`
+	TimestampFormat string          `toml:"json_timestamp_format"`
+	Transformation  string          `toml:"json_transformation"`
 
 	transformation *jsonata.Expr
 }
 
 func NewSerializer(timestampUnits time.Duration, timestampFormat, transform string) (*Serializer, error) {
 	s := &Serializer{
-		TimestampUnits:  truncateDuration(timestampUnits),
+		TimestampUnits:  config.Duration(timestampUnits),
 		TimestampFormat: timestampFormat,
+		Transformation:  transform,
 	}
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
 
-	if transform != "" {
-		e, err := jsonata.Compile(transform)
+func (s *Serializer) Init() error {
+	s.TimestampUnits = config.Duration(truncateDuration(time.Duration(s.TimestampUnits)))
+
+	if s.Transformation != "" {
+		e, err := jsonata.Compile(s.Transformation)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		s.transformation = e
 	}
 
-	return s, nil
+	return nil
 }
 
 func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
@@ -137,3 +149,20 @@ func truncateDuration(units time.Duration) time.Duration {
 		d = d * 10
 	}
 }
+
+func init() {
+	registry.Add("json",
+		func() registry.Serializer {
+			return &Serializer{}
+		},
+	)
+}
+
+// InitFromConfig is a compatibility function to construct the serializer the old way
+func (s *Serializer) InitFromConfig(cfg *registry.Config) error {
+	s.TimestampUnits = config.Duration(cfg.TimestampUnits)
+	s.TimestampFormat = cfg.TimestampFormat
+	s.Transformation = cfg.Transformation
+
+	return s.Init()
+}
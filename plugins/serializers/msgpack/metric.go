@@ -31,6 +31,11 @@ func init() {
 	msgp.RegisterExtension(-1, func() msgp.Extension { return new(MessagePackTime) })
 }
 
+// Time returns the timestamp as time.Time
+func (z *MessagePackTime) Time() time.Time {
+	return z.time
+}
+
 // ExtensionType implements the Extension interface
 func (*MessagePackTime) ExtensionType() int8 {
 	return -1
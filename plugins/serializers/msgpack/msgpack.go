@@ -2,6 +2,7 @@ package msgpack
 
 import (
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
 )
 
 // Serializer encodes metrics in MessagePack format
@@ -21,13 +22,13 @@ func marshalMetric(buf []byte, metric telegraf.Metric) ([]byte, error) {
 	}).MarshalMsg(buf)
 }
 
-// Serialize implements serializers.Serializer.Serialize
+// Serialize implements registry.Serializer.Serialize
 // github.com/influxdata/telegraf/plugins/serializers/Serializer
 func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 	return marshalMetric(nil, metric)
 }
 
-// SerializeBatch implements serializers.Serializer.SerializeBatch
+// SerializeBatch implements registry.Serializer.SerializeBatch
 // github.com/influxdata/telegraf/plugins/serializers/Serializer
 func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 	buf := make([]byte, 0)
@@ -41,3 +42,16 @@ func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 	}
 	return buf, nil
 }
+
+func init() {
+	registry.Add("msgpack",
+		func() registry.Serializer {
+			return &Serializer{}
+		},
+	)
+}
+
+// InitFromConfig is a compatibility function to construct the serializer the old way
+func (s *Serializer) InitFromConfig(_ *registry.Config) error {
+	return nil
+}
@@ -0,0 +1,152 @@
+package parquet
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/columnar"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
+)
+
+var compressionCodecs = map[string]parquet.CompressionCodec{
+	"none":   parquet.CompressionCodec_UNCOMPRESSED,
+	"snappy": parquet.CompressionCodec_SNAPPY,
+	"gzip":   parquet.CompressionCodec_GZIP,
+	"lz4":    parquet.CompressionCodec_LZ4,
+	"zstd":   parquet.CompressionCodec_ZSTD,
+}
+
+// Serializer writes each batch of metrics as a complete Parquet file with one
+// row group per measurement.
+type Serializer struct {
+	Compression string `toml:"parquet_compression"`
+
+	codec parquet.CompressionCodec
+}
+
+func (s *Serializer) Init() error {
+	if s.Compression == "" {
+		s.Compression = "snappy"
+	}
+
+	codec, found := compressionCodecs[s.Compression]
+	if !found {
+		return fmt.Errorf("invalid compression %q", s.Compression)
+	}
+	s.codec = codec
+
+	return nil
+}
+
+// WholeFile returns true as every batch is written as a complete Parquet file
+func (s *Serializer) WholeFile() bool {
+	return true
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	table := columnar.NewTable(metrics)
+
+	var buf bytes.Buffer
+	w, err := writer.NewCSVWriterFromWriter(schema(table), &buf, 1)
+	if err != nil {
+		return nil, fmt.Errorf("creating schema failed: %w", err)
+	}
+	w.CompressionType = s.codec
+
+	for _, group := range table.Groups {
+		for _, m := range group.Metrics {
+			row := make([]interface{}, 0, len(table.Columns)+2)
+			row = append(row, m.Name(), m.Time().UnixNano())
+			for i := range table.Columns {
+				v := table.Columns[i].Value(m)
+				// Parquet stores unsigned integers as annotated signed integers
+				if u, ok := v.(uint64); ok {
+					v = int64(u)
+				}
+				row = append(row, v)
+			}
+			if err := w.Write(row); err != nil {
+				return nil, fmt.Errorf("writing metric %q failed: %w", m.Name(), err)
+			}
+		}
+
+		// Use a separate row group for each measurement
+		if err := w.Flush(true); err != nil {
+			return nil, fmt.Errorf("writing row group for %q failed: %w", group.Name, err)
+		}
+	}
+
+	if err := w.WriteStop(); err != nil {
+		return nil, fmt.Errorf("finishing file failed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// schema returns the column definitions in the metadata format of the
+// Parquet library
+func schema(table *columnar.Table) []string {
+	md := make([]string, 0, len(table.Columns)+2)
+	md = append(md,
+		"name="+columnar.MeasurementColumn+", type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=REQUIRED",
+		"name="+columnar.TimeColumn+", type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, "+
+			"logicaltype.unit=NANOS, repetitiontype=REQUIRED",
+	)
+
+	// The library derives internal names from the column names ignoring the
+	// case of the first letter, so make sure we do not produce duplicates.
+	seen := map[string]bool{
+		columnar.MeasurementColumn: true,
+		columnar.TimeColumn:        true,
+	}
+	for _, c := range table.Columns {
+		name := sanitize(c.Name)
+		for i := 1; seen[strings.ToLower(name)]; i++ {
+			name = fmt.Sprintf("%s_%d", sanitize(c.Name), i)
+		}
+		seen[strings.ToLower(name)] = true
+
+		var typ string
+		switch c.Type {
+		case columnar.Bool:
+			typ = "type=BOOLEAN"
+		case columnar.Int:
+			typ = "type=INT64"
+		case columnar.Uint:
+			typ = "type=INT64, convertedtype=UINT_64"
+		case columnar.Float:
+			typ = "type=DOUBLE"
+		case columnar.String:
+			typ = "type=BYTE_ARRAY, convertedtype=UTF8"
+		}
+		md = append(md, "name="+name+", "+typ+", repetitiontype=OPTIONAL")
+	}
+	return md
+}
+
+// sanitize replaces characters that cannot be used in the metadata format
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ',', '=', ' ', '\t', '\n':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+func init() {
+	registry.Add("parquet",
+		func() registry.Serializer {
+			return &Serializer{}
+		},
+	)
+}
@@ -0,0 +1,102 @@
+package parquet
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestSerializeBatch(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": uint64(1024)}, time.Unix(0, 2)),
+		metric.New("cpu", map[string]string{"host": "a", "cpu": "cpu0"}, map[string]interface{}{"usage": 42.5, "ok": true}, time.Unix(0, 1)),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": int64(23)}, time.Unix(0, 3)),
+	}
+
+	for _, compression := range []string{"none", "snappy", "gzip", "lz4", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			serializer := &Serializer{Compression: compression}
+			require.NoError(t, serializer.Init())
+			buf, err := serializer.SerializeBatch(metrics)
+			require.NoError(t, err)
+
+			r := newReader(t, buf)
+			defer r.ReadStop()
+
+			// Check the schema and the grouping by measurement
+			columns := make([]string, 0, len(r.Footer.Schema)-1)
+			for i, e := range r.Footer.Schema[1:] {
+				columns = append(columns, r.SchemaHandler.Infos[i+1].ExName+":"+e.GetType().String())
+			}
+			require.Equal(t, []string{
+				"measurement:BYTE_ARRAY",
+				"time:INT64",
+				"cpu:BYTE_ARRAY",
+				"host:BYTE_ARRAY",
+				"ok:BOOLEAN",
+				"usage:DOUBLE",
+				"used:INT64",
+			}, columns)
+			require.Len(t, r.Footer.RowGroups, 2)
+
+			require.EqualValues(t, 3, r.GetNumRows())
+			rows, err := r.ReadByNumber(3)
+			require.NoError(t, err)
+			actual, err := json.Marshal(rows)
+			require.NoError(t, err)
+			expected := `[` +
+				`{"Measurement":"cpu","Time":1,"Cpu":"cpu0","Host":"a","Ok":true,"Usage":42.5,"Used":null},` +
+				`{"Measurement":"cpu","Time":3,"Cpu":null,"Host":"b","Ok":null,"Usage":23,"Used":null},` +
+				`{"Measurement":"mem","Time":2,"Cpu":null,"Host":"a","Ok":null,"Usage":null,"Used":1024}` +
+				`]`
+			require.JSONEq(t, expected, string(actual))
+		})
+	}
+}
+
+func TestSerializeColumnNames(t *testing.T) {
+	m := metric.New(
+		"cpu",
+		map[string]string{"time": "now", "host": "a", "some,tag": "b"},
+		map[string]interface{}{"host": "c", "measurement": 1.0, "Value": 2.0, "value": 3.0},
+		time.Unix(0, 0),
+	)
+
+	serializer := &Serializer{}
+	require.NoError(t, serializer.Init())
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+
+	r := newReader(t, buf)
+	defer r.ReadStop()
+
+	columns := make([]string, 0, len(r.SchemaHandler.Infos)-1)
+	for _, info := range r.SchemaHandler.Infos[1:] {
+		columns = append(columns, info.ExName)
+	}
+	require.Equal(t, []string{
+		"measurement", "time",
+		"host", "some_tag", "time_tag",
+		"Value", "host_field", "measurement_field", "value_1",
+	}, columns)
+}
+
+func TestInitError(t *testing.T) {
+	serializer := &Serializer{Compression: "brotli"}
+	require.EqualError(t, serializer.Init(), `invalid compression "brotli"`)
+}
+
+func newReader(t *testing.T, buf []byte) *reader.ParquetReader {
+	pf, err := buffer.NewBufferFile(buf)
+	require.NoError(t, err)
+	r, err := reader.NewParquetReader(pf, nil, 1)
+	require.NoError(t, err)
+	return r
+}
@@ -9,6 +9,7 @@ import (
 
 	dto "github.com/prometheus/client_model/go"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/influxdata/telegraf"
 )
@@ -29,6 +30,14 @@ type Metric struct {
 	Scaler    *Scaler
 	Histogram *Histogram
 	Summary   *Summary
+	Exemplar  *Exemplar
+}
+
+// Exemplar references the value of a counter to external data like traces
+type Exemplar struct {
+	Labels []LabelPair
+	Value  float64
+	Time   time.Time
 }
 
 type LabelPair struct {
@@ -122,9 +131,39 @@ func hasLabel(name string, labels []LabelPair) bool {
 	return false
 }
 
+func (c *Collection) isExemplarTag(key string) bool {
+	for _, tag := range c.config.ExemplarTags {
+		if key == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// createExemplarLabels returns the labels of the exemplar tags present in the
+// metric
+func (c *Collection) createExemplarLabels(metric telegraf.Metric) []LabelPair {
+	var labels []LabelPair
+	for _, tag := range metric.TagList() {
+		if !c.isExemplarTag(tag.Key) {
+			continue
+		}
+		name, ok := SanitizeLabelName(tag.Key)
+		if !ok {
+			continue
+		}
+		labels = append(labels, LabelPair{Name: name, Value: tag.Value})
+	}
+	return labels
+}
+
 func (c *Collection) createLabels(metric telegraf.Metric) []LabelPair {
 	labels := make([]LabelPair, 0, len(metric.TagList()))
 	for _, tag := range metric.TagList() {
+		if c.isExemplarTag(tag.Key) {
+			continue
+		}
+
 		// Ignore special tags for histogram and summary types.
 		switch metric.Type() {
 		case telegraf.Histogram:
@@ -182,6 +221,10 @@ func (c *Collection) createLabels(metric telegraf.Metric) []LabelPair {
 
 func (c *Collection) Add(metric telegraf.Metric, now time.Time) {
 	labels := c.createLabels(metric)
+	var exemplarLabels []LabelPair
+	if metric.Type() == telegraf.Counter && len(c.config.ExemplarTags) > 0 {
+		exemplarLabels = c.createExemplarLabels(metric)
+	}
 	for _, field := range metric.FieldList() {
 		metricName := MetricName(metric.Name(), field.Key, metric.Type())
 		metricName, ok := SanitizeMetricName(metricName)
@@ -226,11 +269,20 @@ func (c *Collection) Add(metric telegraf.Metric, now time.Time) {
 				continue
 			}
 
+			// Keep the last exemplar of the series if the sample has none
+			var exemplar *Exemplar
+			if len(exemplarLabels) > 0 {
+				exemplar = &Exemplar{Labels: exemplarLabels, Value: value, Time: metric.Time()}
+			} else if m != nil {
+				exemplar = m.Exemplar
+			}
+
 			m = &Metric{
-				Labels:  labels,
-				Time:    metric.Time(),
-				AddTime: now,
-				Scaler:  &Scaler{Value: value},
+				Labels:   labels,
+				Time:     metric.Time(),
+				AddTime:  now,
+				Scaler:   &Scaler{Value: value},
+				Exemplar: exemplar,
 			}
 
 			entry.Metrics[metricKey] = m
@@ -406,6 +458,21 @@ func (c *Collection) GetMetrics(entry Entry, order MetricSortOrder) []*Metric {
 	return metrics
 }
 
+func (e *Exemplar) proto() *dto.Exemplar {
+	labels := make([]*dto.LabelPair, 0, len(e.Labels))
+	for _, label := range e.Labels {
+		labels = append(labels, &dto.LabelPair{
+			Name:  proto.String(label.Name),
+			Value: proto.String(label.Value),
+		})
+	}
+	return &dto.Exemplar{
+		Label:     labels,
+		Value:     proto.Float64(e.Value),
+		Timestamp: timestamppb.New(e.Time),
+	}
+}
+
 func (c *Collection) GetProto() []*dto.MetricFamily {
 	result := make([]*dto.MetricFamily, 0, len(c.Entries))
 
@@ -438,6 +505,9 @@ func (c *Collection) GetProto() []*dto.MetricFamily {
 				m.Gauge = &dto.Gauge{Value: proto.Float64(metric.Scaler.Value)}
 			case telegraf.Counter:
 				m.Counter = &dto.Counter{Value: proto.Float64(metric.Scaler.Value)}
+				if metric.Exemplar != nil {
+					m.Counter.Exemplar = metric.Exemplar.proto()
+				}
 			case telegraf.Untyped:
 				m.Untyped = &dto.Untyped{Value: proto.Float64(metric.Scaler.Value)}
 			case telegraf.Histogram:
@@ -842,3 +842,40 @@ func TestExportTimestamps(t *testing.T) {
 		})
 	}
 }
+
+func TestExemplarTags(t *testing.T) {
+	c := NewCollection(FormatConfig{ExemplarTags: []string{"trace_id"}})
+	c.Add(testutil.MustMetric(
+		"http",
+		map[string]string{"host": "example.org", "trace_id": "abc"},
+		map[string]interface{}{"requests": 10.0},
+		time.Unix(10, 0),
+		telegraf.Counter,
+	), time.Unix(10, 0))
+	// The exemplar is kept if the next sample has none
+	c.Add(testutil.MustMetric(
+		"http",
+		map[string]string{"host": "example.org"},
+		map[string]interface{}{"requests": 12.0},
+		time.Unix(20, 0),
+		telegraf.Counter,
+	), time.Unix(20, 0))
+
+	actual := c.GetProto()
+	require.Len(t, actual, 1)
+	require.Len(t, actual[0].Metric, 1)
+
+	m := actual[0].Metric[0]
+	require.Equal(t, []*dto.LabelPair{
+		{Name: proto.String("host"), Value: proto.String("example.org")},
+	}, m.Label)
+	require.Equal(t, 12.0, m.Counter.GetValue())
+
+	exemplar := m.Counter.GetExemplar()
+	require.NotNil(t, exemplar)
+	require.Equal(t, []*dto.LabelPair{
+		{Name: proto.String("trace_id"), Value: proto.String("abc")},
+	}, exemplar.Label)
+	require.Equal(t, 10.0, exemplar.GetValue())
+	require.Equal(t, time.Unix(10, 0).UTC(), exemplar.GetTimestamp().AsTime())
+}
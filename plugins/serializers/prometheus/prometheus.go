@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"time"
 
-	"github.com/influxdata/telegraf"
 	"github.com/prometheus/common/expfmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
 )
 
 // TimestampExport controls if the output contains timestamps.
@@ -36,15 +38,39 @@ type FormatConfig struct {
 	TimestampExport TimestampExport
 	MetricSortOrder MetricSortOrder
 	StringHandling  StringHandling
+	// Tags used as exemplar labels of counters instead of series labels
+	ExemplarTags []string
 }
 
 type Serializer struct {
+	ExportTimestamp bool `toml:"prometheus_export_timestamp"`
+	SortMetrics     bool `toml:"prometheus_sort_metrics"`
+	StringAsLabel   bool `toml:"prometheus_string_as_label"`
+
 	config FormatConfig
 }
 
 func NewSerializer(config FormatConfig) (*Serializer, error) {
-	s := &Serializer{config: config}
-	return s, nil
+	s := &Serializer{
+		ExportTimestamp: config.TimestampExport == ExportTimestamp,
+		SortMetrics:     config.MetricSortOrder == SortMetrics,
+		StringAsLabel:   config.StringHandling == StringAsLabel,
+	}
+	return s, s.Init()
+}
+
+func (s *Serializer) Init() error {
+	s.config = FormatConfig{}
+	if s.ExportTimestamp {
+		s.config.TimestampExport = ExportTimestamp
+	}
+	if s.SortMetrics {
+		s.config.MetricSortOrder = SortMetrics
+	}
+	if s.StringAsLabel {
+		s.config.StringHandling = StringAsLabel
+	}
+	return nil
 }
 
 func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
@@ -68,3 +94,20 @@ func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+func init() {
+	registry.Add("prometheus",
+		func() registry.Serializer {
+			return &Serializer{}
+		},
+	)
+}
+
+// InitFromConfig is a compatibility function to construct the serializer the old way
+func (s *Serializer) InitFromConfig(cfg *registry.Config) error {
+	s.ExportTimestamp = cfg.PrometheusExportTimestamp
+	s.SortMetrics = cfg.PrometheusSortMetrics
+	s.StringAsLabel = cfg.PrometheusStringAsLabel
+
+	return s.Init()
+}
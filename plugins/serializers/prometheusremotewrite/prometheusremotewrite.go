@@ -14,6 +14,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
 )
 
 type MetricKey uint64
@@ -40,12 +41,29 @@ type FormatConfig struct {
 }
 
 type Serializer struct {
+	SortMetrics   bool `toml:"prometheus_sort_metrics"`
+	StringAsLabel bool `toml:"prometheus_string_as_label"`
+
 	config FormatConfig
 }
 
 func NewSerializer(config FormatConfig) (*Serializer, error) {
-	s := &Serializer{config: config}
-	return s, nil
+	s := &Serializer{
+		SortMetrics:   config.MetricSortOrder == SortMetrics,
+		StringAsLabel: config.StringHandling == StringAsLabel,
+	}
+	return s, s.Init()
+}
+
+func (s *Serializer) Init() error {
+	s.config = FormatConfig{}
+	if s.SortMetrics {
+		s.config.MetricSortOrder = SortMetrics
+	}
+	if s.StringAsLabel {
+		s.config.StringHandling = StringAsLabel
+	}
+	return nil
 }
 
 func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
@@ -54,6 +72,24 @@ func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 
 func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 	var buf bytes.Buffer
+	promTS, err := s.TimeSeries(metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	pb := &prompb.WriteRequest{Timeseries: promTS}
+	data, err := pb.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal protobuf: %v", err)
+	}
+	encoded := snappy.Encode(nil, data)
+	buf.Write(encoded) //nolint:revive // from buffer.go: "err is always nil"
+	return buf.Bytes(), nil
+}
+
+// TimeSeries converts the metrics to Prometheus time series with the latest
+// sample of each series in the batch.
+func (s *Serializer) TimeSeries(metrics []telegraf.Metric) ([]prompb.TimeSeries, error) {
 	var entries = make(map[MetricKey]prompb.TimeSeries)
 	for _, metric := range metrics {
 		commonLabels := s.createLabels(metric)
@@ -234,14 +270,7 @@ func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 			return false
 		})
 	}
-	pb := &prompb.WriteRequest{Timeseries: promTS}
-	data, err := pb.Marshal()
-	if err != nil {
-		return nil, fmt.Errorf("unable to marshal protobuf: %v", err)
-	}
-	encoded := snappy.Encode(nil, data)
-	buf.Write(encoded) //nolint:revive // from buffer.go: "err is always nil"
-	return buf.Bytes(), nil
+	return promTS, nil
 }
 
 func hasLabel(name string, labels []prompb.Label) bool {
@@ -341,3 +370,19 @@ func getPromTS(name string, labels []prompb.Label, value float64, ts time.Time)
 	})
 	return MakeMetricKey(labels), prompb.TimeSeries{Labels: labels, Samples: sample}
 }
+
+func init() {
+	registry.Add("prometheusremotewrite",
+		func() registry.Serializer {
+			return &Serializer{}
+		},
+	)
+}
+
+// InitFromConfig is a compatibility function to construct the serializer the old way
+func (s *Serializer) InitFromConfig(cfg *registry.Config) error {
+	s.SortMetrics = cfg.PrometheusSortMetrics
+	s.StringAsLabel = cfg.PrometheusStringAsLabel
+
+	return s.Init()
+}
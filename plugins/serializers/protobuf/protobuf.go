@@ -0,0 +1,335 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	commonproto "github.com/influxdata/telegraf/plugins/common/protobuf"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
+)
+
+// Serializer encodes metrics as protocol-buffer messages of the configured
+// type. Message fields are filled with the metric's fields or tags named after
+// the field path.
+type Serializer struct {
+	File             string   `toml:"protobuf_file"`
+	ImportPaths      []string `toml:"protobuf_import_paths"`
+	MessageType      string   `toml:"protobuf_message_type"`
+	Framing          string   `toml:"protobuf_framing"`
+	MeasurementField string   `toml:"protobuf_measurement_field"`
+	TimestampField   string   `toml:"protobuf_timestamp_field"`
+	TimestampFormat  string   `toml:"protobuf_timestamp_format"`
+	FieldSeparator   string   `toml:"protobuf_field_separator"`
+
+	descriptor protoreflect.MessageDescriptor
+}
+
+func (s *Serializer) Init() error {
+	switch s.Framing {
+	case "":
+		s.Framing = "none"
+	case "none", "length_delimited":
+	default:
+		return fmt.Errorf("invalid framing %q", s.Framing)
+	}
+
+	switch s.TimestampFormat {
+	case "":
+		s.TimestampFormat = "unix"
+	case "unix", "unix_ms", "unix_us", "unix_ns":
+	default:
+		return fmt.Errorf("invalid timestamp format %q", s.TimestampFormat)
+	}
+
+	if s.FieldSeparator == "" {
+		s.FieldSeparator = "_"
+	}
+
+	descriptor, err := commonproto.LoadMessageDescriptor(s.File, s.ImportPaths, s.MessageType)
+	if err != nil {
+		return err
+	}
+	s.descriptor = descriptor
+
+	return nil
+}
+
+func (s *Serializer) Serialize(m telegraf.Metric) ([]byte, error) {
+	return s.serialize(nil, m)
+}
+
+// SerializeBatch concatenates the serialized metrics. As messages do not
+// contain their length, this requires the length-delimited framing for more
+// than one metric.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	if s.Framing == "none" && len(metrics) > 1 {
+		return nil, errors.New("serializing a batch requires the length_delimited framing")
+	}
+
+	var buf []byte
+	for _, m := range metrics {
+		var err error
+		if buf, err = s.serialize(buf, m); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (s *Serializer) serialize(buf []byte, m telegraf.Metric) ([]byte, error) {
+	msg := dynamicpb.NewMessage(s.descriptor)
+	if _, err := s.fill(msg, "", m); err != nil {
+		return nil, fmt.Errorf("metric %q: %w", m.Name(), err)
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("encoding metric %q failed: %w", m.Name(), err)
+	}
+
+	if s.Framing == "length_delimited" {
+		return commonproto.AppendLengthDelimited(buf, data), nil
+	}
+	return append(buf, data...), nil
+}
+
+// fill sets the fields of the message for which the metric provides a value
+// and returns if any field was set. Repeated and map fields are left empty.
+func (s *Serializer) fill(msg protoreflect.Message, prefix string, m telegraf.Metric) (bool, error) {
+	var filled bool
+
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := join(prefix, string(fd.Name()))
+		if fd.IsList() || fd.IsMap() {
+			continue
+		}
+
+		if fd.Kind() == protoreflect.MessageKind && fd.Message().FullName() != commonproto.TimestampMessage {
+			nested := msg.NewField(fd)
+			ok, err := s.fill(nested.Message(), path, m)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				msg.Set(fd, nested)
+				filled = true
+			}
+			continue
+		}
+
+		var value interface{}
+		var found bool
+		switch path {
+		case s.MeasurementField:
+			value, found = m.Name(), true
+		case s.TimestampField:
+			value, found = m.Time(), true
+		default:
+			name := strings.ReplaceAll(path, ".", s.FieldSeparator)
+			if value, found = m.GetField(name); !found {
+				value, found = m.GetTag(name)
+			}
+		}
+		if !found {
+			continue
+		}
+
+		v, err := s.convert(msg, fd, value)
+		if err != nil {
+			return false, fmt.Errorf("message field %q: %w", path, err)
+		}
+		msg.Set(fd, v)
+		filled = true
+	}
+
+	return filled, nil
+}
+
+// convert returns the value converted to the type of the message field
+func (s *Serializer) convert(msg protoreflect.Message, fd protoreflect.FieldDescriptor, value interface{}) (protoreflect.Value, error) {
+	if t, ok := value.(time.Time); ok {
+		switch fd.Kind() {
+		case protoreflect.MessageKind:
+			ts := msg.NewField(fd).Message()
+			fields := ts.Descriptor().Fields()
+			ts.Set(fields.ByName("seconds"), protoreflect.ValueOfInt64(t.Unix()))
+			ts.Set(fields.ByName("nanos"), protoreflect.ValueOfInt32(int32(t.Nanosecond())))
+			return protoreflect.ValueOfMessage(ts), nil
+		case protoreflect.StringKind:
+			return protoreflect.ValueOfString(t.UTC().Format(time.RFC3339Nano)), nil
+		}
+		value = s.timestamp(t)
+	}
+
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		switch v := value.(type) {
+		case bool:
+			return protoreflect.ValueOfBool(v), nil
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return protoreflect.ValueOfBool(b), nil
+			}
+		}
+	case protoreflect.EnumKind:
+		if v, ok := value.(string); ok {
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(v)); ev != nil {
+				return protoreflect.ValueOfEnum(ev.Number()), nil
+			}
+			return protoreflect.Value{}, fmt.Errorf("unknown enum value %q", v)
+		}
+		if i, ok := toInt(value, math.MinInt32, math.MaxInt32); ok {
+			return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), nil
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if i, ok := toInt(value, math.MinInt32, math.MaxInt32); ok {
+			return protoreflect.ValueOfInt32(int32(i)), nil
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if i, ok := toInt(value, math.MinInt64, math.MaxInt64); ok {
+			return protoreflect.ValueOfInt64(i), nil
+		}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if u, ok := toUint(value, math.MaxUint32); ok {
+			return protoreflect.ValueOfUint32(uint32(u)), nil
+		}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if u, ok := toUint(value, math.MaxUint64); ok {
+			return protoreflect.ValueOfUint64(u), nil
+		}
+	case protoreflect.FloatKind:
+		if f, ok := toFloat(value); ok {
+			return protoreflect.ValueOfFloat32(float32(f)), nil
+		}
+	case protoreflect.DoubleKind:
+		if f, ok := toFloat(value); ok {
+			return protoreflect.ValueOfFloat64(f), nil
+		}
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(fmt.Sprintf("%v", value)), nil
+	case protoreflect.BytesKind:
+		if v, ok := value.(string); ok {
+			return protoreflect.ValueOfBytes([]byte(v)), nil
+		}
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("cannot convert %T to %s", value, fd.Kind())
+}
+
+func (s *Serializer) timestamp(t time.Time) int64 {
+	switch s.TimestampFormat {
+	case "unix_ms":
+		return t.UnixNano() / int64(time.Millisecond)
+	case "unix_us":
+		return t.UnixNano() / int64(time.Microsecond)
+	case "unix_ns":
+		return t.UnixNano()
+	}
+	return t.Unix()
+}
+
+func toInt(value interface{}, min, max int64) (int64, bool) {
+	var i int64
+	switch v := value.(type) {
+	case int64:
+		i = v
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, false
+		}
+		i = int64(v)
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+		i = int64(v)
+	case bool:
+		if v {
+			i = 1
+		}
+	case string:
+		var err error
+		if i, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return i, i >= min && i <= max
+}
+
+func toUint(value interface{}, max uint64) (uint64, bool) {
+	var u uint64
+	switch v := value.(type) {
+	case int64:
+		if v < 0 {
+			return 0, false
+		}
+		u = uint64(v)
+	case uint64:
+		u = v
+	case float64:
+		if v != math.Trunc(v) || v < 0 || v >= math.MaxUint64 {
+			return 0, false
+		}
+		u = uint64(v)
+	case bool:
+		if v {
+			u = 1
+		}
+	case string:
+		var err error
+		if u, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return u, u <= max
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func init() {
+	registry.Add("protobuf",
+		func() registry.Serializer {
+			return &Serializer{}
+		},
+	)
+}
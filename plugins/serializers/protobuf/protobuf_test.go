@@ -0,0 +1,156 @@
+package protobuf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	commonproto "github.com/influxdata/telegraf/plugins/common/protobuf"
+)
+
+// decode returns the JSON representation of the binary message
+func decode(t *testing.T, messageType string, buf []byte) string {
+	descriptor, err := commonproto.LoadMessageDescriptor("testdata/metric.proto", nil, messageType)
+	require.NoError(t, err)
+
+	msg := dynamicpb.NewMessage(descriptor)
+	require.NoError(t, proto.Unmarshal(buf, msg))
+	out, err := protojson.Marshal(msg)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name       string
+		serializer *Serializer
+		expected   string
+	}{
+		{
+			name:       "no definition",
+			serializer: &Serializer{MessageType: "telegraf.Metric"},
+			expected:   "no message definition file specified",
+		},
+		{
+			name:       "invalid framing",
+			serializer: &Serializer{File: "testdata/metric.proto", MessageType: "telegraf.Metric", Framing: "netstring"},
+			expected:   `invalid framing "netstring"`,
+		},
+		{
+			name:       "invalid timestamp format",
+			serializer: &Serializer{File: "testdata/metric.proto", MessageType: "telegraf.Metric", TimestampFormat: "rfc3339"},
+			expected:   `invalid timestamp format "rfc3339"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.serializer.Init(), tt.expected)
+		})
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	m := metric.New(
+		"cpu",
+		map[string]string{"source_host": "localhost", "state": "RUNNING"},
+		map[string]interface{}{
+			"usage":  91.5,
+			"count":  int64(4),
+			"bytes":  "1024",
+			"active": true,
+			"note":   uint64(42),
+			"other":  "ignored",
+		},
+		time.Unix(1656410400, 500000000),
+	)
+
+	serializer := &Serializer{
+		File:             "testdata/metric.proto",
+		MessageType:      "telegraf.Metric",
+		MeasurementField: "measurement",
+		TimestampField:   "time",
+	}
+	require.NoError(t, serializer.Init())
+
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+
+	expected := `{
+		"measurement": "cpu",
+		"source": {"host": "localhost"},
+		"time": "2022-06-28T10:00:00.500Z",
+		"usage": 91.5,
+		"count": 4,
+		"bytes": "1024",
+		"state": "RUNNING",
+		"active": true,
+		"note": "42"
+	}`
+	require.JSONEq(t, expected, decode(t, "telegraf.Metric", buf))
+}
+
+func TestSerializeConversionError(t *testing.T) {
+	serializer := &Serializer{File: "testdata/metric.proto", MessageType: "telegraf.Metric"}
+	require.NoError(t, serializer.Init())
+
+	tests := []struct {
+		name     string
+		fields   map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "out of range",
+			fields:   map[string]interface{}{"count": int64(1) << 40},
+			expected: `metric "cpu": message field "count": cannot convert int64 to int32`,
+		},
+		{
+			name:     "unknown enum value",
+			fields:   map[string]interface{}{"state": "PAUSED"},
+			expected: `metric "cpu": message field "state": unknown enum value "PAUSED"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := metric.New("cpu", map[string]string{}, tt.fields, time.Unix(0, 0))
+			_, err := serializer.Serialize(m)
+			require.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.5}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"value": int64(23)}, time.Unix(2, 0)),
+	}
+
+	serializer := &Serializer{
+		File:             "testdata/metric.proto",
+		MessageType:      "telegraf.Sample",
+		MeasurementField: "measurement",
+		TimestampField:   "time_ms",
+		TimestampFormat:  "unix_ms",
+	}
+	require.NoError(t, serializer.Init())
+
+	_, err := serializer.SerializeBatch(metrics)
+	require.EqualError(t, err, "serializing a batch requires the length_delimited framing")
+
+	serializer.Framing = "length_delimited"
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	messages, err := commonproto.SplitLengthDelimited(buf)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	require.JSONEq(t, `{"measurement": "cpu", "timeMs": "1000", "value": 42.5}`, decode(t, "telegraf.Sample", messages[0]))
+	require.JSONEq(t, `{"measurement": "mem", "timeMs": "2000", "value": 23}`, decode(t, "telegraf.Sample", messages[1]))
+}
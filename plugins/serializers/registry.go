@@ -4,282 +4,161 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/influxdata/telegraf"
-	"github.com/influxdata/telegraf/plugins/serializers/carbon2"
-	"github.com/influxdata/telegraf/plugins/serializers/csv"
-	"github.com/influxdata/telegraf/plugins/serializers/graphite"
-	"github.com/influxdata/telegraf/plugins/serializers/influx"
-	"github.com/influxdata/telegraf/plugins/serializers/json"
-	"github.com/influxdata/telegraf/plugins/serializers/msgpack"
-	"github.com/influxdata/telegraf/plugins/serializers/nowmetric"
-	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
-	"github.com/influxdata/telegraf/plugins/serializers/prometheusremotewrite"
-	"github.com/influxdata/telegraf/plugins/serializers/splunkmetric"
-	"github.com/influxdata/telegraf/plugins/serializers/wavefront"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
+
+	// Serializers provided by the deprecated constructors below
+	_ "github.com/influxdata/telegraf/plugins/serializers/carbon2"
+	_ "github.com/influxdata/telegraf/plugins/serializers/csv"
+	_ "github.com/influxdata/telegraf/plugins/serializers/graphite"
+	_ "github.com/influxdata/telegraf/plugins/serializers/influx"
+	_ "github.com/influxdata/telegraf/plugins/serializers/json"
+	_ "github.com/influxdata/telegraf/plugins/serializers/msgpack"
+	_ "github.com/influxdata/telegraf/plugins/serializers/nowmetric"
+	_ "github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	_ "github.com/influxdata/telegraf/plugins/serializers/prometheusremotewrite"
+	_ "github.com/influxdata/telegraf/plugins/serializers/splunkmetric"
+	_ "github.com/influxdata/telegraf/plugins/serializers/wavefront"
 )
 
-// SerializerOutput is an interface for output plugins that are able to
-// serialize telegraf metrics into arbitrary data formats.
-type SerializerOutput interface {
-	// SetSerializer sets the serializer function for the interface.
-	SetSerializer(serializer Serializer)
-}
+// Creator is the function to create a new serializer
+type Creator = registry.Creator
 
 // Serializer is an interface defining functions that a serializer plugin must
-// satisfy.
-//
-// Implementations of this interface should be reentrant but are not required
-// to be thread-safe.
-type Serializer interface {
-	// Serialize takes a single telegraf metric and turns it into a byte buffer.
-	// separate metrics should be separated by a newline, and there should be
-	// a newline at the end of the buffer.
-	//
-	// New plugins should use SerializeBatch instead to allow for non-line
-	// delimited metrics.
-	Serialize(metric telegraf.Metric) ([]byte, error)
+// satisfy, see registry.Serializer.
+type Serializer = registry.Serializer
 
-	// SerializeBatch takes an array of telegraf metric and serializes it into
-	// a byte buffer.  This method is not required to be suitable for use with
-	// line oriented framing.
-	SerializeBatch(metrics []telegraf.Metric) ([]byte, error)
-}
+// WholeFileSerializer is an interface for serializers producing a complete
+// file for each batch, see registry.WholeFileSerializer.
+type WholeFileSerializer = registry.WholeFileSerializer
+
+// SerializerCompatibility is an interface for backward-compatible initialization of serializers
+type SerializerCompatibility = registry.SerializerCompatibility
 
 // Config is a struct that covers the data types needed for all serializer types,
 // and can be used to instantiate _any_ of the serializers.
-type Config struct {
-	// DataFormat can be one of the serializer types listed in NewSerializer.
-	DataFormat string `toml:"data_format"`
-
-	// Carbon2 metric format.
-	Carbon2Format string `toml:"carbon2_format"`
-
-	// Character used for metric name sanitization in Carbon2.
-	Carbon2SanitizeReplaceChar string `toml:"carbon2_sanitize_replace_char"`
-
-	// Separator for CSV
-	CSVSeparator string `toml:"csv_separator"`
-
-	// Output a CSV header for naming the columns
-	CSVHeader bool `toml:"csv_header"`
-
-	// Prefix the tag and field columns for CSV format
-	CSVPrefix bool `toml:"csv_column_prefix"`
-
-	// Support tags in graphite protocol
-	GraphiteTagSupport bool `toml:"graphite_tag_support"`
-
-	// Support tags which follow the spec
-	GraphiteTagSanitizeMode string `toml:"graphite_tag_sanitize_mode"`
-
-	// Character for separating metric name and field for Graphite tags
-	GraphiteSeparator string `toml:"graphite_separator"`
-
-	// Maximum line length in bytes; influx format only
-	InfluxMaxLineBytes int `toml:"influx_max_line_bytes"`
-
-	// Sort field keys, set to true only when debugging as it less performant
-	// than unsorted fields; influx format only
-	InfluxSortFields bool `toml:"influx_sort_fields"`
-
-	// Support unsigned integer output; influx format only
-	InfluxUintSupport bool `toml:"influx_uint_support"`
-
-	// Prefix to add to all measurements, only supports Graphite
-	Prefix string `toml:"prefix"`
+// DEPRECATED: Please configure the serializers directly.
+type Config = registry.Config
 
-	// Template for converting telegraf metrics into Graphite
-	// only supports Graphite
-	Template string `toml:"template"`
+// Serializers contains the registry of all known serializers (following the new style)
+var Serializers = registry.Serializers
 
-	// Templates same Template, but multiple
-	Templates []string `toml:"templates"`
-
-	// Timestamp units to use for JSON formatted output
-	TimestampUnits time.Duration `toml:"timestamp_units"`
-
-	// Timestamp format to use for JSON and CSV formatted output
-	TimestampFormat string `toml:"timestamp_format"`
-
-	// Transformation as JSONata expression to use for JSON formatted output
-	Transformation string `toml:"transformation"`
-
-	// Include HEC routing fields for splunkmetric output
-	HecRouting bool `toml:"hec_routing"`
-
-	// Enable Splunk MultiMetric output (Splunk 8.0+)
-	SplunkmetricMultiMetric bool `toml:"splunkmetric_multi_metric"`
-
-	// Point tags to use as the source name for Wavefront (if none found, host will be used).
-	WavefrontSourceOverride []string `toml:"wavefront_source_override"`
-
-	// Use Strict rules to sanitize metric and tag names from invalid characters for Wavefront
-	// When enabled forward slash (/) and comma (,) will be accepted
-	WavefrontUseStrict bool `toml:"wavefront_use_strict"`
-
-	// Convert "_" in prefixes to "." for Wavefront
-	WavefrontDisablePrefixConversion bool `toml:"wavefront_disable_prefix_conversion"`
-
-	// Include the metric timestamp on each sample.
-	PrometheusExportTimestamp bool `toml:"prometheus_export_timestamp"`
-
-	// Sort prometheus metric families and metric samples.  Useful for
-	// debugging.
-	PrometheusSortMetrics bool `toml:"prometheus_sort_metrics"`
-
-	// Output string fields as metric labels; when false string fields are
-	// discarded.
-	PrometheusStringAsLabel bool `toml:"prometheus_string_as_label"`
+// Add adds a serializer to the registry. Usually this function is called in the plugin's init function
+func Add(name string, creator Creator) {
+	registry.Add(name, creator)
 }
 
+// SerializerOutput is an interface for output plugins that are able to
+// serialize telegraf metrics into arbitrary data formats.
+type SerializerOutput = registry.SerializerOutput
+
 // NewSerializer a Serializer interface based on the given config.
 func NewSerializer(config *Config) (Serializer, error) {
-	var err error
-	var serializer Serializer
-	switch config.DataFormat {
-	case "csv":
-		serializer, err = NewCSVSerializer(config)
-	case "influx":
-		serializer, err = NewInfluxSerializerConfig(config)
-	case "graphite":
-		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template, config.GraphiteTagSupport, config.GraphiteTagSanitizeMode, config.GraphiteSeparator, config.Templates)
-	case "json":
-		serializer, err = NewJSONSerializer(config.TimestampUnits, config.TimestampFormat, config.Transformation)
-	case "splunkmetric":
-		serializer, err = NewSplunkmetricSerializer(config.HecRouting, config.SplunkmetricMultiMetric)
-	case "nowmetric":
-		serializer, err = NewNowSerializer()
-	case "carbon2":
-		serializer, err = NewCarbon2Serializer(config.Carbon2Format, config.Carbon2SanitizeReplaceChar)
-	case "wavefront":
-		serializer, err = NewWavefrontSerializer(config.Prefix, config.WavefrontUseStrict, config.WavefrontSourceOverride, config.WavefrontDisablePrefixConversion)
-	case "prometheus":
-		serializer, err = NewPrometheusSerializer(config)
-	case "prometheusremotewrite":
-		serializer, err = NewPrometheusRemoteWriteSerializer(config)
-	case "msgpack":
-		serializer, err = NewMsgpackSerializer()
-	default:
-		err = fmt.Errorf("invalid data format: %s", config.DataFormat)
+	creator, found := Serializers[config.DataFormat]
+	if !found {
+		return nil, fmt.Errorf("invalid data format: %s", config.DataFormat)
+	}
+
+	// Try to create new-style serializers the old way...
+	// DEPRECATED: Please instantiate the serializer directly instead of using this function.
+	serializer := creator()
+	s, ok := serializer.(SerializerCompatibility)
+	if !ok {
+		return nil, fmt.Errorf("serializer for %q cannot be created the old way", config.DataFormat)
 	}
-	return serializer, err
+	return serializer, s.InitFromConfig(config)
 }
 
+// Deprecated: Use the csv serializer directly.
 func NewCSVSerializer(config *Config) (Serializer, error) {
-	return csv.NewSerializer(config.TimestampFormat, config.CSVSeparator, config.CSVHeader, config.CSVPrefix)
+	return newSerializer("csv", config)
 }
 
+// Deprecated: Use the prometheusremotewrite serializer directly.
 func NewPrometheusRemoteWriteSerializer(config *Config) (Serializer, error) {
-	sortMetrics := prometheusremotewrite.NoSortMetrics
-	if config.PrometheusExportTimestamp {
-		sortMetrics = prometheusremotewrite.SortMetrics
-	}
-
-	stringAsLabels := prometheusremotewrite.DiscardStrings
-	if config.PrometheusStringAsLabel {
-		stringAsLabels = prometheusremotewrite.StringAsLabel
-	}
-
-	return prometheusremotewrite.NewSerializer(prometheusremotewrite.FormatConfig{
-		MetricSortOrder: sortMetrics,
-		StringHandling:  stringAsLabels,
-	})
+	return newSerializer("prometheusremotewrite", config)
 }
 
+// Deprecated: Use the prometheus serializer directly.
 func NewPrometheusSerializer(config *Config) (Serializer, error) {
-	exportTimestamp := prometheus.NoExportTimestamp
-	if config.PrometheusExportTimestamp {
-		exportTimestamp = prometheus.ExportTimestamp
-	}
-
-	sortMetrics := prometheus.NoSortMetrics
-	if config.PrometheusExportTimestamp {
-		sortMetrics = prometheus.SortMetrics
-	}
-
-	stringAsLabels := prometheus.DiscardStrings
-	if config.PrometheusStringAsLabel {
-		stringAsLabels = prometheus.StringAsLabel
-	}
-
-	return prometheus.NewSerializer(prometheus.FormatConfig{
-		TimestampExport: exportTimestamp,
-		MetricSortOrder: sortMetrics,
-		StringHandling:  stringAsLabels,
-	})
+	return newSerializer("prometheus", config)
 }
 
+// Deprecated: Use the wavefront serializer directly.
 func NewWavefrontSerializer(prefix string, useStrict bool, sourceOverride []string, disablePrefixConversions bool) (Serializer, error) {
-	return wavefront.NewSerializer(prefix, useStrict, sourceOverride, disablePrefixConversions)
+	return NewSerializer(&Config{
+		DataFormat:                       "wavefront",
+		Prefix:                           prefix,
+		WavefrontUseStrict:               useStrict,
+		WavefrontSourceOverride:          sourceOverride,
+		WavefrontDisablePrefixConversion: disablePrefixConversions,
+	})
 }
 
+// Deprecated: Use the json serializer directly.
 func NewJSONSerializer(timestampUnits time.Duration, timestampFormat, transform string) (Serializer, error) {
-	return json.NewSerializer(timestampUnits, timestampFormat, transform)
+	return NewSerializer(&Config{
+		DataFormat:      "json",
+		TimestampUnits:  timestampUnits,
+		TimestampFormat: timestampFormat,
+		Transformation:  transform,
+	})
 }
 
+// Deprecated: Use the carbon2 serializer directly.
 func NewCarbon2Serializer(carbon2format string, carbon2SanitizeReplaceChar string) (Serializer, error) {
-	return carbon2.NewSerializer(carbon2format, carbon2SanitizeReplaceChar)
+	return NewSerializer(&Config{
+		DataFormat:                 "carbon2",
+		Carbon2Format:              carbon2format,
+		Carbon2SanitizeReplaceChar: carbon2SanitizeReplaceChar,
+	})
 }
 
+// Deprecated: Use the splunkmetric serializer directly.
 func NewSplunkmetricSerializer(splunkmetricHecRouting bool, splunkmetricMultimetric bool) (Serializer, error) {
-	return splunkmetric.NewSerializer(splunkmetricHecRouting, splunkmetricMultimetric)
+	return NewSerializer(&Config{
+		DataFormat:              "splunkmetric",
+		HecRouting:              splunkmetricHecRouting,
+		SplunkmetricMultiMetric: splunkmetricMultimetric,
+	})
 }
 
+// Deprecated: Use the nowmetric serializer directly.
 func NewNowSerializer() (Serializer, error) {
-	return nowmetric.NewSerializer()
+	return NewSerializer(&Config{DataFormat: "nowmetric"})
 }
 
+// Deprecated: Use the influx serializer directly.
 func NewInfluxSerializerConfig(config *Config) (Serializer, error) {
-	var sort influx.FieldSortOrder
-	if config.InfluxSortFields {
-		sort = influx.SortFields
-	}
-
-	var typeSupport influx.FieldTypeSupport
-	if config.InfluxUintSupport {
-		typeSupport = typeSupport + influx.UintSupport
-	}
-
-	s := influx.NewSerializer()
-	s.SetMaxLineBytes(config.InfluxMaxLineBytes)
-	s.SetFieldSortOrder(sort)
-	s.SetFieldTypeSupport(typeSupport)
-	return s, nil
+	return newSerializer("influx", config)
 }
 
+// Deprecated: Use the influx serializer directly.
 func NewInfluxSerializer() (Serializer, error) {
-	return influx.NewSerializer(), nil
+	return NewSerializer(&Config{DataFormat: "influx"})
 }
 
+// Deprecated: Use the graphite serializer directly.
 func NewGraphiteSerializer(prefix, template string, tagSupport bool, tagSanitizeMode string, separator string, templates []string) (Serializer, error) {
-	graphiteTemplates, defaultTemplate, err := graphite.InitGraphiteTemplates(templates)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if defaultTemplate != "" {
-		template = defaultTemplate
-	}
-
-	if tagSanitizeMode == "" {
-		tagSanitizeMode = "strict"
-	}
-
-	if separator == "" {
-		separator = "."
-	}
-
-	return &graphite.GraphiteSerializer{
-		Prefix:          prefix,
-		Template:        template,
-		TagSupport:      tagSupport,
-		TagSanitizeMode: tagSanitizeMode,
-		Separator:       separator,
-		Templates:       graphiteTemplates,
-	}, nil
+	return NewSerializer(&Config{
+		DataFormat:              "graphite",
+		Prefix:                  prefix,
+		Template:                template,
+		GraphiteTagSupport:      tagSupport,
+		GraphiteTagSanitizeMode: tagSanitizeMode,
+		GraphiteSeparator:       separator,
+		Templates:               templates,
+	})
 }
 
+// Deprecated: Use the msgpack serializer directly.
 func NewMsgpackSerializer() (Serializer, error) {
-	return msgpack.NewSerializer(), nil
+	return NewSerializer(&Config{DataFormat: "msgpack"})
+}
+
+// newSerializer creates the serializer of the given data format regardless
+// of the data format set in the config
+func newSerializer(dataformat string, config *Config) (Serializer, error) {
+	cfg := *config
+	cfg.DataFormat = dataformat
+	return NewSerializer(&cfg)
 }
@@ -0,0 +1,73 @@
+package serializers_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	_ "github.com/influxdata/telegraf/plugins/serializers/all"
+)
+
+func TestRegistry_BackwardCompatibility(t *testing.T) {
+	cfg := &serializers.Config{
+		AvroSchemaFile: "avro/testdata/cpu.avsc",
+		AvroSchemaID:   1,
+		Template:       "{{ .Name }}",
+	}
+
+	// Some serializers need certain settings to not error
+	override := map[string]map[string]interface{}{
+		"avro": {
+			"SchemaFile": cfg.AvroSchemaFile,
+			"SchemaID":   cfg.AvroSchemaID,
+		},
+		"graphite": {
+			"Template": cfg.Template,
+		},
+	}
+
+	for name, creator := range serializers.Serializers {
+		t.Logf("testing %q...", name)
+		cfg.DataFormat = name
+
+		// Create serializer the new way
+		expected := creator()
+		if _, ok := expected.(serializers.SerializerCompatibility); !ok {
+			// Formats added after the migration cannot be created the old way
+			continue
+		}
+		if settings, found := override[name]; found {
+			s := reflect.Indirect(reflect.ValueOf(expected))
+			for key, value := range settings {
+				v := reflect.ValueOf(value)
+				s.FieldByName(key).Set(v)
+			}
+		}
+		if s, ok := expected.(telegraf.Initializer); ok {
+			require.NoError(t, s.Init())
+		}
+
+		// Create serializer the old way
+		actual, err := serializers.NewSerializer(cfg)
+		require.NoError(t, err)
+
+		// Ignore all unexported fields and fields not relevant for functionality
+		stype := reflect.Indirect(reflect.ValueOf(expected)).Interface()
+		options := []cmp.Option{
+			cmpopts.IgnoreUnexported(stype),
+			cmpopts.IgnoreTypes(sync.Mutex{}),
+			cmpopts.IgnoreInterfaces(struct{ telegraf.Logger }{}),
+		}
+
+		// Do a manual comparison as require.EqualValues will also work on unexported fields
+		// that cannot be cleared or ignored.
+		diff := cmp.Diff(expected, actual, options...)
+		require.Emptyf(t, diff, "Difference for %q", name)
+	}
+}
@@ -0,0 +1,81 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/templating"
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
+)
+
+// Serializer renders metrics using Go templates
+type Serializer struct {
+	Template      string          `toml:"template"`
+	BatchTemplate string          `toml:"batch_template"`
+	Log           telegraf.Logger `toml:"-"`
+
+	tmpl      *template.Template
+	batchTmpl *template.Template
+}
+
+func (s *Serializer) Init() error {
+	if s.Template == "" {
+		return errors.New("template cannot be empty")
+	}
+
+	var err error
+	if s.tmpl, err = templating.New("template", s.Template); err != nil {
+		return fmt.Errorf("parsing template failed: %w", err)
+	}
+
+	if s.BatchTemplate != "" {
+		if s.batchTmpl, err = templating.New("batch_template", s.BatchTemplate); err != nil {
+			return fmt.Errorf("parsing batch template failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	var b bytes.Buffer
+	if err := s.tmpl.Execute(&b, templating.NewMetric(metric)); err != nil {
+		return nil, fmt.Errorf("executing template for metric %q failed: %w", metric.Name(), err)
+	}
+	return b.Bytes(), nil
+}
+
+// SerializeBatch renders all metrics at once using the batch template. In
+// case no batch template is configured, the output of the template for each
+// metric is concatenated.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var b bytes.Buffer
+	if s.batchTmpl == nil {
+		for _, m := range metrics {
+			if err := s.tmpl.Execute(&b, templating.NewMetric(m)); err != nil {
+				return nil, fmt.Errorf("executing template for metric %q failed: %w", m.Name(), err)
+			}
+		}
+		return b.Bytes(), nil
+	}
+
+	batch := make([]*templating.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		batch = append(batch, templating.NewMetric(m))
+	}
+	if err := s.batchTmpl.Execute(&b, batch); err != nil {
+		return nil, fmt.Errorf("executing batch template failed: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+func init() {
+	registry.Add("template",
+		func() registry.Serializer {
+			return &Serializer{}
+		},
+	)
+}
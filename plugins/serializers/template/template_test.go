@@ -0,0 +1,110 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestSerialize(t *testing.T) {
+	m := metric.New(
+		"cpu",
+		map[string]string{"host": "localhost", "cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": 91.5},
+		time.Unix(1656410400, 0),
+	)
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name:     "name and tags",
+			template: `{{ .Name }} on {{ .Tag "host" }}/{{ .Tag "cpu" }}`,
+			expected: "cpu on localhost/cpu0",
+		},
+		{
+			name:     "fields",
+			template: `{{ range $k, $v := .FieldList }}{{ $k }}={{ $v }}{{ end }}`,
+			expected: "usage_idle=91.5",
+		},
+		{
+			name:     "conditional",
+			template: `{{ if .HasTag "region" }}{{ .Tag "region" }}{{ else }}none{{ end }}`,
+			expected: "none",
+		},
+		{
+			name:     "time formatting",
+			template: `{{ formatTime .Time "unix_ms" }} {{ formatTime .Time.UTC "2006-01-02T15:04:05Z07:00" }}`,
+			expected: "1656410400000 2022-06-28T10:00:00Z",
+		},
+		{
+			name:     "json escaping",
+			template: `{"text": "{{ jsonEscape "load \"high\"\n" }}", "tags": {{ json .TagList }}}`,
+			expected: `{"text": "load \"high\"\n", "tags": {"cpu":"cpu0","host":"localhost"}}`,
+		},
+		{
+			name:     "json metric",
+			template: `{{ json . }}`,
+			expected: `{"fields":{"usage_idle":91.5},"name":"cpu","tags":{"cpu":"cpu0","host":"localhost"},"timestamp":1656410400}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serializer := &Serializer{Template: tt.template}
+			require.NoError(t, serializer.Init())
+
+			actual, err := serializer.Serialize(m)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(actual))
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 42}, time.Unix(0, 0)),
+		metric.New("mem", map[string]string{"host": "b"}, map[string]interface{}{"value": 23}, time.Unix(0, 0)),
+	}
+
+	serializer := &Serializer{Template: "{{ .Name }}:{{ .Field \"value\" }}\n"}
+	require.NoError(t, serializer.Init())
+	actual, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, "cpu:42\nmem:23\n", string(actual))
+
+	serializer = &Serializer{
+		Template:      "{{ .Name }}",
+		BatchTemplate: `{"count": {{ len . }}, "hosts": [{{ range $i, $m := . }}{{ if $i }}, {{ end }}"{{ $m.Tag "host" }}"{{ end }}]}`,
+	}
+	require.NoError(t, serializer.Init())
+	actual, err = serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, `{"count": 2, "hosts": ["a", "b"]}`, string(actual))
+}
+
+func TestInitError(t *testing.T) {
+	serializer := &Serializer{}
+	require.EqualError(t, serializer.Init(), "template cannot be empty")
+
+	serializer = &Serializer{Template: "{{ .Name"}
+	require.ErrorContains(t, serializer.Init(), "parsing template failed")
+
+	serializer = &Serializer{Template: "{{ .Name }}", BatchTemplate: "{{ range . }}"}
+	require.ErrorContains(t, serializer.Init(), "parsing batch template failed")
+}
+
+func TestSerializeError(t *testing.T) {
+	serializer := &Serializer{Template: "{{ .Unknown }}"}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	_, err := serializer.Serialize(m)
+	require.ErrorContains(t, err, `executing template for metric "cpu" failed`)
+}
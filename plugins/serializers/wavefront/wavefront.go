@@ -8,14 +8,15 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/outputs/wavefront" // TODO: this dependency is going the wrong way: Move MetricPoint into the serializer.
+	"github.com/influxdata/telegraf/plugins/serializers/registry"
 )
 
 // WavefrontSerializer : WavefrontSerializer struct
 type WavefrontSerializer struct {
-	Prefix                   string
-	UseStrict                bool
-	SourceOverride           []string
-	DisablePrefixConversions bool
+	Prefix                   string   `toml:"prefix"`
+	UseStrict                bool     `toml:"wavefront_use_strict"`
+	SourceOverride           []string `toml:"wavefront_source_override"`
+	DisablePrefixConversions bool     `toml:"wavefront_disable_prefix_conversion"`
 	scratch                  buffer
 	mu                       sync.Mutex // buffer mutex
 }
@@ -220,3 +221,21 @@ func (b *buffer) WriteUint64(val uint64) {
 func (b *buffer) WriteFloat64(val float64) {
 	*b = strconv.AppendFloat(*b, val, 'f', 6, 64)
 }
+
+func init() {
+	registry.Add("wavefront",
+		func() registry.Serializer {
+			return &WavefrontSerializer{}
+		},
+	)
+}
+
+// InitFromConfig is a compatibility function to construct the serializer the old way
+func (s *WavefrontSerializer) InitFromConfig(cfg *registry.Config) error {
+	s.Prefix = cfg.Prefix
+	s.UseStrict = cfg.WavefrontUseStrict
+	s.SourceOverride = cfg.WavefrontSourceOverride
+	s.DisablePrefixConversions = cfg.WavefrontDisablePrefixConversion
+
+	return nil
+}